@@ -9,7 +9,7 @@ class Ksw < Formula
   depends_on "kubernetes-cli"
 
   def install
-    system "go", "build", "-ldflags", "-s -w", "-o", bin/"ksw", "."
+    system "go", "build", "-ldflags", "-s -w -X main.buildDate=#{time.iso8601} -X main.builtBy=homebrew", "-o", bin/"ksw", "."
   end
 
   test do
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ── ksw add ────────────────────────────────────────────
+
+// execAPIVersion is the client.authentication API exec plugins added by
+// ksw add speak; aws eks get-token, gke-gcloud-auth-plugin and kubelogin
+// all do
+const execAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// addSpec is the context ksw add creates, with a cluster and a user of the
+// same name
+type addSpec struct {
+	name      string
+	server    string
+	token     string
+	exec      []string // credential plugin and its args, instead of a token
+	ca        string   // CA certificate file, embedded
+	insecure  bool     // skip TLS verification instead
+	namespace string
+}
+
+// configArgs are the kubectl config commands creating the cluster and the
+// context; the user goes in through userKubeconfig
+func (s addSpec) configArgs() [][]string {
+	cluster := []string{"set-cluster", s.name, "--server", s.server}
+	if s.ca != "" {
+		cluster = append(cluster, "--certificate-authority", s.ca, "--embed-certs=true")
+	} else if s.insecure {
+		cluster = append(cluster, "--insecure-skip-tls-verify=true")
+	}
+	ctx := []string{"set-context", s.name, "--cluster", s.name, "--user", s.name}
+	if s.namespace != "" {
+		ctx = append(ctx, "--namespace", s.namespace)
+	}
+	return [][]string{cluster, ctx}
+}
+
+// userKubeconfig is a kubeconfig holding only the user. It reaches kubectl
+// as a file rather than as set-credentials flags, so the token never shows
+// in ps or in the --debug log.
+func (s addSpec) userKubeconfig() []byte {
+	user := map[string]any{"token": s.token}
+	if s.token == "" {
+		user = map[string]any{"exec": map[string]any{"apiVersion": execAPIVersion, "command": s.exec[0], "args": s.exec[1:]}}
+	}
+	data, _ := json.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Config",
+		"users":      []any{map[string]any{"name": s.name, "user": user}},
+	})
+	return data
+}
+
+// writeTo creates the cluster, user and context in the kubeconfig file
+func (s addSpec) writeTo(kubeconfig string) error {
+	for _, args := range s.configArgs() {
+		args = append(append([]string{"config"}, args...), "--kubeconfig", kubeconfig)
+		if out, err := combinedOutput("kubectl", args...); err != nil {
+			return withExitCode(exitKubeconfig, fmt.Errorf("kubectl config %s: %s", args[1], strings.TrimSpace(string(out))))
+		}
+	}
+	return mergeKubeconfig(kubeconfig, s.userKubeconfig())
+}
+
+// mergeKubeconfig adds the entries of the kubeconfig in data to the file
+// target. data is handed to kubectl in a private temp file, and target is
+// replaced in one rename.
+func mergeKubeconfig(target string, data []byte) error {
+	// CreateTemp makes the file 0600
+	part, err := os.CreateTemp("", "ksw-add-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part.Name())
+	_, err = part.Write(data)
+	if err := errors.Join(err, part.Close()); err != nil {
+		return err
+	}
+	cmd, done := command("kubectl", "config", "view", "--raw")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+target+string(os.PathListSeparator)+part.Name())
+	merged, err := cmd.Output()
+	if err := done(err); err != nil {
+		return withExitCode(exitKubeconfig, fmt.Errorf("failed to merge into %s: %w", target, err))
+	}
+	tmp := target + ".ksw-tmp"
+	if err := os.WriteFile(tmp, merged, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// addTarget is the kubeconfig file new entries go to, the one kubectl
+// picks: the first existing file of $KUBECONFIG, else the last one
+func addTarget() string {
+	paths := kubeconfigPaths()
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return paths[len(paths)-1]
+}
+
+// kubeconfigEntryNames returns the names of the kubeconfig's clusters and
+// users, which ksw add mustn't overwrite
+func kubeconfigEntryNames() (clusters, users []string, err error) {
+	entries, err := kube.Entries()
+	if err != nil {
+		return nil, nil, err
+	}
+	for name := range entries.Clusters {
+		clusters = append(clusters, name)
+	}
+	return clusters, entries.Users, nil
+}
+
+// addContext creates s in the kubeconfig. With verify, s is first written to
+// a scratch kubeconfig and has to answer the request --verify makes; nothing
+// is saved when it doesn't.
+func addContext(s addSpec, verify bool) error {
+	contexts, err := getContexts()
+	if err != nil {
+		return err
+	}
+	clusters, users, err := kubeconfigEntryNames()
+	if err != nil {
+		return err
+	}
+	for _, taken := range []struct {
+		what  string
+		names []string
+	}{{"context", contexts}, {"cluster", clusters}, {"user", users}} {
+		for _, n := range taken.names {
+			if n == s.name {
+				return fmt.Errorf("the kubeconfig already has a %s named '%s'; pick another name", taken.what, s.name)
+			}
+		}
+	}
+
+	if verify {
+		tmp, err := os.CreateTemp("", "ksw-add-*.yaml")
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := s.writeTo(tmp.Name()); err != nil {
+			return err
+		}
+		if err := probe("--kubeconfig", tmp.Name(), "--context", s.name); err != nil {
+			return withExitCode(exitVerify, fmt.Errorf("%s didn't answer with these credentials, so nothing was saved: %v\n  Add --no-verify to save it anyway", s.server, err))
+		}
+	}
+	return s.writeTo(addTarget())
+}
+
+// handleAdd creates a context without editing YAML:
+// ksw add <name> --server <url> [--ca <file> | --insecure] [-n <namespace>]
+// [--no-verify] (--token <token>|- | -- <command> [args...]); the exec
+// plugin's argv is taken as given, so its args may hold spaces
+func handleAdd(cfg config) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: ksw add <name> --server <url> [--ca <file> | --insecure] [-n <namespace>] [--no-verify]")
+		fmt.Fprintln(os.Stderr, "               (--token <token|-> | -- <command> [args...])")
+		os.Exit(1)
+	}
+	var s addSpec
+	verify := true
+	args := os.Args[2:]
+	if sep := slices.Index(args, "--"); sep >= 0 {
+		args, s.exec = args[:sep], args[sep+1:]
+	}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--insecure":
+			s.insecure = true
+			continue
+		case a == "--no-verify":
+			verify = false
+			continue
+		case !strings.HasPrefix(a, "-"):
+			if s.name != "" {
+				usage()
+			}
+			s.name = a
+			continue
+		}
+		flag, value, ok := strings.Cut(a, "=")
+		if !ok {
+			if i+1 == len(args) {
+				usage()
+			}
+			i++
+			value = args[i]
+		}
+		switch flag {
+		case "--server":
+			s.server = value
+		case "--token":
+			s.token = value
+		case "--ca":
+			s.ca = value
+		case "-n", "--namespace":
+			s.namespace = value
+		default:
+			usage()
+		}
+	}
+	if s.name == "" || s.server == "" || (s.token == "") == (len(s.exec) == 0) {
+		usage()
+	}
+	if !strings.HasPrefix(s.server, "https://") && !strings.HasPrefix(s.server, "http://") {
+		fmt.Fprintf(os.Stderr, "%s --server wants the API server's URL, like https://10.0.0.1:6443\n", warnStyle.Render("✗"))
+		os.Exit(1)
+	}
+	if s.ca != "" && s.insecure {
+		fmt.Fprintf(os.Stderr, "%s --ca and --insecure don't go together\n", warnStyle.Render("✗"))
+		os.Exit(1)
+	}
+	if s.token == "-" {
+		// From stdin, so the token stays out of the shell history
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if s.token = strings.TrimSpace(line); s.token == "" {
+			fmt.Fprintf(os.Stderr, "%s no token on stdin: %v\n", warnStyle.Render("✗"), err)
+			os.Exit(1)
+		}
+	}
+
+	if err := addContext(s, verify); err != nil {
+		fatal(err)
+	}
+	setProvenance(&cfg, s.name, "manual", "ksw add, "+s.server)
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s Added %s %s\n", successStyle.Render("✔"), s.name, dimStyle.Render("→ "+s.server))
+	if !verify {
+		fmt.Printf("  %s\n", dimStyle.Render("Not verified; ksw check "+s.name+" tests it"))
+	}
+	fmt.Printf("  %s\n", dimStyle.Render("ksw "+s.name+" to switch to it"))
+}
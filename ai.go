@@ -2,13 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,18 +35,104 @@ type aiConfig struct {
 	AWSAuthMethod  string `json:"aws_auth_method,omitempty"` // profile | keys | env
 	AWSAccessKey   string `json:"aws_access_key,omitempty"`  // for bedrock keys auth
 	AWSSecretKey   string `json:"aws_secret_key,omitempty"`  // for bedrock keys auth
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"` // per request, default 15
+	Retries        *int   `json:"retries,omitempty"`         // on 429/5xx, default 3
+	BackoffMs      int    `json:"backoff_ms,omitempty"`      // first retry delay, doubles each time; default 1000
+	Language       string `json:"language,omitempty"`        // reply language regardless of query language, e.g. "English"
+	CABundle       string `json:"ca_bundle,omitempty"`       // PEM file trusted in addition to system roots
+	Insecure       bool   `json:"insecure,omitempty"`        // skip TLS verification (TLS-intercepting proxies)
+}
+
+// aiEnvOverrides maps KSW_AI_* variables to the settings they override for
+// the current process only; they are never written to the config file.
+var aiEnvOverrides = []struct {
+	env   string
+	field func(*aiConfig) *string
+}{
+	{"KSW_AI_PROVIDER", func(ai *aiConfig) *string { return &ai.Provider }},
+	{"KSW_AI_MODEL", func(ai *aiConfig) *string { return &ai.Model }},
+	{"KSW_AI_API_KEY", func(ai *aiConfig) *string { return &ai.APIKey }},
+}
+
+func (ai aiConfig) withEnv() aiConfig {
+	for _, o := range aiEnvOverrides {
+		if v := os.Getenv(o.env); v != "" {
+			*o.field(&ai) = v
+		}
+	}
+	return ai
+}
+
+// withoutEnv puts back the stored value of every overridden setting
+func (ai aiConfig) withoutEnv(file aiConfig) aiConfig {
+	for _, o := range aiEnvOverrides {
+		if os.Getenv(o.env) != "" {
+			*o.field(&ai) = *o.field(&file)
+		}
+	}
+	return ai
+}
+
+func (ai aiConfig) timeout() time.Duration {
+	if ai.TimeoutSeconds > 0 {
+		return time.Duration(ai.TimeoutSeconds) * time.Second
+	}
+	return 15 * time.Second
+}
+
+func (ai aiConfig) retries() int {
+	if ai.Retries != nil && *ai.Retries >= 0 {
+		return *ai.Retries
+	}
+	return defaultRetries
+}
+
+func (ai aiConfig) backoff() time.Duration {
+	if ai.BackoffMs > 0 {
+		return time.Duration(ai.BackoffMs) * time.Millisecond
+	}
+	return time.Second
 }
 
 // ── Conversational Memory ──────────────────────────────
 
 type aiMemoryEntry struct {
-	Query    string `json:"query"`
-	Action   string `json:"action"`
-	Result   string `json:"result"`
-	Time     int64  `json:"time"`
+	Query      string `json:"query"`
+	Action     string `json:"action"`
+	Result     string `json:"result"`
+	Time       int64  `json:"time"`
+	Provider   string `json:"provider,omitempty"`
+	Kubeconfig string `json:"kubeconfig,omitempty"` // $KUBECONFIG when recorded, "" = default
+}
+
+const (
+	maxMemory = 10                 // per provider + kubeconfig scope
+	memoryTTL = 7 * 24 * time.Hour // older entries are dropped
+)
+
+// inScope reports whether e belongs to the current provider and kubeconfig
+// and hasn't expired. Entries from before scoping have no provider and
+// match any provider until they expire.
+func (e aiMemoryEntry) inScope(ai aiConfig) bool {
+	if time.Since(time.Unix(e.Time, 0)) > memoryTTL {
+		return false
+	}
+	if e.Provider != "" && e.Provider != ai.Provider {
+		return false
+	}
+	return e.Kubeconfig == os.Getenv("KUBECONFIG")
 }
 
-const maxMemory = 10
+// scopedMemory returns the memory entries visible to the current session
+func scopedMemory(cfg config) []aiMemoryEntry {
+	var entries []aiMemoryEntry
+	for _, e := range cfg.AIMemory {
+		if e.inScope(cfg.AI) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
 
 // ── Response Cache ─────────────────────────────────────
 
@@ -116,20 +210,29 @@ func defaultModel(provider string) string {
 
 // ── Retry with backoff ─────────────────────────────────
 
-const maxRetries = 3
+const defaultRetries = 3
 
-// callWithRetry wraps an API call with retry logic for 429/5xx errors
-func callWithRetry(fn func() (string, int, error)) (string, error) {
+// callWithRetry wraps an API call with retry logic for 429/5xx errors.
+// Backoff waits end early when ctx is cancelled.
+func callWithRetry(ctx context.Context, ai aiConfig, fn func() (string, int, error)) (string, error) {
+	maxRetries := ai.retries()
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		result, statusCode, err := fn()
 		if err == nil {
 			return result, nil
 		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		// Retry on 429 (rate limit) or 5xx (server error)
 		if statusCode == 429 || (statusCode >= 500 && statusCode < 600) {
 			if attempt < maxRetries {
-				wait := time.Duration(1<<uint(attempt)) * time.Second // 1s, 2s, 4s
-				time.Sleep(wait)
+				wait := ai.backoff() * time.Duration(1<<uint(attempt)) // 1s, 2s, 4s by default
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return "", ctx.Err()
+				}
 				continue
 			}
 		}
@@ -145,11 +248,23 @@ func handleAI(cfg config) {
 		fmt.Fprintln(os.Stderr, "Usage: ksw ai \"<query>\"")
 		fmt.Fprintln(os.Stderr, "       ksw ai config")
 		fmt.Fprintln(os.Stderr, "       ksw ai chat")
+		fmt.Fprintln(os.Stderr, "       ksw ai summarize")
+		fmt.Fprintln(os.Stderr, "       ksw ai tidy [--yes]")
+		fmt.Fprintln(os.Stderr, "       ksw ai models [use <model>]")
+		fmt.Fprintln(os.Stderr, "       ksw ai learned [ls|rm <query>|clear]")
 		os.Exit(1)
 	}
 
 	sub := os.Args[2]
 	if sub == "config" {
+		if len(os.Args) >= 4 && os.Args[3] == "set" {
+			handleAIConfigSet(cfg)
+			return
+		}
+		if len(os.Args) >= 4 && strings.HasPrefix(os.Args[3], "--") {
+			handleAIConfigFlags(cfg)
+			return
+		}
 		handleAIConfig(cfg)
 		return
 	}
@@ -169,22 +284,47 @@ func handleAI(cfg config) {
 		os.Exit(1)
 	}
 
+	if sub == "summarize" {
+		handleAISummarize(cfg)
+		return
+	}
+	if sub == "tidy" {
+		handleAITidy(cfg)
+		return
+	}
+	if sub == "models" {
+		handleAIModels(cfg)
+		return
+	}
+	if sub == "learned" {
+		handleAILearned(cfg)
+		return
+	}
+
 	contexts, err := getContexts()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fatal(err)
 	}
 	if len(contexts) == 0 {
-		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig.")
-		os.Exit(1)
+		noContexts()
 	}
 
-	runAIQuery(query, contexts, &cfg, false)
+	if !runAIQuery(context.Background(), query, contexts, &cfg, false) {
+		os.Exit(exitAI)
+	}
 }
 
 // runAIQuery executes a single AI query and updates cfg in place.
+// Cancelling ctx aborts the provider request before anything is executed.
 // Returns false if a fatal error occurred.
-func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) bool {
+func runAIQuery(ctx context.Context, query string, contexts []string, cfg *config, chatMode bool) bool {
+	// Learned resolutions answer instantly, without calling the provider
+	if !chatMode {
+		if ctx, ok := learnedContext(*cfg, query, contexts); ok {
+			return switchFromAI(query, ctx, cfg, true)
+		}
+	}
+
 	// Check cache (only in single-shot mode)
 	if !chatMode {
 		if cached := loadCache(); cached != nil && strings.EqualFold(cached.Query, query) {
@@ -193,19 +333,21 @@ func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) boo
 		}
 	}
 
-	done := make(chan struct{})
-	if !chatMode {
-		go showSpinner(done)
-	}
-
 	candidates := preFilterContexts(query, contexts)
 	if len(candidates) == 0 {
 		candidates = contexts
 	}
 
-	chosen, raw, err := resolveContextWithAI(query, candidates, *cfg)
-	close(done)
-	time.Sleep(90 * time.Millisecond)
+	var chosen, raw string
+	var err error
+	exitIfInterrupted(runWithProgress(ctx, "ksw ai thinking", 0, func(ctx context.Context, _ *progress) error {
+		chosen, raw, err = resolveContextWithAI(ctx, query, candidates, *cfg)
+		return nil
+	}))
+	if errors.Is(err, context.Canceled) {
+		fmt.Println(dimStyle.Render("Cancelled."))
+		return true
+	}
 
 	if raw != "" && !chatMode {
 		saveCache(query, raw)
@@ -213,18 +355,24 @@ func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) boo
 
 	if err != nil {
 		if multiErr, ok := err.(*aiMultiError); ok {
-			var results []string
-			for _, act := range multiErr.actions {
-				executeAction(act, contexts, cfg)
-				results = append(results, act.Action+":"+act.Command+act.Reply)
+			results, err := executePlan(ctx, multiErr.actions, contexts, cfg)
+			if err != nil {
+				printActionError(err)
+				return false
 			}
 			saveMemory(cfg, query, "multi", strings.Join(results, " | "))
 			return true
 		}
 		if cmdErr, ok := err.(*aiCommandError); ok {
 			saveMemory(cfg, query, "command", cmdErr.command+" "+strings.Join(cmdErr.args, " "))
-			runAICommand(cmdErr.command, cmdErr.args, *cfg)
+			err := runAICommand(cmdErr.command, cmdErr.args, *cfg)
 			*cfg = loadConfig()
+			printActionError(err)
+			return true
+		}
+		if tuiErr, ok := err.(*aiTUIError); ok {
+			saveMemory(cfg, query, "tui", tuiErr.act.Group+strings.Join(tuiErr.act.Contexts, ","))
+			printActionError(openAITUI(tuiErr.act, contexts, cfg))
 			return true
 		}
 		if replyErr, ok := err.(*aiReplyError); ok {
@@ -242,6 +390,14 @@ func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) boo
 		return false
 	}
 
+	if !chatMode {
+		learnResolution(cfg, query, chosen)
+	}
+	return switchFromAI(query, chosen, cfg, false)
+}
+
+// switchFromAI switches to the context an AI query resolved to
+func switchFromAI(query, chosen string, cfg *config, learned bool) bool {
 	current := getCurrentContext()
 	if chosen == current {
 		saveMemory(cfg, query, "switch", "already on "+shortName(current))
@@ -249,9 +405,19 @@ func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) boo
 		return true
 	}
 
+	if err := checkFreeze(*cfg, current, chosen); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+		if !inChatMode {
+			os.Exit(exitFrozen)
+		}
+		return false
+	}
 	recordHistory(cfg, current, chosen)
 	if err := switchContext(chosen); err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), chosen, err)
+		if !inChatMode {
+			os.Exit(exitKubeconfig)
+		}
 		return false
 	}
 
@@ -265,7 +431,11 @@ func runAIQuery(query string, contexts []string, cfg *config, chatMode bool) boo
 			break
 		}
 	}
+	if learned {
+		alias += " " + dimStyle.Render("(learned)")
+	}
 	fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), chosen, alias)
+	runSwitchHooks(*cfg, current, chosen)
 	return true
 }
 
@@ -294,6 +464,7 @@ type chatModel struct {
 	thinking  bool
 	quitting  bool
 	spinFrame int
+	cancel    context.CancelFunc // cancels the in-flight query
 }
 
 type aiResultMsg struct {
@@ -329,6 +500,10 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if m.thinking {
+			// Ctrl+C cancels the in-flight request instead of leaving the chat
+			if msg.Type == tea.KeyCtrlC && m.cancel != nil {
+				m.cancel()
+			}
 			return m, nil
 		}
 		switch msg.Type {
@@ -353,7 +528,10 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.messages = append(m.messages, chatMsg{label: "user", text: query, time: now})
 			m.thinking = true
 			m.spinFrame = 0
+			ctx, cancel := context.WithCancel(context.Background())
+			m.cancel = cancel
 			aiCmd := func() tea.Msg {
+				defer cancel()
 				oldStdout := os.Stdout
 				r, w, _ := os.Pipe()
 				os.Stdout = w
@@ -364,7 +542,7 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					doneCh <- buf.String()
 				}()
 				cfg := loadConfig()
-				runAIQuery(query, m.contexts, &cfg, true)
+				runAIQuery(ctx, query, m.contexts, &cfg, true)
 				w.Close()
 				os.Stdout = oldStdout
 				captured := <-doneCh
@@ -398,6 +576,7 @@ func (m chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		now := time.Now().Format("15:04")
 		m.messages = append(m.messages, chatMsg{label: "ai", text: msg.output, time: now})
 		m.thinking = false
+		m.cancel = nil
 		m.cfg = loadConfig()
 		return m, nil
 	}
@@ -453,10 +632,8 @@ func (m chatModel) View() string {
 	}
 
 	if m.thinking {
-		frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-		dots := []string{"", ".", "..", "..."}
-		f := frames[m.spinFrame%len(frames)]
-		d := dots[(m.spinFrame/3)%len(dots)]
+		f := spinnerFrames[m.spinFrame%len(spinnerFrames)]
+		d := spinnerDots[(m.spinFrame/3)%len(spinnerDots)]
 		msgLines = append(msgLines, "  "+thinkStyle.Render(f+" ksw ai thinking"+d))
 		msgLines = append(msgLines, "")
 	}
@@ -512,12 +689,10 @@ func handleAIChat(cfg config) {
 
 	contexts, err := getContexts()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fatal(err)
 	}
 	if len(contexts) == 0 {
-		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig.")
-		os.Exit(1)
+		noContexts()
 	}
 
 	m := chatModel{
@@ -525,7 +700,7 @@ func handleAIChat(cfg config) {
 		contexts: contexts,
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := newProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -541,33 +716,89 @@ func handleAIChat(cfg config) {
 
 
 // executeAction runs a single AI action
-func executeAction(act aiResponse, contexts []string, cfg *config) {
+func executeAction(act aiResponse, contexts []string, cfg *config) error {
+	if err := aiAllowed(*cfg, aiActionName(act)); err != nil {
+		return err
+	}
 	switch act.Action {
 	case "command":
-		runAICommand(act.Command, act.Args, *cfg)
+		err := runAICommand(act.Command, act.Args, *cfg)
 		// Reload config in case command modified it
 		*cfg = loadConfig()
+		return err
 	case "switch":
 		chosen, err := resolveExactOrFuzzy(act.Context, contexts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
-			return
+			return err
 		}
 		current := getCurrentContext()
 		if chosen == current {
 			fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
-			return
+			return nil
+		}
+		if err := checkFreeze(*cfg, current, chosen); err != nil {
+			return err
 		}
 		recordHistory(cfg, current, chosen)
 		if err := switchContext(chosen); err != nil {
-			fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), chosen, err)
-			return
+			return fmt.Errorf("Failed to switch to '%s': %v", chosen, err)
 		}
 		_ = saveConfig(*cfg)
 		fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), chosen)
+		runSwitchHooks(*cfg, current, chosen)
 	case "reply":
 		fmt.Printf("%s\n", act.Reply)
+	case "tui":
+		return openAITUI(act, contexts, cfg)
+	}
+	return nil
+}
+
+// printActionError reports a failed action; a declined confirmation was already reported
+func printActionError(err error) {
+	if err != nil && !errors.Is(err, errNotConfirmed) {
+		fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+	}
+}
+
+// openAITUI opens the interactive selector filtered to a group or to the
+// contexts the AI picked, e.g. for "show me the payment clusters"
+func openAITUI(act aiResponse, contexts []string, cfg *config) error {
+	current := getCurrentContext()
+	var m model
+	switch {
+	case act.Group != "":
+		if _, ok := cfg.Groups[act.Group]; !ok {
+			return fmt.Errorf("Group '%s' not found", act.Group)
+		}
+		if inChatMode {
+			fmt.Printf("No puedo abrir el TUI desde el chat. Ejecuta desde tu terminal:\n  ksw group use %s\n", act.Group)
+			return nil
+		}
+		m = initialModel(contexts, current, *cfg, act.Group, false)
+	case len(act.Contexts) > 0:
+		var resolved []string
+		for _, c := range act.Contexts {
+			if ctx, err := resolveExactOrFuzzy(c, contexts); err == nil && !slices.Contains(resolved, ctx) {
+				resolved = append(resolved, ctx)
+			}
+		}
+		if len(resolved) == 0 {
+			return fmt.Errorf("None of the suggested contexts were found")
+		}
+		if inChatMode {
+			for _, ctx := range resolved {
+				fmt.Printf("  %s\n", shortName(ctx))
+			}
+			return nil
+		}
+		m = initialModel(resolved, current, *cfg, "", false)
+	default:
+		return fmt.Errorf("AI returned a tui action without a group or contexts")
 	}
+	runTUI(m, current)
+	*cfg = loadConfig()
+	return nil
 }
 
 // executeRawResponse parses and executes a cached raw response
@@ -576,23 +807,175 @@ func executeRawResponse(raw string, contexts []string, cfg *config) {
 	if err != nil {
 		return
 	}
-	for _, act := range actions {
-		executeAction(act, contexts, cfg)
+	if len(actions) > 1 {
+		_, err = executePlan(context.Background(), actions, contexts, cfg)
+	} else {
+		err = executeAction(actions[0], contexts, cfg)
+	}
+	printActionError(err)
+}
+
+// ── Multi-action plans ─────────────────────────────────
+
+// aiCommandArgs is the number of args each command runAICommand accepts needs
+var aiCommandArgs = map[string]int{
+	"list": 0, "history": 0,
+	"group ls": 0, "group add": 2, "group rm": 1, "group add-ctx": 2, "group use": 1,
+	"alias ls": 0, "alias add": 2, "alias rm": 1,
+	"pin ls": 0, "pin add": 1, "pin rm": 1, "pin use": 0,
+	"rename": 2, "eks kubeconfig": 0, "eks kubeconfig --profile": 1,
+}
+
+// describeAction is the one-line label shown for a plan step
+func describeAction(act aiResponse) string {
+	switch act.Action {
+	case "switch":
+		return "switch " + act.Context
+	case "command":
+		return strings.TrimSpace(act.Command + " " + strings.Join(act.Args, " "))
+	case "tui":
+		return "open selector"
+	default:
+		return act.Action
+	}
+}
+
+// validatePlan checks every action before any of them runs, so a plan that
+// would fail halfway is rejected up front
+func validatePlan(actions []aiResponse, contexts []string, cfg config) error {
+	exists := func(target string) bool {
+		return slices.ContainsFunc(contexts, func(ctx string) bool {
+			return shortName(ctx) == target || ctx == target || strings.Contains(ctx, target)
+		})
+	}
+	groups := make(map[string]bool, len(cfg.Groups))
+	for g := range cfg.Groups {
+		groups[g] = true
+	}
+
+	for i, act := range actions {
+		var err error
+		switch act.Action {
+		case "switch":
+			_, err = resolveExactOrFuzzy(act.Context, contexts)
+		case "reply":
+		case "tui":
+			if act.Group != "" && !groups[act.Group] {
+				err = fmt.Errorf("group '%s' not found", act.Group)
+			} else if act.Group == "" && len(act.Contexts) == 0 {
+				err = fmt.Errorf("tui action without a group or contexts")
+			}
+		case "command":
+			n, ok := aiCommandArgs[act.Command]
+			switch {
+			case strings.HasPrefix(act.Command, "history "):
+			case !ok:
+				err = fmt.Errorf("command '%s' not supported via AI yet", act.Command)
+			case len(act.Args) < n:
+				err = fmt.Errorf("%s needs %d argument(s)", act.Command, n)
+			case act.Command == "rename" || act.Command == "pin add":
+				if !exists(act.Args[0]) {
+					err = fmt.Errorf("context '%s' not found", act.Args[0])
+				}
+			case act.Command == "alias add" || act.Command == "group add-ctx":
+				if !exists(act.Args[1]) {
+					err = fmt.Errorf("context '%s' not found", act.Args[1])
+				}
+			case act.Command == "group use" || act.Command == "group rm":
+				for _, g := range act.Args {
+					if !groups[g] {
+						err = fmt.Errorf("group '%s' not found", g)
+					}
+				}
+			}
+			if err == nil && (act.Command == "group add" || act.Command == "group add-ctx") {
+				groups[act.Args[0]] = true
+			}
+		default:
+			err = fmt.Errorf("unexpected action '%s'", act.Action)
+		}
+		if err == nil {
+			err = aiAllowed(cfg, aiActionName(act))
+		}
+		if err != nil {
+			return fmt.Errorf("Plan rejected, step %d (%s): %v", i+1, describeAction(act), err)
+		}
+	}
+	return nil
+}
+
+// executePlan validates and runs a multi-action AI response step by step.
+// If a step fails or Ctrl+C is pressed, the ksw config and the current
+// context are restored to what they were before the plan started.
+// Kubeconfig edits made by earlier steps (renames) are kept.
+func executePlan(ctx context.Context, actions []aiResponse, contexts []string, cfg *config) ([]string, error) {
+	if err := validatePlan(actions, contexts, *cfg); err != nil {
+		return nil, err
+	}
+
+	savedConfig, readErr := os.ReadFile(configPath())
+	startContext := getCurrentContext()
+	rollback := func() {
+		switch {
+		case readErr == nil:
+			_ = os.WriteFile(configPath(), savedConfig, 0600)
+		case os.IsNotExist(readErr):
+			_ = os.Remove(configPath())
+		}
+		*cfg = loadConfig()
+		if startContext != "" && getCurrentContext() != startContext {
+			_ = switchContext(startContext)
+		}
+		fmt.Printf("%s Rolled back ksw config and context %s\n", dimStyle.Render("↺"), shortName(startContext))
+	}
+
+	// Ctrl+C between steps stops the plan instead of killing it mid-step
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var results []string
+	for i, act := range actions {
+		if ctx.Err() != nil {
+			rollback()
+			return nil, fmt.Errorf("Interrupted, %d of %d steps skipped", len(actions)-i, len(actions))
+		}
+		fmt.Println(dimStyle.Render(fmt.Sprintf("[%d/%d] %s", i+1, len(actions), describeAction(act))))
+		if err := executeAction(act, contexts, cfg); err != nil {
+			if errors.Is(err, errNotConfirmed) {
+				err = fmt.Errorf("step %d was not confirmed", i+1)
+			}
+			rollback()
+			return nil, fmt.Errorf("Plan stopped at step %d: %v", i+1, err)
+		}
+		results = append(results, act.Action+":"+act.Command+act.Reply)
 	}
+	return results, nil
 }
 
 // saveMemory records an AI interaction in conversational memory
 func saveMemory(cfg *config, query, action, result string) {
 	entry := aiMemoryEntry{
-		Query:  query,
-		Action: action,
-		Result: result,
-		Time:   time.Now().Unix(),
-	}
-	cfg.AIMemory = append(cfg.AIMemory, entry)
-	if len(cfg.AIMemory) > maxMemory {
-		cfg.AIMemory = cfg.AIMemory[len(cfg.AIMemory)-maxMemory:]
-	}
+		Query:      query,
+		Action:     action,
+		Result:     result,
+		Time:       time.Now().Unix(),
+		Provider:   cfg.AI.Provider,
+		Kubeconfig: os.Getenv("KUBECONFIG"),
+	}
+	// Keep other scopes' unexpired entries, and the last maxMemory of this one
+	var kept, scope []aiMemoryEntry
+	for _, e := range append(cfg.AIMemory, entry) {
+		switch {
+		case e.inScope(cfg.AI):
+			scope = append(scope, e)
+		case time.Since(time.Unix(e.Time, 0)) <= memoryTTL:
+			kept = append(kept, e)
+		}
+	}
+	if len(scope) > maxMemory {
+		scope = scope[len(scope)-maxMemory:]
+	}
+	cfg.AIMemory = append(kept, scope...)
 	_ = saveConfig(*cfg)
 }
 
@@ -986,6 +1369,126 @@ func (m configModel) View() string {
 	return b.String()
 }
 
+// handleAIConfigSet sets tuning options not covered by the interactive setup:
+//
+//	ksw ai config set timeout 60    # seconds per request
+//	ksw ai config set retries 1     # retries on 429/5xx
+//	ksw ai config set backoff 2000  # first retry delay in ms
+//	ksw ai config set ca_bundle ~/corp-ca.pem  # "" to clear
+//	ksw ai config set insecure true
+//	ksw ai config set language English  # "" to follow the query language
+func handleAIConfigSet(cfg config) {
+	if len(os.Args) < 6 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw ai config set <timeout|retries|backoff|ca_bundle|insecure|language> <value>")
+		os.Exit(1)
+	}
+	key, value := os.Args[4], os.Args[5]
+	number := func() int {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			fmt.Fprintf(os.Stderr, "%s '%s' must be a non-negative number.\n", warnStyle.Render("✗"), value)
+			os.Exit(1)
+		}
+		return n
+	}
+	switch key {
+	case "timeout":
+		cfg.AI.TimeoutSeconds = number()
+	case "retries":
+		n := number()
+		cfg.AI.Retries = &n
+	case "backoff":
+		cfg.AI.BackoffMs = number()
+	case "ca_bundle":
+		if value != "" {
+			if _, err := os.Stat(expandHome(value)); err != nil {
+				fatal(err)
+			}
+		}
+		cfg.AI.CABundle = value
+	case "insecure":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s '%s' must be true or false.\n", warnStyle.Render("✗"), value)
+			os.Exit(1)
+		}
+		cfg.AI.Insecure = b
+	case "language":
+		cfg.AI.Language = value
+	default:
+		fmt.Fprintf(os.Stderr, "%s Unknown key '%s'. Supported: timeout, retries, backoff, ca_bundle, insecure, language\n", warnStyle.Render("✗"), key)
+		os.Exit(1)
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s AI %s set to %s\n", successStyle.Render("✔"), key, value)
+}
+
+// handleAIConfigFlags configures the provider without the interactive setup:
+//
+//	ksw ai config --provider bedrock --region us-east-1 --profile dev --model X
+//	ksw ai config --provider claude --api-key sk-ant-...
+func handleAIConfigFlags(cfg config) {
+	flags := map[string]*string{
+		"--provider":   &cfg.AI.Provider,
+		"--model":      &cfg.AI.Model,
+		"--api-key":    &cfg.AI.APIKey,
+		"--region":     &cfg.AI.AWSRegion,
+		"--profile":    &cfg.AI.AWSProfile,
+		"--auth":       &cfg.AI.AWSAuthMethod,
+		"--access-key": &cfg.AI.AWSAccessKey,
+		"--secret-key": &cfg.AI.AWSSecretKey,
+		"--language":   &cfg.AI.Language,
+	}
+	prevProvider := cfg.AI.Provider
+	modelSet := false
+	for i := 3; i < len(os.Args); i++ {
+		field, ok := flags[os.Args[i]]
+		if !ok || i+1 >= len(os.Args) {
+			fmt.Fprintf(os.Stderr, "%s Unknown or incomplete flag '%s'\n", warnStyle.Render("✗"), os.Args[i])
+			fmt.Fprintln(os.Stderr, "Flags: --provider --model --api-key --region --profile --auth <profile|keys|env> --access-key --secret-key --language")
+			os.Exit(1)
+		}
+		*field = os.Args[i+1]
+		modelSet = modelSet || os.Args[i] == "--model"
+		i++
+	}
+
+	if _, ok := providerModels[cfg.AI.Provider]; !ok {
+		fmt.Fprintf(os.Stderr, "%s Unknown provider '%s'. Supported: openai, claude, gemini, bedrock\n", warnStyle.Render("✗"), cfg.AI.Provider)
+		os.Exit(1)
+	}
+	// A model from the previous provider won't work with the new one
+	if cfg.AI.Provider != prevProvider && !modelSet {
+		cfg.AI.Model = ""
+	}
+	if cfg.AI.Provider == "bedrock" {
+		if cfg.AI.AWSAuthMethod == "" {
+			cfg.AI.AWSAuthMethod = "profile"
+		}
+		if cfg.AI.AWSRegion == "" {
+			cfg.AI.AWSRegion = "us-east-1"
+		}
+	}
+
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	model := cfg.AI.Model
+	if model == "" {
+		model = defaultModel(cfg.AI.Provider)
+	}
+	fmt.Printf("%s AI configured: %s · %s\n", successStyle.Render("✔"), currentValueStyle.Render(cfg.AI.Provider), model)
+	for _, o := range aiEnvOverrides {
+		if os.Getenv(o.env) != "" {
+			fmt.Printf("  %s %s is set and overrides the saved value\n", dimStyle.Render("·"), o.env)
+		}
+	}
+}
+
 func handleAIConfig(cfg config) {
 	providers := []string{"openai", "claude", "gemini", "bedrock"}
 	authMethods := []string{"AWS Profile (SSO / cli)", "Access Key + Secret Key", "Environment variables"}
@@ -1007,7 +1510,7 @@ func handleAIConfig(cfg config) {
 		authMethods: authMethods,
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := newProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1017,11 +1520,13 @@ func handleAIConfig(cfg config) {
 // ── LLM resolution ─────────────────────────────────────
 
 type aiResponse struct {
-	Action  string   `json:"action"`
-	Context string   `json:"context,omitempty"`
-	Command string   `json:"command,omitempty"`
-	Reply   string   `json:"reply,omitempty"`
-	Args    []string `json:"args,omitempty"`
+	Action   string   `json:"action"`
+	Context  string   `json:"context,omitempty"`
+	Command  string   `json:"command,omitempty"`
+	Reply    string   `json:"reply,omitempty"`
+	Args     []string `json:"args,omitempty"`
+	Group    string   `json:"group,omitempty"`    // tui: open the selector filtered to this group
+	Contexts []string `json:"contexts,omitempty"` // tui: or to these contexts
 }
 
 type aiCommandError struct {
@@ -1041,6 +1546,15 @@ func (e *aiReplyError) Error() string {
 	return "reply:" + e.reply
 }
 
+// aiTUIError asks the caller to open the selector pre-filtered
+type aiTUIError struct {
+	act aiResponse
+}
+
+func (e *aiTUIError) Error() string {
+	return "tui:" + e.act.Group
+}
+
 // aiMultiError holds multiple actions to execute sequentially
 type aiMultiError struct {
 	actions []aiResponse
@@ -1125,30 +1639,10 @@ func parseAIResponse(raw string) ([]aiResponse, error) {
 	return nil, fmt.Errorf("could not parse AI response: %s", truncate(raw, 200))
 }
 
-func resolveContextWithAI(query string, contexts []string, cfg config) (string, string, error) {
-	ai := cfg.AI
-	model := ai.Model
-	if model == "" {
-		model = defaultModel(ai.Provider)
-	}
-
+func resolveContextWithAI(ctx context.Context, query string, contexts []string, cfg config) (string, string, error) {
 	prompt := buildPrompt(query, contexts, cfg)
 
-	var raw string
-	var err error
-
-	switch ai.Provider {
-	case "openai":
-		raw, err = callWithRetry(func() (string, int, error) { return callOpenAI(prompt, model, ai.APIKey) })
-	case "claude":
-		raw, err = callWithRetry(func() (string, int, error) { return callClaude(prompt, model, ai.APIKey) })
-	case "gemini":
-		raw, err = callWithRetry(func() (string, int, error) { return callGemini(prompt, model, ai.APIKey) })
-	case "bedrock":
-		raw, err = callWithRetry(func() (string, int, error) { return callBedrock(prompt, model, ai) })
-	default:
-		return "", "", fmt.Errorf("unknown provider '%s'", ai.Provider)
-	}
+	raw, err := callProvider(ctx, cfg.AI, prompt)
 	if err != nil {
 		return "", "", err
 	}
@@ -1174,11 +1668,42 @@ func resolveContextWithAI(query string, contexts []string, cfg config) (string,
 		return result, string(jsonStr), err
 	case "reply":
 		return "", string(jsonStr), &aiReplyError{reply: resp.Reply}
+	case "tui":
+		return "", string(jsonStr), &aiTUIError{act: resp}
 	default:
 		return "", string(jsonStr), fmt.Errorf("unexpected AI action: %s", resp.Action)
 	}
 }
 
+// callProvider sends prompt to the configured provider and returns the raw text.
+// Failures are tagged with exitAI.
+func callProvider(ctx context.Context, ai aiConfig, prompt string) (string, error) {
+	out, err := callProviderModel(ctx, ai, prompt)
+	if err != nil && ctx.Err() == nil {
+		err = withExitCode(exitAI, err)
+	}
+	return out, err
+}
+
+func callProviderModel(ctx context.Context, ai aiConfig, prompt string) (string, error) {
+	model := ai.Model
+	if model == "" {
+		model = defaultModel(ai.Provider)
+	}
+	switch ai.Provider {
+	case "openai":
+		return callWithRetry(ctx, ai, func() (string, int, error) { return callOpenAI(ctx, prompt, model, ai) })
+	case "claude":
+		return callWithRetry(ctx, ai, func() (string, int, error) { return callClaude(ctx, prompt, model, ai) })
+	case "gemini":
+		return callWithRetry(ctx, ai, func() (string, int, error) { return callGemini(ctx, prompt, model, ai) })
+	case "bedrock":
+		return callWithRetry(ctx, ai, func() (string, int, error) { return callBedrock(ctx, prompt, model, ai) })
+	default:
+		return "", fmt.Errorf("unknown provider '%s'", ai.Provider)
+	}
+}
+
 func resolveExactOrFuzzy(result string, contexts []string) (string, error) {
 	result = strings.TrimSpace(strings.Trim(result, `"'`))
 
@@ -1232,9 +1757,9 @@ func buildPrompt(query string, contexts []string, cfg config) string {
 
 	// Build conversation history
 	memoryBlock := ""
-	if len(cfg.AIMemory) > 0 {
+	if memory := scopedMemory(cfg); len(memory) > 0 {
 		var lines []string
-		for _, m := range cfg.AIMemory {
+		for _, m := range memory {
 			lines = append(lines, fmt.Sprintf("- User: \"%s\" → %s: %s", m.Query, m.Action, m.Result))
 		}
 		memoryBlock = fmt.Sprintf("\nRECENT CONVERSATION:\n%s\n", strings.Join(lines, "\n"))
@@ -1260,11 +1785,18 @@ func buildPrompt(query string, contexts []string, cfg config) string {
 	}
 
 	// Aliases
-	if len(cfg.Aliases) > 0 {
+	if len(cfg.Aliases) > 0 || len(cfg.MultiAliases) > 0 {
 		var aLines []string
 		for alias, target := range cfg.Aliases {
 			aLines = append(aLines, fmt.Sprintf("  @%s → %s", alias, shortName(target)))
 		}
+		for alias, targets := range cfg.MultiAliases {
+			shorts := make([]string, len(targets))
+			for i, t := range targets {
+				shorts[i] = shortName(t)
+			}
+			aLines = append(aLines, fmt.Sprintf("  @%s → [%s] (picker)", alias, strings.Join(shorts, ", ")))
+		}
 		stateParts = append(stateParts, "ALIASES:\n"+strings.Join(aLines, "\n"))
 	} else {
 		stateParts = append(stateParts, "ALIASES: none")
@@ -1312,6 +1844,7 @@ ACTIONS:
 1. Switch context: {"action":"switch","context":"<exact short name from list>"}
 2. Run command: {"action":"command","command":"<cmd>","args":["arg1","arg2",...]}
 3. Free reply: {"action":"reply","reply":"<your answer in the user's language>"}
+4. Open selector: {"action":"tui","group":"<existing group>"} or {"action":"tui","contexts":["<short name>",...]}
 
 AVAILABLE COMMANDS (these execute real actions):
 %s
@@ -1323,20 +1856,29 @@ RULES:
 - When user asks to CREATE a group, DO IT with "command"+"group add". Don't just suggest.
 - When user asks to ADD a context to a group, use "group add-ctx".
 - When user asks to pin/alias/unpin/rename, DO IT. Don't just suggest.
+- When user asks to SHOW/browse/pick from a set of clusters ("show me the payment clusters"), use "tui": with "group" if an existing group matches, otherwise with the matching "contexts".
 - IMPORTANT: If user asks for a CUSTOM FORMAT (table, summary, resumen, tabla, comparar, etc.), use "reply" and build the answer yourself from USER STATE. Do NOT use "command" because commands have fixed output format.
 - For questions/chat, use "reply" and answer naturally in the user's language. Use the USER STATE above to give accurate, specific answers.
 - When user asks "who are you" or "what can you do", include specific details from their state (how many groups, pins, aliases they have).
 - Pick the BEST single match for switch. Return short name EXACTLY as listed.
 - Use conversation history to understand references like "the previous one", "same but dev", "go back".
 - Return ONLY valid JSON. No text before or after.
-- FORMATTING: Keep replies concise and conversational. Use simple lists with emojis instead of markdown tables. Avoid ** bold ** markers. Think of your output as a chat message, not a document.
+- FORMATTING: Keep replies concise and conversational. Use simple lists with emojis instead of markdown tables. Avoid ** bold ** markers. Think of your output as a chat message, not a document.%s
 
 Request: %s
 
 Contexts:
 %s
 
-JSON:`, currentShort, len(contexts), stateBlock, memoryBlock, aiCommandsPrompt(), query, list)
+JSON:`, currentShort, len(contexts), stateBlock, memoryBlock, aiCommandsPrompt(), languageRule(cfg.AI), query, list)
+}
+
+// languageRule forces replies into the configured language, if any
+func languageRule(ai aiConfig) string {
+	if ai.Language == "" {
+		return ""
+	}
+	return "\n- LANGUAGE: Always write \"reply\" text in " + ai.Language + ", whatever language the request is in. This overrides \"the user's language\" above."
 }
 
 func preFilterContexts(query string, contexts []string) []string {
@@ -1370,28 +1912,9 @@ func preFilterContexts(query string, contexts []string) []string {
 	return matches
 }
 
-func showSpinner(done <-chan struct{}) {
-	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-	dots := []string{"", ".", "..", "..."}
-	i := 0
-	for {
-		select {
-		case <-done:
-			fmt.Printf("\r%-40s\r", "")
-			return
-		default:
-			frame := dimStyle.Render(frames[i%len(frames)])
-			d := dots[(i/3)%len(dots)]
-			fmt.Printf("\r%s ksw ai thinking%s   ", frame, d)
-			i++
-			time.Sleep(80 * time.Millisecond)
-		}
-	}
-}
-
 // ── OpenAI ─────────────────────────────────────────────
 
-func callOpenAI(prompt, model, apiKey string) (string, int, error) {
+func callOpenAI(ctx context.Context, prompt, model string, ai aiConfig) (string, int, error) {
 	body := map[string]any{
 		"model":       model,
 		"messages":    []map[string]string{{"role": "user", "content": prompt}},
@@ -1400,11 +1923,11 @@ func callOpenAI(prompt, model, apiKey string) (string, int, error) {
 	}
 	data, _ := json.Marshal(body)
 
-	req, _ := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(data))
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+ai.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient().Do(req)
+	resp, err := httpClient(ai).Do(req)
 	if err != nil {
 		return "", 0, fmt.Errorf("OpenAI request failed: %w", err)
 	}
@@ -1429,7 +1952,7 @@ func callOpenAI(prompt, model, apiKey string) (string, int, error) {
 
 // ── Claude ─────────────────────────────────────────────
 
-func callClaude(prompt, model, apiKey string) (string, int, error) {
+func callClaude(ctx context.Context, prompt, model string, ai aiConfig) (string, int, error) {
 	body := map[string]any{
 		"model":      model,
 		"messages":   []map[string]string{{"role": "user", "content": prompt}},
@@ -1437,12 +1960,12 @@ func callClaude(prompt, model, apiKey string) (string, int, error) {
 	}
 	data, _ := json.Marshal(body)
 
-	req, _ := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(data))
-	req.Header.Set("x-api-key", apiKey)
+	req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(data))
+	req.Header.Set("x-api-key", ai.APIKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient().Do(req)
+	resp, err := httpClient(ai).Do(req)
 	if err != nil {
 		return "", 0, fmt.Errorf("Claude request failed: %w", err)
 	}
@@ -1465,8 +1988,8 @@ func callClaude(prompt, model, apiKey string) (string, int, error) {
 
 // ── Gemini ─────────────────────────────────────────────
 
-func callGemini(prompt, model, apiKey string) (string, int, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, apiKey)
+func callGemini(ctx context.Context, prompt, model string, ai aiConfig) (string, int, error) {
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, ai.APIKey)
 
 	body := map[string]any{
 		"contents": []map[string]any{
@@ -1479,10 +2002,10 @@ func callGemini(prompt, model, apiKey string) (string, int, error) {
 	}
 	data, _ := json.Marshal(body)
 
-	req, _ := http.NewRequest("POST", url, bytes.NewReader(data))
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient().Do(req)
+	resp, err := httpClient(ai).Do(req)
 	if err != nil {
 		return "", 0, fmt.Errorf("Gemini request failed: %w", err)
 	}
@@ -1523,7 +2046,7 @@ func callGemini(prompt, model, apiKey string) (string, int, error) {
 
 // ── Bedrock (AWS SigV4) ────────────────────────────────
 
-func callBedrock(prompt, modelID string, ai aiConfig) (string, int, error) {
+func callBedrock(ctx context.Context, prompt, modelID string, ai aiConfig) (string, int, error) {
 	region := ai.AWSRegion
 	if region == "" {
 		region = "us-east-1"
@@ -1552,6 +2075,7 @@ func callBedrock(prompt, modelID string, ai aiConfig) (string, int, error) {
 		"--messages", string(messages),
 		"--inference-config", string(inferenceConfig),
 		"--output", "json",
+		"--cli-read-timeout", strconv.Itoa(int(ai.timeout().Seconds())),
 	}
 
 	// Set profile/credentials based on auth method
@@ -1571,9 +2095,22 @@ func callBedrock(prompt, modelID string, ai aiConfig) (string, int, error) {
 		}
 	}
 
-	cmd := exec.Command("aws", args...)
+	// The aws cli reads HTTPS_PROXY/NO_PROXY itself; pass TLS settings through
+	if ai.CABundle != "" {
+		env = append(env, "AWS_CA_BUNDLE="+expandHome(ai.CABundle))
+	}
+	if ai.Insecure {
+		args = append(args, "--no-verify-ssl")
+	}
+
+	cmd := exec.CommandContext(ctx, "aws", args...)
 	cmd.Env = env
+	start := time.Now()
 	out, err := cmd.CombinedOutput()
+	traceCommand(cmd, start, err)
+	if ctx.Err() != nil {
+		return "", 0, ctx.Err()
+	}
 	if err != nil {
 		msg := strings.TrimSpace(string(out))
 		if strings.Contains(msg, "ThrottlingException") || strings.Contains(msg, "Too Many Requests") {
@@ -1606,8 +2143,36 @@ func callBedrock(prompt, modelID string, ai aiConfig) (string, int, error) {
 
 // ── Helpers ────────────────────────────────────────────
 
-func httpClient() *http.Client {
-	return &http.Client{Timeout: 15 * time.Second}
+// httpClient honors HTTPS_PROXY/NO_PROXY and the configured CA bundle.
+// A bad CA bundle is reported rather than silently falling back.
+func httpClient(ai aiConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if ai.CABundle != "" || ai.Insecure {
+		tlsCfg := &tls.Config{InsecureSkipVerify: ai.Insecure}
+		if ai.CABundle != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			pem, err := os.ReadFile(expandHome(ai.CABundle))
+			if err != nil || !pool.AppendCertsFromPEM(pem) {
+				fmt.Fprintf(os.Stderr, "%s Could not load CA bundle %s\n", warnStyle.Render("!"), ai.CABundle)
+			}
+			tlsCfg.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsCfg
+	}
+	return &http.Client{Timeout: ai.timeout(), Transport: transport}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return path
 }
 
 func truncate(s string, n int) string {
@@ -1618,7 +2183,7 @@ func truncate(s string, n int) string {
 }
 
 // runAICommand executes a ksw command suggested by the AI
-func runAICommand(command string, args []string, cfg config) {
+func runAICommand(command string, args []string, cfg config) error {
 	// Handle "history N" — switch to history entry
 	if strings.HasPrefix(command, "history ") {
 		parts := strings.Fields(command)
@@ -1632,14 +2197,17 @@ func runAICommand(command string, args []string, cfg config) {
 			if n >= 1 && n <= len(cfg.History) {
 				target := cfg.History[n-1]
 				current := getCurrentContext()
+				if err := checkFreeze(cfg, current, target); err != nil {
+					return err
+				}
 				recordHistory(&cfg, current, target)
 				if err := switchContext(target); err != nil {
-					fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), target)
-					os.Exit(1)
+					return fmt.Errorf("Context '%s' not found", target)
 				}
 				_ = saveConfig(cfg)
 				fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), target)
-				return
+				runSwitchHooks(cfg, current, target)
+				return nil
 			}
 		}
 	}
@@ -1648,8 +2216,7 @@ func runAICommand(command string, args []string, cfg config) {
 	case "list":
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
 		}
 		current := getCurrentContext()
 		reverseAlias := make(map[string]string)
@@ -1675,8 +2242,7 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "group add":
 		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "%s group add needs name and pattern\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("group add needs name and pattern")
 		}
 		groupName := args[0]
 		pattern := strings.ToLower(args[1])
@@ -1685,8 +2251,7 @@ func runAICommand(command string, args []string, cfg config) {
 		// Find all contexts matching the pattern (substring or glob)
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			return
+			return err
 		}
 		var members []string
 		for _, ctx := range contexts {
@@ -1696,8 +2261,7 @@ func runAICommand(command string, args []string, cfg config) {
 			}
 		}
 		if len(members) == 0 {
-			fmt.Fprintf(os.Stderr, "%s No contexts match '%s'\n", warnStyle.Render("✗"), pattern)
-			return
+			return fmt.Errorf("No contexts match '%s'", pattern)
 		}
 		cfg.Groups[groupName] = members
 		_ = saveConfig(cfg)
@@ -1708,23 +2272,21 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "group rm":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "%s group rm needs a name\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("group rm needs a name")
 		}
 		for _, name := range args {
 			if _, ok := cfg.Groups[name]; !ok {
 				fmt.Fprintf(os.Stderr, "%s Group '%s' not found\n", warnStyle.Render("✗"), name)
 				continue
 			}
-			delete(cfg.Groups, name)
+			trashGroup(&cfg, name, "ai")
 			fmt.Printf("%s Group '%s' removed\n", successStyle.Render("✔"), name)
 		}
 		_ = saveConfig(cfg)
 
 	case "group add-ctx":
 		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "%s group add-ctx needs group name and context\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("group add-ctx needs group name and context")
 		}
 		groupName := args[0]
 		target := args[1]
@@ -1737,8 +2299,7 @@ func runAICommand(command string, args []string, cfg config) {
 			}
 		}
 		if resolved == "" {
-			fmt.Fprintf(os.Stderr, "%s Context '%s' not found\n", warnStyle.Render("✗"), target)
-			return
+			return fmt.Errorf("Context '%s' not found", target)
 		}
 		// Create group if it doesn't exist
 		if cfg.Groups[groupName] == nil {
@@ -1748,7 +2309,7 @@ func runAICommand(command string, args []string, cfg config) {
 		for _, c := range cfg.Groups[groupName] {
 			if c == resolved {
 				fmt.Printf("%s Already in group '%s': %s\n", dimStyle.Render("·"), groupName, resolved)
-				return
+				return nil
 			}
 		}
 		cfg.Groups[groupName] = append(cfg.Groups[groupName], resolved)
@@ -1757,41 +2318,40 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "group use":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "%s group use needs a group name\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("group use needs a group name")
 		}
 		groupName := args[0]
 		if _, ok := cfg.Groups[groupName]; !ok {
-			fmt.Fprintf(os.Stderr, "%s Group '%s' not found\n", warnStyle.Render("✗"), groupName)
-			return
+			return fmt.Errorf("Group '%s' not found", groupName)
 		}
 		if inChatMode {
 			fmt.Printf("No puedo abrir el TUI desde el chat. Ejecuta desde tu terminal:\n  ksw group use %s\n", groupName)
-			return
+			return nil
 		}
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			return
+			return err
 		}
 		current := getCurrentContext()
 		m := initialModel(contexts, current, cfg, groupName, false)
-		p := tea.NewProgram(m, tea.WithAltScreen())
+		p := newProgram(m, tea.WithAltScreen())
 		result, err := p.Run()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			return
+			return err
 		}
 		final := result.(model)
 		if final.chosen != "" && final.chosen != current {
+			if err := checkFreeze(cfg, current, final.chosen); err != nil {
+				return err
+			}
 			recordHistory(&cfg, current, final.chosen)
 			if err := switchContext(final.chosen); err != nil {
-				fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", final.chosen, err)
-				return
+				return fmt.Errorf("Error switching to %s: %v", final.chosen, err)
 			}
 			cfg = final.cfg
 			_ = saveConfig(cfg)
 			fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), final.chosen)
+			runSwitchHooks(cfg, current, final.chosen)
 		} else if final.chosen == current {
 			fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
 		}
@@ -1799,39 +2359,39 @@ func runAICommand(command string, args []string, cfg config) {
 	case "pin use":
 		if inChatMode {
 			fmt.Println("No puedo abrir el TUI desde el chat. Ejecuta desde tu terminal:\n  ksw pin use")
-			return
+			return nil
 		}
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			return
+			return err
 		}
 		current := getCurrentContext()
 		m := initialModel(contexts, current, cfg, "", true)
-		p := tea.NewProgram(m, tea.WithAltScreen())
+		p := newProgram(m, tea.WithAltScreen())
 		result, err := p.Run()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			return
+			return err
 		}
 		final := result.(model)
 		if final.chosen != "" && final.chosen != current {
+			if err := checkFreeze(cfg, current, final.chosen); err != nil {
+				return err
+			}
 			recordHistory(&cfg, current, final.chosen)
 			if err := switchContext(final.chosen); err != nil {
-				fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", final.chosen, err)
-				return
+				return fmt.Errorf("Error switching to %s: %v", final.chosen, err)
 			}
 			cfg = final.cfg
 			_ = saveConfig(cfg)
 			fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), final.chosen)
+			runSwitchHooks(cfg, current, final.chosen)
 		} else if final.chosen == current {
 			fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
 		}
 
 	case "rename":
 		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "%s rename needs old and new name\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("rename needs old and new name")
 		}
 		oldName := args[0]
 		newName := args[1]
@@ -1845,32 +2405,22 @@ func runAICommand(command string, args []string, cfg config) {
 			}
 		}
 		if resolved == "" {
-			fmt.Fprintf(os.Stderr, "%s Context '%s' not found\n", warnStyle.Render("✗"), oldName)
-			return
+			return fmt.Errorf("Context '%s' not found", oldName)
 		}
-		cmd := exec.Command("kubectl", "config", "rename-context", resolved, newName)
-		if out, err := cmd.CombinedOutput(); err != nil {
-			fmt.Fprintf(os.Stderr, "%s Failed to rename: %s\n", warnStyle.Render("✗"), strings.TrimSpace(string(out)))
-			return
+		if !confirmProtected(cfg, "rename", resolved, newName) {
+			return errNotConfirmed
 		}
-		// Update aliases/history
-		for alias, target := range cfg.Aliases {
-			if target == resolved {
-				cfg.Aliases[alias] = newName
-			}
-		}
-		for i, h := range cfg.History {
-			if h == resolved {
-				cfg.History[i] = newName
-			}
+		if out, err := kubeconfigEdit(resolved, "rename-context", resolved, newName); err != nil {
+			return fmt.Errorf("Failed to rename: %s", strings.TrimSpace(string(out)))
 		}
+		renameContextRefs(&cfg, resolved, newName)
 		_ = saveConfig(cfg)
 		fmt.Printf("%s Renamed %s → %s\n", successStyle.Render("✔"), dimStyle.Render(resolved), currentValueStyle.Render(newName))
 
 	case "history":
 		if len(cfg.History) == 0 {
 			fmt.Println(dimStyle.Render("No history yet."))
-			return
+			return nil
 		}
 		current := getCurrentContext()
 		reverseAlias := make(map[string]string)
@@ -1896,8 +2446,7 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "alias add":
 		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "%s alias add needs name and context\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("alias add needs name and context")
 		}
 		aliasName := strings.TrimLeft(args[0], "@")
 		target := args[1]
@@ -1911,8 +2460,7 @@ func runAICommand(command string, args []string, cfg config) {
 			}
 		}
 		if resolved == "" {
-			fmt.Fprintf(os.Stderr, "%s Context '%s' not found\n", warnStyle.Render("✗"), target)
-			return
+			return fmt.Errorf("Context '%s' not found", target)
 		}
 		cfg.Aliases[aliasName] = resolved
 		_ = saveConfig(cfg)
@@ -1920,14 +2468,13 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "alias rm":
 		if len(args) < 1 {
-			return
+			return nil
 		}
 		name := strings.TrimLeft(args[0], "@")
 		if _, ok := cfg.Aliases[name]; !ok {
-			fmt.Fprintf(os.Stderr, "%s Alias '%s' not found\n", warnStyle.Render("✗"), name)
-			return
+			return fmt.Errorf("Alias '%s' not found", name)
 		}
-		delete(cfg.Aliases, name)
+		trashAlias(&cfg, name, "ai")
 		_ = saveConfig(cfg)
 		fmt.Printf("%s Alias @%s removed\n", successStyle.Render("✔"), name)
 
@@ -1937,7 +2484,7 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "pin add":
 		if len(args) < 1 {
-			return
+			return nil
 		}
 		target := args[0]
 		contexts, _ := getContexts()
@@ -1949,8 +2496,7 @@ func runAICommand(command string, args []string, cfg config) {
 			}
 		}
 		if resolved == "" {
-			fmt.Fprintf(os.Stderr, "%s Context '%s' not found\n", warnStyle.Render("✗"), target)
-			return
+			return fmt.Errorf("Context '%s' not found", target)
 		}
 		cfg.Pins = append(cfg.Pins, resolved)
 		_ = saveConfig(cfg)
@@ -1958,23 +2504,21 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "pin rm":
 		if len(args) < 1 {
-			return
+			return nil
 		}
 		target := args[0]
-		newPins := make([]string, 0, len(cfg.Pins))
-		found := false
+		var matched []string
 		for _, p := range cfg.Pins {
 			if strings.Contains(p, target) || shortName(p) == target {
-				found = true
-				continue
+				matched = append(matched, p)
 			}
-			newPins = append(newPins, p)
 		}
-		if !found {
-			fmt.Fprintf(os.Stderr, "%s '%s' not pinned\n", warnStyle.Render("✗"), target)
-			return
+		if len(matched) == 0 {
+			return fmt.Errorf("'%s' not pinned", target)
+		}
+		for _, p := range matched {
+			trashPin(&cfg, p, "ai")
 		}
-		cfg.Pins = newPins
 		_ = saveConfig(cfg)
 		fmt.Printf("%s Unpinned %s\n", successStyle.Render("✔"), target)
 
@@ -1987,12 +2531,647 @@ func runAICommand(command string, args []string, cfg config) {
 
 	case "eks kubeconfig --profile":
 		if len(args) < 1 {
-			fmt.Fprintf(os.Stderr, "%s eks kubeconfig --profile needs a profile name\n", warnStyle.Render("✗"))
-			return
+			return fmt.Errorf("eks kubeconfig --profile needs a profile name")
 		}
 		handleEksKubeconfig(args[0])
 
 	default:
-		fmt.Fprintf(os.Stderr, "%s Command '%s' not supported via AI yet.\n", warnStyle.Render("?"), command)
+		return fmt.Errorf("Command '%s' not supported via AI yet", command)
+	}
+	return nil
+}
+
+// ── ksw ai summarize ───────────────────────────────────
+
+// contextUsage is how often and how recently a context was switched to
+type contextUsage struct {
+	Switches int
+	LastUsed int64
+}
+
+// usageStats aggregates the structured history per context
+func usageStats(cfg config) map[string]contextUsage {
+	stats := make(map[string]contextUsage)
+	for _, e := range cfg.HistoryLog {
+		u := stats[e.Context]
+		u.Switches++
+		if e.Time > u.LastUsed {
+			u.LastUsed = e.Time
+		}
+		stats[e.Context] = u
+	}
+	return stats
+}
+
+// buildInventory describes every context with its groups and usage, one per line
+func buildInventory(contexts []string, cfg config) string {
+	stats := usageStats(cfg)
+	memberOf := make(map[string][]string)
+	for name, members := range cfg.Groups {
+		for _, m := range members {
+			memberOf[m] = append(memberOf[m], name)
+		}
+	}
+	now := time.Now().Unix()
+	servers := getContextServers()
+
+	var sb strings.Builder
+	for _, ctx := range contexts {
+		sb.WriteString("- " + ctx)
+		if badge := providerBadge(ctx, servers[ctx], cfg.Icons); badge != "" {
+			sb.WriteString(" [" + badge + "]")
+		}
+		if groups := memberOf[ctx]; len(groups) > 0 {
+			sort.Strings(groups)
+			sb.WriteString(" groups=" + strings.Join(groups, ","))
+		}
+		if u, ok := stats[ctx]; ok {
+			sb.WriteString(fmt.Sprintf(" switches=%d last_used=%dd_ago", u.Switches, (now-u.LastUsed)/86400))
+		} else {
+			sb.WriteString(" never_used")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func buildSummarizePrompt(contexts []string, cfg config) string {
+	return `You are an assistant for ksw, a kubectl context switcher. Summarize the user's
+Kubernetes context inventory below for a human reader.
+
+Produce a concise, organized overview in plain text (no JSON, no markdown tables) with these sections:
+1. By environment (prod / staging / qa / dev / local — infer from names)
+2. By cloud account or project and region (infer from ARNs, gke_ names, etc.)
+3. Unused or stale clusters (never used, or not used in 30+ days)
+4. Notable observations (naming inconsistencies, duplicates, ungrouped clusters) — at most 3 bullets
+
+Use short context names (the part after the last "/") when they are unambiguous.` + languageRule(cfg.AI) + `
+
+CONTEXTS (` + fmt.Sprint(len(contexts)) + ` total):
+` + buildInventory(contexts, cfg)
+}
+
+// handleAISummarize prints an AI-written overview of the context inventory
+func handleAISummarize(cfg config) {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	if len(contexts) == 0 {
+		noContexts()
+	}
+
+	var out string
+	err = runWithProgress(context.Background(), "ksw ai thinking", 0, func(ctx context.Context, _ *progress) error {
+		var err error
+		out, err = callProvider(ctx, cfg.AI, buildSummarizePrompt(contexts, cfg))
+		return err
+	})
+	exitIfInterrupted(err)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Println(strings.TrimSpace(out))
+}
+
+// ── ksw ai tidy ────────────────────────────────────────
+
+// tidyAction is one suggestion in the cleanup plan
+type tidyAction struct {
+	Op       string   `json:"op"` // rename | group | pin | delete
+	Context  string   `json:"context,omitempty"`
+	To       string   `json:"to,omitempty"`       // rename target
+	Name     string   `json:"name,omitempty"`     // group name
+	Contexts []string `json:"contexts,omitempty"` // group members
+	Reason   string   `json:"reason,omitempty"`
+}
+
+func (a tidyAction) describe() string {
+	switch a.Op {
+	case "rename":
+		return fmt.Sprintf("rename %s → %s", shortName(a.Context), a.To)
+	case "group":
+		return fmt.Sprintf("group %s (%d contexts)", a.Name, len(a.Contexts))
+	case "pin":
+		return "pin " + shortName(a.Context)
+	case "delete":
+		return "delete " + shortName(a.Context)
+	}
+	return a.Op
+}
+
+func buildTidyPrompt(contexts []string, cfg config, health map[string]latencyResult) string {
+	var sb strings.Builder
+	for _, ctx := range contexts {
+		if r, ok := health[ctx]; ok && r.Err != nil {
+			sb.WriteString("- " + ctx + ": unreachable\n")
+		}
+	}
+	unreachable := sb.String()
+	if unreachable == "" {
+		unreachable = "(all reachable)\n"
+	}
+	pins := "(none)"
+	if len(cfg.Pins) > 0 {
+		pins = strings.Join(cfg.Pins, ", ")
+	}
+
+	return `You are an assistant for ksw, a kubectl context switcher. Propose a cleanup plan
+for the user's Kubernetes contexts.
+
+You may suggest these operations:
+- rename: give a context a shorter, consistent name  {"op":"rename","context":"<full name>","to":"<new name>","reason":"..."}
+- group: collect related contexts                    {"op":"group","name":"<group>","contexts":["<full name>",...],"reason":"..."}
+- pin: pin a frequently used context                 {"op":"pin","context":"<full name>","reason":"..."}
+- delete: remove a stale, unreachable, unused context {"op":"delete","context":"<full name>","reason":"..."}
+
+Rules:
+- Use exact full context names from the list.
+- Only suggest delete for contexts that are unreachable AND unused (never used or 30+ days).
+- Don't suggest pins that already exist or renames of contexts that already have short names.
+- At most 15 suggestions, most valuable first. Keep reasons under 12 words.
+- Respond ONLY with a JSON array of operations, nothing else.
+
+CONTEXTS:
+` + buildInventory(contexts, cfg) + `
+UNREACHABLE API SERVERS:
+` + unreachable + `
+CURRENT PINS: ` + pins + "\n"
+}
+
+// handleAITidy asks the model for a cleanup plan and applies the chosen steps
+func handleAITidy(cfg config) {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	if len(contexts) == 0 {
+		noContexts()
+	}
+	yes := len(os.Args) > 3 && (os.Args[3] == "--yes" || os.Args[3] == "-y")
+
+	var raw string
+	var health map[string]latencyResult
+	err = runWithProgress(context.Background(), "ksw ai thinking", 0, func(ctx context.Context, _ *progress) error {
+		health = measureAll(contexts, nil)
+		var err error
+		raw, err = callProvider(ctx, cfg.AI, buildTidyPrompt(contexts, cfg, health))
+		return err
+	})
+	exitIfInterrupted(err)
+	if err != nil {
+		fatal(err)
+	}
+
+	var proposed []tidyAction
+	if err := json.Unmarshal([]byte(extractJSON(raw)), &proposed); err != nil {
+		fmt.Fprintf(os.Stderr, "%s could not parse AI response: %s\n", warnStyle.Render("✗"), truncate(raw, 200))
+		os.Exit(exitAI)
+	}
+
+	// Drop suggestions that reference contexts that don't exist, and
+	// deletions of contexts that answered or have been used
+	current := getCurrentContext()
+	known := make(map[string]bool, len(contexts))
+	for _, c := range contexts {
+		known[c] = true
+	}
+	var plan []tidyAction
+	for _, a := range proposed {
+		if aiAllowed(cfg, a.Op) != nil {
+			continue
+		}
+		switch a.Op {
+		case "rename", "pin", "delete":
+			if a.Op == "delete" && !tidyDeletable(cfg, a.Context, current, health) {
+				continue
+			}
+			if known[a.Context] && (a.Op != "rename" || a.To != "") {
+				plan = append(plan, a)
+			}
+		case "group":
+			var members []string
+			for _, c := range a.Contexts {
+				if known[c] {
+					members = append(members, c)
+				}
+			}
+			if a.Name != "" && len(members) > 0 {
+				a.Contexts = members
+				plan = append(plan, a)
+			}
+		}
+	}
+	if len(plan) == 0 {
+		fmt.Println(dimStyle.Render("Nothing to tidy."))
+		return
+	}
+
+	fmt.Println(dimStyle.Render("  Proposed plan:"))
+	for i, a := range plan {
+		line := a.describe()
+		if a.Op == "delete" {
+			line = warnStyle.Render(line)
+		}
+		fmt.Printf("  %2d  %s  %s\n", i+1, line, dimStyle.Render(a.Reason))
+	}
+
+	picked := promptSelection("Apply", len(plan), yes)
+	if len(picked) == 0 {
+		fmt.Println(dimStyle.Render("  Nothing applied."))
+		return
+	}
+	for _, i := range picked {
+		a := plan[i]
+		if err := applyTidyAction(&cfg, a); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", warnStyle.Render("✗"), a.describe(), err)
+			continue
+		}
+		fmt.Printf("%s %s\n", successStyle.Render("✔"), a.describe())
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// tidyDeletable reports whether ksw ai tidy may offer to delete ctx: only
+// when its API server didn't answer and nothing in the history shows it
+// in use, whatever the model says
+func tidyDeletable(cfg config, ctx, current string, health map[string]latencyResult) bool {
+	if r, ok := health[ctx]; !ok || r.Err == nil {
+		return false
+	}
+	if ctx == current || ctx == cfg.Previous || slices.Contains(cfg.History, ctx) {
+		return false
+	}
+	return !slices.ContainsFunc(cfg.HistoryLog, func(e historyEntry) bool { return e.Context == ctx || e.From == ctx })
+}
+
+func applyTidyAction(cfg *config, a tidyAction) error {
+	if err := aiAllowed(*cfg, a.Op); err != nil {
+		return err
+	}
+	if (a.Op == "rename" || a.Op == "delete") && !confirmProtected(*cfg, a.Op, a.Context, a.To) {
+		return fmt.Errorf("skipped")
+	}
+	switch a.Op {
+	case "rename":
+		if out, err := kubeconfigEdit(a.Context, "rename-context", a.Context, a.To); err != nil {
+			return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+		}
+		renameContextRefs(cfg, a.Context, a.To)
+	case "group":
+		members := cfg.Groups[a.Name]
+		for _, c := range a.Contexts {
+			if !slices.Contains(members, c) {
+				members = append(members, c)
+			}
+		}
+		cfg.Groups[a.Name] = members
+	case "pin":
+		if !slices.Contains(cfg.Pins, a.Context) {
+			cfg.Pins = append(cfg.Pins, a.Context)
+		}
+	case "delete":
+		if out, err := kubeconfigEdit(a.Context, "delete-context", a.Context); err != nil {
+			return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+		}
+		dropContextRefs(cfg, a.Context, "ai")
+	}
+	return nil
+}
+
+// ── TUI natural-language filter ────────────────────────
+
+// aiFilterMsg carries the contexts the provider picked for a "?" query
+type aiFilterMsg struct {
+	query   string
+	matches []string
+	err     error
+}
+
+// aiFilterCmd resolves a "?<sentence>" TUI query into a set of contexts
+func aiFilterCmd(query string, contexts []string, cfg config) tea.Cmd {
+	return func() tea.Msg {
+		matches, err := filterContextsWithAI(strings.TrimPrefix(query, "?"), contexts, cfg)
+		return aiFilterMsg{query: query, matches: matches, err: err}
+	}
+}
+
+// filterContextsWithAI asks the provider which contexts match a sentence
+// like "prod clusters in us-east-1" and returns them in kubeconfig order.
+func filterContextsWithAI(sentence string, contexts []string, cfg config) ([]string, error) {
+	if cfg.AI.Provider == "" || (cfg.AI.Provider != "bedrock" && cfg.AI.APIKey == "") {
+		return nil, fmt.Errorf("AI not configured. Run: ksw ai config")
+	}
+	prompt := `You are an assistant for ksw, a kubectl context switcher. The user describes a set
+of Kubernetes contexts in natural language. Select every context from the list that matches.
+Infer environment, region, account and provider from the names (ARNs, gke_ prefixes, suffixes
+like -pdn/-prod/-qa/-dev). Group memberships are listed after each context.
+
+Respond ONLY with a JSON array of exact context names, e.g. ["ctx-a","ctx-b"]. Use [] if none match.
+
+QUERY: ` + strings.TrimSpace(sentence) + `
+
+CONTEXTS:
+` + buildInventory(contexts, cfg)
+
+	raw, err := callProvider(context.Background(), cfg.AI, prompt)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(extractJSON(raw)), &names); err != nil {
+		return nil, fmt.Errorf("could not parse AI response: %s", truncate(raw, 200))
+	}
+	picked := make(map[string]bool, len(names))
+	for _, n := range names {
+		picked[n] = true
+	}
+	matches := []string{}
+	for _, ctx := range contexts {
+		if picked[ctx] || picked[shortName(ctx)] {
+			matches = append(matches, ctx)
+		}
+	}
+	return matches, nil
+}
+
+// ── ksw ai models ──────────────────────────────────────
+
+// listModels asks the provider which models are available
+func listModels(ai aiConfig) ([]string, error) {
+	var req *http.Request
+	switch ai.Provider {
+	case "openai":
+		req, _ = http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer "+ai.APIKey)
+	case "claude":
+		req, _ = http.NewRequest("GET", "https://api.anthropic.com/v1/models?limit=100", nil)
+		req.Header.Set("x-api-key", ai.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	case "gemini":
+		req, _ = http.NewRequest("GET", "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1000&key="+ai.APIKey, nil)
+	case "bedrock":
+		return listBedrockModels(ai)
+	default:
+		return nil, fmt.Errorf("unknown provider '%s'", ai.Provider)
+	}
+
+	resp, err := httpClient(ai).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("model list request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("model list error %d: %s", resp.StatusCode, truncate(string(b), 200))
+	}
+
+	// OpenAI and Claude: {"data":[{"id":...}]}; Gemini: {"models":[{"name":"models/...",...}]}
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Models []struct {
+			Name    string   `json:"name"`
+			Methods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("unexpected model list response")
+	}
+	var models []string
+	for _, d := range result.Data {
+		models = append(models, d.ID)
+	}
+	for _, m := range result.Models {
+		if slices.Contains(m.Methods, "generateContent") {
+			models = append(models, strings.TrimPrefix(m.Name, "models/"))
+		}
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// listBedrockModels lists inference profiles, which is what converse needs for most models
+func listBedrockModels(ai aiConfig) ([]string, error) {
+	region := ai.AWSRegion
+	if region == "" {
+		region = "us-east-1"
+	}
+	args := []string{"bedrock", "list-inference-profiles", "--region", region,
+		"--query", "inferenceProfileSummaries[].inferenceProfileId", "--output", "json"}
+	env := os.Environ()
+	switch ai.AWSAuthMethod {
+	case "keys":
+		env = append(env, "AWS_ACCESS_KEY_ID="+ai.AWSAccessKey, "AWS_SECRET_ACCESS_KEY="+ai.AWSSecretKey)
+	case "env":
+	default:
+		if ai.AWSProfile != "" && ai.AWSProfile != "default" {
+			args = append(args, "--profile", ai.AWSProfile)
+		}
+	}
+	cmd, done := command("aws", args...)
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err = done(err); err != nil {
+		return nil, fmt.Errorf("aws bedrock list-inference-profiles failed: %w", err)
+	}
+	var models []string
+	if err := json.Unmarshal(out, &models); err != nil {
+		return nil, fmt.Errorf("unexpected Bedrock response: %w", err)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// handleAIModels lists the provider's models, or sets one with `ksw ai models use <id>`
+func handleAIModels(cfg config) {
+	if len(os.Args) >= 4 && os.Args[3] == "use" {
+		if len(os.Args) < 5 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw ai models use <model>")
+			os.Exit(1)
+		}
+		cfg.AI.Model = os.Args[4]
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s AI model set to %s\n", successStyle.Render("✔"), currentValueStyle.Render(cfg.AI.Model))
+		return
+	}
+
+	current := cfg.AI.Model
+	if current == "" {
+		current = defaultModel(cfg.AI.Provider)
+	}
+	models, err := listModels(cfg.AI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+		fmt.Fprintln(os.Stderr, dimStyle.Render("  Built-in list:"))
+		models = providerModels[cfg.AI.Provider]
+	}
+	for _, m := range models {
+		if m == current {
+			fmt.Printf("  %s %s\n", activeTag, activeItemStyle.Render(m))
+		} else {
+			fmt.Printf("    %s\n", m)
+		}
+	}
+}
+
+// ── Protected contexts ─────────────────────────────────
+
+// isProtected reports whether ctx matches the protected patterns (prod by
+// default) or those of the team policy
+func isProtected(cfg config, ctx string) bool {
+	patterns := cfg.Protected
+	if len(patterns) == 0 {
+		patterns = defaultProdPatterns
+	}
+	// The team policy adds to them; it can't be opted out of locally
+	for _, p := range slices.Concat(patterns, loadedPolicy(cfg).Protected) {
+		if globMatch(p, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// errNotConfirmed is returned by actions the user declined at confirmProtected
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirmProtected shows a diff-style preview of a rename/delete the AI
+// proposed on a protected context and requires typing "yes". It always
+// asks, even when the plan was accepted with --yes.
+func confirmProtected(cfg config, op, ctx, to string) bool {
+	if !isProtected(cfg, ctx) {
+		return true
+	}
+	if inChatMode {
+		fmt.Printf("%s %s on protected context %s needs confirmation. Run it from your terminal.\n", warnStyle.Render("!"), op, shortName(ctx))
+		return false
+	}
+
+	fmt.Printf("\n  %s %s on protected context\n", warnStyle.Render("!"), op)
+	fmt.Printf("  %s\n", warnStyle.Render("- "+ctx))
+	if op == "rename" {
+		fmt.Printf("  %s\n", successStyle.Render("+ "+to))
+	}
+	var refs []string
+	for alias, target := range cfg.Aliases {
+		if target == ctx {
+			refs = append(refs, "@"+alias)
+		}
+	}
+	if slices.Contains(cfg.Pins, ctx) {
+		refs = append(refs, "pin")
+	}
+	for name, members := range cfg.Groups {
+		if slices.Contains(members, ctx) {
+			refs = append(refs, "group "+name)
+		}
+	}
+	if len(refs) > 0 {
+		sort.Strings(refs)
+		verb := "updates"
+		if op == "delete" {
+			verb = "removes from"
+		}
+		fmt.Printf("  %s\n", dimStyle.Render("  "+verb+": "+strings.Join(refs, ", ")))
+	}
+
+	fmt.Printf("  Type 'yes' to %s: ", op)
+	var answer string
+	fmt.Scanln(&answer)
+	if answer != "yes" {
+		fmt.Println(dimStyle.Render("  Skipped."))
+		return false
+	}
+	return true
+}
+
+// ── Learned resolutions ────────────────────────────────
+
+// relativeWords mark queries that depend on conversation state ("go back",
+// "same but qa") and so must never be learned as a fixed mapping
+var relativeWords = []string{"previous", "back", "same", "last", "anterior", "mismo", "misma", "volver", "regresa"}
+
+// learnKey normalizes a query so "Canales  PROD" and "canales prod" match
+func learnKey(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// learnedContext returns the context a query previously resolved to, if it still exists
+func learnedContext(cfg config, query string, contexts []string) (string, bool) {
+	ctx, ok := cfg.AILearned[learnKey(query)]
+	if !ok || !slices.Contains(contexts, ctx) {
+		return "", false
+	}
+	return ctx, true
+}
+
+// learnResolution remembers query → ctx unless the query is relative
+func learnResolution(cfg *config, query, ctx string) {
+	key := learnKey(query)
+	for _, w := range strings.Fields(key) {
+		if slices.Contains(relativeWords, w) {
+			return
+		}
+	}
+	if cfg.AILearned == nil {
+		cfg.AILearned = make(map[string]string)
+	}
+	cfg.AILearned[key] = ctx
+}
+
+// handleAILearned manages learned shortcuts: ksw ai learned [ls|rm <query>|clear]
+func handleAILearned(cfg config) {
+	sub := "ls"
+	if len(os.Args) >= 4 {
+		sub = os.Args[3]
+	}
+	switch sub {
+	case "ls", "list":
+		if len(cfg.AILearned) == 0 {
+			fmt.Println(dimStyle.Render("Nothing learned yet. Successful ksw ai switches are remembered here."))
+			return
+		}
+		keys := make([]string, 0, len(cfg.AILearned))
+		for k := range cfg.AILearned {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s → %s\n", aliasStyle.Render("\""+k+"\""), shortName(cfg.AILearned[k]))
+		}
+	case "rm", "remove", "delete":
+		if len(os.Args) < 5 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw ai learned rm <query>")
+			os.Exit(1)
+		}
+		key := learnKey(strings.Join(os.Args[4:], " "))
+		if _, ok := cfg.AILearned[key]; !ok {
+			fmt.Fprintf(os.Stderr, "%s Nothing learned for '%s'.\n", warnStyle.Render("✗"), key)
+			os.Exit(1)
+		}
+		delete(cfg.AILearned, key)
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Forgot \"%s\"\n", successStyle.Render("✔"), key)
+	case "clear":
+		cfg.AILearned = nil
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Cleared learned resolutions\n", successStyle.Render("✔"))
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: ksw ai learned [ls|rm <query>|clear]")
+		os.Exit(1)
 	}
 }
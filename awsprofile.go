@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ── AWS profiles ───────────────────────────────────────
+
+// eksAccount returns the AWS account of an EKS ARN context, or ""
+func eksAccount(ctx string) string {
+	rest, ok := strings.CutPrefix(ctx, "arn:aws:eks:")
+	if !ok {
+		return ""
+	}
+	if parts := strings.SplitN(rest, ":", 3); len(parts) == 3 {
+		return parts[1]
+	}
+	return ""
+}
+
+// requiredAWSProfile is the AWS profile ctx needs: cfg.AWSProfiles by context,
+// then by the account in its EKS ARN, then the profile ksw eks sync imported
+// it with. The second result says where it came from.
+func requiredAWSProfile(cfg config, ctx string) (string, string) {
+	if p, ok := cfg.AWSProfiles[ctx]; ok {
+		return p, "mapped"
+	}
+	if account := eksAccount(ctx); account != "" {
+		if p, ok := cfg.AWSProfiles[account]; ok {
+			return p, "account " + account
+		}
+	}
+	if pv, ok := cfg.Provenance[ctx]; ok && pv.Source == "eks" {
+		// Detail is "profile <name>, <region>"
+		if rest, ok := strings.CutPrefix(pv.Detail, "profile "); ok {
+			if name, _, _ := strings.Cut(rest, ","); name != "" {
+				return name, "imported"
+			}
+		}
+	}
+	return "", ""
+}
+
+// activeAWSProfile is the profile the AWS CLI and SDKs use right now
+func activeAWSProfile() string {
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	if p := os.Getenv("AWS_DEFAULT_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// warnAWSProfile warns after a switch when ctx needs another AWS profile than
+// the active one, since its exec credentials would fail with a confusing error
+func warnAWSProfile(cfg config, ctx string) {
+	want, _ := requiredAWSProfile(cfg, ctx)
+	if want == "" || want == activeAWSProfile() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s uses AWS profile %s, but %s is active · %s\n", warnStyle.Render("⚠"),
+		shortName(ctx), currentValueStyle.Render(want), activeAWSProfile(), dimStyle.Render("export AWS_PROFILE="+want))
+}
+
+// awsProfileEnv sets AWS_PROFILE for commands ksw runs on ctx (exec, k, logs)
+// and for ksw shellenv, where ksw can change the environment itself
+func awsProfileEnv(cfg config, ctx string) []string {
+	if want, _ := requiredAWSProfile(cfg, ctx); want != "" {
+		return []string{"AWS_PROFILE=" + want}
+	}
+	return nil
+}
+
+// handleAWSProfile maps contexts, or whole AWS accounts, to AWS profiles:
+// ksw aws-profile <ctx|account> <profile> | ls | rm <ctx|account>
+func handleAWSProfile(cfg config) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw aws-profile <ctx|account> <profile> | ls | rm <ctx|account>")
+		os.Exit(1)
+	}
+	save := func() {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// An AWS account ID is used as is; anything else is resolved to a context
+	key := func(name string) string {
+		if len(name) == 12 && strings.Trim(name, "0123456789") == "" {
+			return name
+		}
+		if _, ok := cfg.AWSProfiles[name]; ok {
+			return name
+		}
+		contexts, err := getContexts()
+		if err != nil {
+			fatal(err)
+		}
+		ctx, err := resolveContext(name, contexts)
+		if err != nil {
+			fatal(err)
+		}
+		return ctx
+	}
+
+	switch sub := os.Args[2]; sub {
+	case "ls", "list":
+		if len(cfg.AWSProfiles) == 0 {
+			fmt.Println(dimStyle.Render("No AWS profiles mapped. Use: ksw aws-profile <ctx|account> <profile>"))
+			return
+		}
+		keys := make([]string, 0, len(cfg.AWSProfiles))
+		for k := range cfg.AWSProfiles {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %-40s %s\n", k, aliasStyle.Render(cfg.AWSProfiles[k]))
+		}
+
+	case "rm", "remove":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw aws-profile rm <ctx|account>")
+			os.Exit(1)
+		}
+		k := key(os.Args[3])
+		if _, ok := cfg.AWSProfiles[k]; !ok {
+			fmt.Fprintf(os.Stderr, "%s %s has no AWS profile.\n", warnStyle.Render("✗"), k)
+			os.Exit(exitNotFound)
+		}
+		delete(cfg.AWSProfiles, k)
+		save()
+		fmt.Printf("%s %s no longer maps to an AWS profile\n", successStyle.Render("✔"), k)
+
+	default:
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw aws-profile <ctx|account> <profile>")
+			os.Exit(1)
+		}
+		k := key(sub)
+		if cfg.AWSProfiles == nil {
+			cfg.AWSProfiles = make(map[string]string)
+		}
+		cfg.AWSProfiles[k] = os.Args[3]
+		save()
+		fmt.Printf("%s %s → AWS profile %s\n", successStyle.Render("✔"), k, aliasStyle.Render(os.Args[3]))
+	}
+}
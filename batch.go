@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ── Batch mode ─────────────────────────────────────────
+
+// batchOp is one validated line of a batch file
+type batchOp struct {
+	line int
+	verb string
+	desc string
+}
+
+// handleBatch reads operations from stdin, one per line:
+//
+//	alias <name> <context> [context2 ...]
+//	pin <context|glob|@group>
+//	group <name> <context|glob> ...
+//	switch <context|@alias>
+//
+// Every line is validated against kubeconfig before anything is written;
+// if any line fails, nothing is applied. A switch (the last one wins)
+// happens after the config has been saved.
+func handleBatch(cfg config) {
+	dryRun := len(os.Args) > 2 && os.Args[2] == "--dry-run"
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+
+	var ops []batchOp
+	var errs []string
+	switchTo := ""
+
+	scanner := bufio.NewScanner(os.Stdin)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			fields[i] = strings.Trim(f, `"'`)
+		}
+		fail := func(format string, a ...any) {
+			errs = append(errs, fmt.Sprintf("line %d: %s", lineNo, fmt.Sprintf(format, a...)))
+		}
+
+		switch verb := fields[0]; verb {
+		case "switch":
+			if len(fields) != 2 {
+				fail("usage: switch <context|@alias>")
+				continue
+			}
+			name := fields[1]
+			if strings.HasPrefix(name, "@") {
+				target, ok := cfg.Aliases[name[1:]]
+				if !ok {
+					fail("alias '%s' not found", name)
+					continue
+				}
+				name = target
+			}
+			ctx, err := resolveContext(name, contexts)
+			if err != nil {
+				fail("%v", err)
+				continue
+			}
+			switchTo = ctx
+			ops = append(ops, batchOp{lineNo, verb, ctx})
+
+		case "alias":
+			if len(fields) < 3 {
+				fail("usage: alias <name> <context> [context2 ...]")
+				continue
+			}
+			name := strings.TrimPrefix(fields[1], "@")
+			var targets []string
+			ok := true
+			for _, t := range fields[2:] {
+				ctx, err := resolveContext(t, contexts)
+				if err != nil {
+					fail("%v", err)
+					ok = false
+					break
+				}
+				targets = append(targets, ctx)
+			}
+			if !ok {
+				continue
+			}
+			if len(targets) == 1 {
+				delete(cfg.MultiAliases, name)
+				cfg.Aliases[name] = targets[0]
+			} else {
+				delete(cfg.Aliases, name)
+				cfg.MultiAliases[name] = targets
+			}
+			ops = append(ops, batchOp{lineNo, verb, "@" + name + " → " + strings.Join(targets, ", ")})
+
+		case "pin":
+			if len(fields) != 2 {
+				fail("usage: pin <context|glob|@group>")
+				continue
+			}
+			var matches []string
+			if strings.HasPrefix(fields[1], "@") {
+				members, ok := cfg.Groups[fields[1][1:]]
+				if !ok {
+					fail("group '%s' not found", fields[1][1:])
+					continue
+				}
+				var missing []string
+				for _, m := range members {
+					if !slices.Contains(contexts, m) {
+						missing = append(missing, m)
+					}
+				}
+				if len(missing) > 0 {
+					fail("group '%s' has contexts that aren't in the kubeconfig: %s", fields[1][1:], strings.Join(missing, ", "))
+					continue
+				}
+				matches = members
+			} else if matches, err = resolveContexts(fields[1], contexts); err != nil {
+				fail("%v", err)
+				continue
+			}
+			for _, ctx := range matches {
+				if !slices.Contains(cfg.Pins, ctx) {
+					cfg.Pins = append(cfg.Pins, ctx)
+				}
+			}
+			ops = append(ops, batchOp{lineNo, verb, fmt.Sprintf("%s (%d context(s))", fields[1], len(matches))})
+
+		case "group":
+			// Accept both "group <name> ..." and "group add <name> ..."
+			if len(fields) > 1 && fields[1] == "add" {
+				fields = append(fields[:1], fields[2:]...)
+			}
+			if len(fields) < 3 {
+				fail("usage: group <name> <context|glob> ...")
+				continue
+			}
+			name := fields[1]
+			members := cfg.Groups[name]
+			ok := true
+			for _, arg := range fields[2:] {
+				ctxs, err := resolveContexts(arg, contexts)
+				if err != nil {
+					fail("%v", err)
+					ok = false
+					break
+				}
+				for _, ctx := range ctxs {
+					if !slices.Contains(members, ctx) {
+						members = append(members, ctx)
+					}
+				}
+			}
+			if !ok {
+				continue
+			}
+			cfg.Groups[name] = members
+			ops = append(ops, batchOp{lineNo, verb, fmt.Sprintf("%s (%d context(s))", name, len(members))})
+
+		default:
+			fail("unknown operation '%s' (use switch, alias, pin or group)", verb)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%s Batch rejected, nothing applied:\n", warnStyle.Render("✗"))
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "  %s\n", e)
+		}
+		os.Exit(1)
+	}
+	if len(ops) == 0 {
+		fmt.Println(dimStyle.Render("No operations."))
+		return
+	}
+
+	for _, op := range ops {
+		fmt.Printf("  %s %-6s %s\n", dimStyle.Render(fmt.Sprintf("%3d", op.line)), op.verb, op.desc)
+	}
+	if dryRun {
+		fmt.Printf("%s %d operation(s) valid (dry run, nothing applied)\n", dimStyle.Render("·"), len(ops))
+		return
+	}
+
+	current := getCurrentContext()
+	if switchTo != "" && switchTo != current {
+		if err := checkFreeze(cfg, current, switchTo); err != nil {
+			fatal(err)
+		}
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if switchTo != "" && switchTo != current {
+		if err := switchContext(switchTo); err != nil {
+			fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", switchTo, err)
+			os.Exit(1)
+		}
+		// Only a switch that happened goes to the history
+		recordHistory(&cfg, current, switchTo)
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		runSwitchHooks(cfg, current, switchTo)
+	}
+	fmt.Printf("%s Applied %d operation(s)\n", successStyle.Render("✔"), len(ops))
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── ksw bench ──────────────────────────────────────────
+
+// benchPhases are the steps of ksw <name>, in the order they run
+var benchPhases = []string{"kubeconfig read", "resolution", "kubeconfig write", "config save", "switch checks", "verification"}
+
+// benchRun is one timed switch
+type benchRun struct {
+	times     []time.Duration // per benchPhases
+	verifyErr error
+}
+
+// benchSwitch switches to name the way ksw <name> does, timing each
+// phase, then back to where it started. The checks that follow a switch are
+// run without printing; hooks that change something outside ksw (kubectx
+// sync, tunnels, Slack) aren't run at all.
+func benchSwitch(cfg config, name string) (benchRun, error) {
+	run := benchRun{times: make([]time.Duration, len(benchPhases))}
+	lap := func(phase int, start time.Time) { run.times[phase] = time.Since(start) }
+
+	start := time.Now()
+	current, contexts, err := getKubeconfigState()
+	lap(0, start)
+	if err != nil {
+		return run, err
+	}
+
+	start = time.Now()
+	target, err := resolveContext(name, contexts)
+	lap(1, start)
+	if err != nil {
+		return run, err
+	}
+
+	start = time.Now()
+	err = switchContext(target)
+	lap(2, start)
+	if err != nil {
+		return run, err
+	}
+	defer func() {
+		if target != current {
+			_ = switchContext(current)
+		}
+	}()
+
+	start = time.Now()
+	err = saveConfig(cfg)
+	lap(3, start)
+	if err != nil {
+		return run, err
+	}
+
+	start = time.Now()
+	_ = isExpired(cfg, target)
+	_, _ = requiredAWSProfile(cfg, target)
+	_ = activeAWSProfile()
+	_ = certWarning(cfg, target, clientCertExpiries())
+	lap(4, start)
+
+	start = time.Now()
+	run.verifyErr = probeContext(target)
+	lap(5, start)
+	return run, nil
+}
+
+// median of ds, which it sorts
+func median(ds []time.Duration) time.Duration {
+	slices.Sort(ds)
+	return ds[len(ds)/2]
+}
+
+// formatBenchDuration keeps sub-millisecond phases readable
+func formatBenchDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// handleBench: ksw bench [<name>] [-n <runs>]. Times a switch to <name>
+// (default: the current context) phase by phase, and switches back after
+// each run.
+func handleBench(cfg config) {
+	runs := 3
+	name := ""
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-n" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "%s -n wants a number of runs, got '%s'\n", warnStyle.Render("✗"), args[i+1])
+				os.Exit(1)
+			}
+			runs = n
+			i++
+		case name == "" && !strings.HasPrefix(args[i], "-"):
+			name = args[i]
+		default:
+			fmt.Fprintln(os.Stderr, "Usage: ksw bench [<name>] [-n <runs>]")
+			os.Exit(1)
+		}
+	}
+	current, contexts, err := getKubeconfigState()
+	if err != nil {
+		fatal(err)
+	}
+	if len(contexts) == 0 {
+		noContexts()
+	}
+	if name == "" {
+		name = current
+	}
+	target, err := resolveContext(name, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	if target != current {
+		if err := checkFreeze(cfg, current, target); err != nil {
+			fatal(err)
+		}
+	}
+
+	// Ctrl+C stops between runs, so ksw is never left on the target
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("%s Switching to %s %d times %s\n", dimStyle.Render("·"), shortName(target), runs,
+		dimStyle.Render("(and back to "+shortName(current)+" after each)"))
+	var done []benchRun
+	for i := 0; i < runs && ctx.Err() == nil; i++ {
+		run, err := benchSwitch(cfg, name)
+		if ctx.Err() != nil {
+			// Ctrl+C reaches kubectl too: this run's times are meaningless
+			break
+		}
+		if err != nil {
+			fatal(withExitCode(exitKubeconfig, err))
+		}
+		done = append(done, run)
+	}
+	if ctx.Err() != nil {
+		if getCurrentContext() != current {
+			_ = switchContext(current)
+		}
+		fmt.Fprintln(os.Stderr, dimStyle.Render("Interrupted."))
+		if len(done) == 0 {
+			os.Exit(exitCancelled)
+		}
+	}
+	printBench(cfg, target, done)
+}
+
+func printBench(cfg config, target string, runs []benchRun) {
+	medians := make([]time.Duration, len(benchPhases))
+	slowest := make([]time.Duration, len(benchPhases))
+	var total time.Duration
+	for p := range benchPhases {
+		ds := make([]time.Duration, len(runs))
+		for i, r := range runs {
+			ds[i] = r.times[p]
+		}
+		medians[p] = median(ds)
+		slowest[p] = ds[len(ds)-1]
+		total += medians[p]
+	}
+	top := 0
+	for p := range medians {
+		if medians[p] > medians[top] {
+			top = p
+		}
+	}
+
+	fmt.Printf("\n  %-18s %9s %9s\n", "", "median", "slowest")
+	for p, phase := range benchPhases {
+		share := ""
+		if total > 0 {
+			share = dimStyle.Render(fmt.Sprintf("%3.0f%%", float64(medians[p])/float64(total)*100))
+		}
+		label := fmt.Sprintf("%-18s", phase)
+		if p == top {
+			label = warnStyle.Render(label)
+		}
+		fmt.Printf("  %s %9s %9s  %s\n", label, formatBenchDuration(medians[p]), formatBenchDuration(slowest[p]), share)
+	}
+	fmt.Printf("  %-18s %9s\n", "total", formatBenchDuration(total))
+
+	fmt.Println()
+	if err := runs[len(runs)-1].verifyErr; err != nil {
+		fmt.Printf("  %s verification failed: %v\n", warnStyle.Render("!"), err)
+	}
+	// The API server round trip without credentials, to tell the network
+	// from the exec-credential plugin
+	if server, ok := getContextServers()[target]; ok && benchPhases[top] == "verification" {
+		if r := measureLatency(server); r.Err == nil {
+			fmt.Printf("  %s %s of verification is the network round trip; the rest is mostly the credential plugin\n",
+				dimStyle.Render("·"), formatBenchDuration(r.RTT))
+		}
+	}
+	var skipped []string
+	if cfg.KubectxSync {
+		skipped = append(skipped, "kubectx sync")
+	}
+	if len(cfg.Tunnels) > 0 {
+		skipped = append(skipped, "tunnels")
+	}
+	if cfg.Integrations.Slack.Enabled {
+		skipped = append(skipped, "Slack status")
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("  %s %s\n", dimStyle.Render("·"), dimStyle.Render("Not measured, since they change things outside ksw: "+strings.Join(skipped, ", ")))
+	}
+	fmt.Printf("  %s %s\n", dimStyle.Render("·"), dimStyle.Render("ksw <name> --debug logs each kubectl and aws call with its duration"))
+}
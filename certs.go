@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Client certificate expiry ──────────────────────────
+
+// defaultCertWarnDays is how close to its expiry a client certificate gets
+// flagged, unless cert_warn_days says otherwise
+const defaultCertWarnDays = 14
+
+func certWarnDays(cfg config) int {
+	if cfg.CertWarnDays > 0 {
+		return cfg.CertWarnDays
+	}
+	return defaultCertWarnDays
+}
+
+// clientCertExpiries returns when the client certificate of each context
+// expires, for contexts whose user authenticates with one
+func clientCertExpiries() map[string]time.Time {
+	out, err := output("kubectl", "config", "view", "--raw", "-o", "json")
+	if err != nil {
+		return nil
+	}
+	return parseClientCertExpiries(out)
+}
+
+// parseClientCertExpiries reads embedded certificates and certificate files
+// out of a "kubectl config view --raw -o json"
+func parseClientCertExpiries(data []byte) map[string]time.Time {
+	var kc struct {
+		Users []struct {
+			Name string `json:"name"`
+			User struct {
+				CertData string `json:"client-certificate-data"`
+				CertFile string `json:"client-certificate"`
+			} `json:"user"`
+		} `json:"users"`
+		Contexts []struct {
+			Name    string `json:"name"`
+			Context struct {
+				User string `json:"user"`
+			} `json:"context"`
+		} `json:"contexts"`
+	}
+	if json.Unmarshal(data, &kc) != nil {
+		return nil
+	}
+	byUser := make(map[string]time.Time)
+	for _, u := range kc.Users {
+		var raw []byte
+		switch {
+		case u.User.CertData != "":
+			raw, _ = base64.StdEncoding.DecodeString(u.User.CertData)
+		case u.User.CertFile != "":
+			raw, _ = os.ReadFile(u.User.CertFile)
+		}
+		if block, _ := pem.Decode(raw); block != nil && block.Type == "CERTIFICATE" {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				byUser[u.Name] = cert.NotAfter
+			}
+		}
+	}
+	expiries := make(map[string]time.Time)
+	for _, c := range kc.Contexts {
+		if t, ok := byUser[c.Context.User]; ok {
+			expiries[c.Name] = t
+		}
+	}
+	return expiries
+}
+
+// certLeft is how long until notAfter as a badge, e.g. "5d", "today" or
+// "expired"; "" when it is further away than days
+func certLeft(notAfter, now time.Time, days int) string {
+	left := notAfter.Sub(now)
+	switch {
+	case left <= 0:
+		return "expired"
+	case left < 24*time.Hour:
+		return "today"
+	case left <= time.Duration(days)*24*time.Hour:
+		return fmt.Sprintf("%dd", int(left.Hours()/24))
+	}
+	return ""
+}
+
+// certWarning describes a client certificate close to or past its expiry for
+// ksw check and switching; "" when it's fine
+func certWarning(cfg config, ctx string, expiries map[string]time.Time) string {
+	notAfter, ok := expiries[ctx]
+	if !ok {
+		return ""
+	}
+	switch left := certLeft(notAfter, time.Now(), certWarnDays(cfg)); left {
+	case "":
+		return ""
+	case "expired":
+		return "client certificate expired on " + notAfter.Format("2006-01-02")
+	case "today":
+		return "client certificate expires today"
+	default:
+		return "client certificate expires in " + left + " (" + notAfter.Format("2006-01-02") + ")"
+	}
+}
+
+// warnCertExpiry warns after a switch when the context's certificate is
+// about to expire, or already has
+func warnCertExpiry(cfg config, ctx string) {
+	if w := certWarning(cfg, ctx, clientCertExpiries()); w != "" {
+		fmt.Fprintf(os.Stderr, "  %s %s: %s\n", warnStyle.Render("⚠"), shortName(ctx), w)
+	}
+}
+
+// certExpiryMsg delivers client certificate expiries to the TUI, which loads
+// them after the first paint
+type certExpiryMsg map[string]time.Time
+
+func loadCertExpiryCmd() tea.Msg {
+	return certExpiryMsg(clientCertExpiries())
+}
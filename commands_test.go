@@ -0,0 +1,1486 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveContexts(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	tests := []struct {
+		name, mode string
+		want       []string
+	}{
+		{"payments-dev", "", []string{testContexts[0]}},
+		{"docker-desktop", "", []string{"docker-desktop"}},
+		{"search", "", []string{testContexts[3]}},
+		{"payments-prod", "exact", []string{testContexts[2]}},
+		{"payments", "exact", nil},
+		{"payments-", "prefix", testContexts[:3]},
+	}
+	for _, tt := range tests {
+		matchMode = tt.mode
+		got, _ := resolveContexts(tt.name, testContexts)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("resolveContexts(%q) with mode %q = %v, want %v", tt.name, tt.mode, got, tt.want)
+		}
+	}
+}
+
+// TestCompletionTreeHasEveryCommand keeps new commands completable: every
+// command main dispatches, besides the hidden __complete, needs a
+// completionTree entry
+func TestCompletionTreeHasEveryCommand(t *testing.T) {
+	src, err := os.ReadFile("main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatch := regexp.MustCompile(`(?m)^\t\tcase "([^"]+)"[^\n]*:\n\t\t\thandle`)
+	cases := dispatch.FindAllStringSubmatch(string(src), -1)
+	if len(cases) < 40 {
+		t.Fatalf("found only %d commands in main.go's dispatch", len(cases))
+	}
+	for _, c := range cases {
+		if c[1] != "__complete" && !slices.ContainsFunc(completionTree, func(cmd compCommand) bool { return cmd.name == c[1] }) {
+			t.Errorf("ksw %s isn't in completionTree", c[1])
+		}
+	}
+}
+
+func TestWhichRule(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	tests := []struct {
+		name, mode, rule string
+		want             []string
+	}{
+		{"docker-desktop", "", "exact", []string{"docker-desktop"}},
+		{"payments-dev", "", "suffix", []string{testContexts[0]}},
+		{"search", "", "substring", []string{testContexts[3]}},
+		{"prod", "", "suffix", testContexts[2:4]},
+		{"pay*", "", "glob", testContexts[:3]},
+		{"ments-d*", "", "glob *ments-d*", []string{testContexts[0]}},
+		{"payments", "exact", "", nil},
+		{"payments-", "prefix", "prefix (--prefix)", testContexts[:3]},
+	}
+	for _, tt := range tests {
+		matchMode = tt.mode
+		rule, got := whichRule(tt.name, testContexts)
+		if rule != tt.rule || !slices.Equal(got, tt.want) {
+			t.Errorf("whichRule(%q) with mode %q = %q %v, want %q %v", tt.name, tt.mode, rule, got, tt.rule, tt.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	got := didYouMean("paymnets-dev", testContexts)
+	if len(got) == 0 || got[0] != testContexts[0] {
+		t.Errorf("didYouMean(paymnets-dev) = %v, want %s first", got, testContexts[0])
+	}
+}
+
+func TestSwitchRecordsHistory(t *testing.T) {
+	f := newFakeKube(t, "docker-desktop")
+	out := runCommand(t, func(cfg config) { switchTo(cfg, "payments-qa", "") })
+
+	if f.current != testContexts[1] {
+		t.Fatalf("current = %s, want %s", f.current, testContexts[1])
+	}
+	if !strings.Contains(out, "Switched to "+testContexts[1]) {
+		t.Errorf("output = %q", out)
+	}
+	cfg := loadConfig()
+	if cfg.Previous != "docker-desktop" || len(cfg.HistoryLog) != 1 {
+		t.Errorf("previous = %q, history log = %v", cfg.Previous, cfg.HistoryLog)
+	}
+
+	// Switching to where we already are is not a switch
+	out = runCommand(t, func(cfg config) { switchTo(cfg, "payments-qa", "") })
+	if len(f.switches) != 1 || !strings.Contains(out, "Already on") {
+		t.Errorf("switches = %v, output = %q", f.switches, out)
+	}
+}
+
+func TestHome(t *testing.T) {
+	f := newFakeKube(t, "arn:aws:eks:us-east-1:222222222222:cluster/payments-prod")
+	runCommand(t, handleHome, "home", "set", "payments-dev")
+	if got := loadConfig().Home; got != testContexts[0] {
+		t.Fatalf("home = %q, want %s", got, testContexts[0])
+	}
+	runCommand(t, handleHome, "home")
+	if f.current != testContexts[0] {
+		t.Errorf("current = %s after ksw home", f.current)
+	}
+}
+
+func TestCurrentHonoursKswContext(t *testing.T) {
+	newFakeKube(t, testContexts[2])
+	if out := runCommand(t, handleCurrent, "current", "--short"); out != "payments-prod\n" {
+		t.Errorf("ksw current --short = %q", out)
+	}
+	t.Setenv("KSW_CONTEXT", "docker-desktop")
+	if out := runCommand(t, handleCurrent, "current"); out != "docker-desktop\n" {
+		t.Errorf("ksw current with KSW_CONTEXT = %q", out)
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	cfg := config{Aliases: map[string]string{"d": "payments-dev"}, Pins: []string{testContexts[2]}}
+	trashAlias(&cfg, "d", "")
+	trashPin(&cfg, testContexts[2], "")
+	writeConfig(t, cfg)
+
+	// Newest first: 1 is the pin, 2 the alias
+	runCommand(t, handleTrash, "trash", "restore", "2")
+	cfg = loadConfig()
+	if cfg.Aliases["d"] != "payments-dev" || len(cfg.Trash) != 1 || cfg.Trash[0].Kind != "pin" {
+		t.Fatalf("after restore: aliases = %v, trash = %v", cfg.Aliases, cfg.Trash)
+	}
+	runCommand(t, handleTrash, "trash", "restore", "payments-prod")
+	if cfg = loadConfig(); !slices.Equal(cfg.Pins, []string{testContexts[2]}) || len(cfg.Trash) != 0 {
+		t.Errorf("after restore: pins = %v, trash = %v", cfg.Pins, cfg.Trash)
+	}
+}
+
+func TestGroupColorTrash(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	writeConfig(t, config{Groups: map[string][]string{"prod": testContexts[2:4]}})
+	runCommand(t, handleGroup, "group", "color", "prod", "red")
+	cfg := loadConfig()
+	if cfg.GroupColors["prod"] != "#ff5555" {
+		t.Fatalf("group_colors = %v", cfg.GroupColors)
+	}
+	trashGroup(&cfg, "prod", "")
+	if _, ok := cfg.GroupColors["prod"]; ok {
+		t.Error("trashed group kept its color")
+	}
+	writeConfig(t, cfg)
+	runCommand(t, handleTrash, "trash", "restore", "prod")
+	if cfg = loadConfig(); cfg.GroupColors["prod"] != "#ff5555" {
+		t.Errorf("restored group color = %q", cfg.GroupColors["prod"])
+	}
+	runCommand(t, handleGroup, "group", "color", "prod", "off")
+	if cfg = loadConfig(); len(cfg.GroupColors) != 0 {
+		t.Errorf("color off left %v", cfg.GroupColors)
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	recordQuery(config{}, "pay", testContexts[2], 0)
+	if entries := loadQueryLog(); len(entries) != 0 {
+		t.Fatalf("recorded %v without record_queries", entries)
+	}
+
+	writeConfig(t, config{Aliases: map[string]string{"s": testContexts[3]}})
+	runCommand(t, handleStats, "stats", "--queries", "on")
+	cfg := loadConfig()
+	if !cfg.RecordQueries {
+		t.Fatal("ksw stats --queries on didn't turn recording on")
+	}
+	recordQuery(cfg, "payprod", testContexts[2], 0)
+	recordQuery(cfg, "payprod", testContexts[2], 0)
+	recordQuery(cfg, "pay", testContexts[2], 2)
+	recordQuery(cfg, "search", testContexts[3], 0)
+	recordQuery(cfg, "  ", testContexts[0], 0)
+
+	r := buildQueryStats(cfg, loadQueryLog(), 0, "")
+	if r.Picks != 4 || len(r.Contexts) != 2 {
+		t.Fatalf("report = %+v", r)
+	}
+	pp := r.Contexts[0]
+	if pp.Context != testContexts[2] || pp.NotFirst != 1 || pp.Queries[0] != (queryCount{"payprod", 2}) {
+		t.Errorf("payments-prod stats = %+v", pp)
+	}
+	if r.Contexts[1].Alias != "s" {
+		t.Errorf("search-prod alias = %q", r.Contexts[1].Alias)
+	}
+
+	out := runCommand(t, handleStats, "stats", "--queries")
+	if !strings.Contains(out, "ksw alias pay "+testContexts[2]) || strings.Contains(out, "ksw alias sea") {
+		t.Errorf("alias hints:\n%s", out)
+	}
+	runCommand(t, handleStats, "stats", "--queries", "off")
+	if loadConfig().RecordQueries {
+		t.Error("ksw stats --queries off left recording on")
+	}
+}
+
+func TestNotes(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	runCommand(t, handleNote, "note", "payments-prod", "No", "LB", "here")
+	cfg := loadConfig()
+	if cfg.Notes[testContexts[2]] != "No LB here" {
+		t.Fatalf("notes = %v", cfg.Notes)
+	}
+	// The note is printed on stderr, after the switch message
+	shown := func() string {
+		return runCommand(t, func(cfg config) {
+			prev := os.Stderr
+			os.Stderr = os.Stdout
+			defer func() { os.Stderr = prev }()
+			showNote(cfg, testContexts[2])
+		})
+	}
+	if out := shown(); !strings.Contains(out, "No LB here") {
+		t.Errorf("switch didn't show the note: %q", out)
+	}
+	runCommand(t, handleNote, "note", "mute", "payments-prod")
+	if out := shown(); out != "" {
+		t.Errorf("muted note still shown: %q", out)
+	}
+	runCommand(t, handleNote, "note", "unmute", "payments-prod")
+	if out := shown(); out == "" {
+		t.Error("unmuted note not shown")
+	}
+
+	cfg = loadConfig()
+	cfg.NotesMuted = []string{testContexts[2]}
+	renameContextRefs(&cfg, testContexts[2], "payments-pdn")
+	if cfg.Notes["payments-pdn"] != "No LB here" || cfg.NotesMuted[0] != "payments-pdn" {
+		t.Errorf("rename left notes = %v, muted = %v", cfg.Notes, cfg.NotesMuted)
+	}
+	dropContextRefs(&cfg, "payments-pdn", "")
+	if len(cfg.Notes) != 0 || len(cfg.NotesMuted) != 0 {
+		t.Errorf("drop left notes = %v, muted = %v", cfg.Notes, cfg.NotesMuted)
+	}
+}
+
+func TestTidyDeletable(t *testing.T) {
+	down := latencyResult{Err: errors.New("timeout")}
+	health := map[string]latencyResult{"up": {RTT: time.Millisecond}, "old": down, "recent": down, "current": down}
+	cfg := config{HistoryLog: []historyEntry{{Context: "recent", From: "up"}}}
+	for ctx, want := range map[string]bool{"up": false, "old": true, "recent": false, "current": false, "unmeasured": false} {
+		if got := tidyDeletable(cfg, ctx, "current", health); got != want {
+			t.Errorf("tidyDeletable(%s) = %v, want %v", ctx, got, want)
+		}
+	}
+
+	cfg = config{
+		Aliases:      map[string]string{"o": "old"},
+		MultiAliases: map[string][]string{"both": {"old", "up"}},
+		Pins:         []string{"old"},
+		Home:         "old",
+		Expiry:       map[string]string{"old": "2000-01-01"},
+	}
+	dropContextRefs(&cfg, "old", "ai")
+	if cfg.Home != "" || len(cfg.Expiry) != 0 || len(cfg.Aliases) != 0 || len(cfg.Pins) != 0 || !slices.Equal(cfg.MultiAliases["both"], []string{"up"}) {
+		t.Errorf("drop left %+v", cfg)
+	}
+	for _, e := range cfg.Trash {
+		if e.By != "ai" {
+			t.Errorf("trash entry %s not marked as removed by ai", e.Name)
+		}
+	}
+}
+
+func TestBatchSwitchHistory(t *testing.T) {
+	fake := newFakeKube(t, "docker-desktop")
+	batch := func(input string) {
+		t.Helper()
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = prev }()
+		fmt.Fprint(w, input)
+		w.Close()
+		runCommand(t, handleBatch, "batch")
+	}
+
+	batch("pin search-prod\nswitch payments-qa\n")
+	cfg := loadConfig()
+	if fake.current != testContexts[1] || len(cfg.HistoryLog) != 1 || cfg.Previous != "docker-desktop" || len(cfg.Pins) != 1 {
+		t.Fatalf("after the switch: current %s, log %v, previous %q, pins %v", fake.current, cfg.HistoryLog, cfg.Previous, cfg.Pins)
+	}
+	// Switching to the current context is no switch, and leaves no history
+	batch("switch payments-qa\n")
+	if cfg = loadConfig(); len(cfg.HistoryLog) != 1 || len(fake.switches) != 1 {
+		t.Errorf("no-op switch: log %v, switches %v", cfg.HistoryLog, fake.switches)
+	}
+}
+
+func TestBatchPinGroup(t *testing.T) {
+	// Run again below as a subprocess, to see the batch fail
+	if _, ok := os.LookupEnv("KSW_TEST_BATCH_PIN"); ok {
+		newFakeKube(t, "docker-desktop")
+		writeConfig(t, config{Groups: map[string][]string{"team": {testContexts[3], "gone-cluster"}}})
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdin = r
+		fmt.Fprint(w, "pin @team\n")
+		w.Close()
+		runCommand(t, handleBatch, "batch")
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestBatchPinGroup$")
+	cmd.Env = append(os.Environ(), "KSW_TEST_BATCH_PIN=1")
+	out, err := cmd.CombinedOutput()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 1 || !strings.Contains(string(out), "gone-cluster") {
+		t.Errorf("pin of a group with a deleted context: %v\n%s", err, out)
+	}
+}
+
+func TestReportChoice(t *testing.T) {
+	// Run again below as a subprocess, to see the cancelled exit code
+	if file, ok := os.LookupEnv("KSW_TEST_CANCEL_INTO"); ok {
+		printOnlyFlag, chooseIntoFile = true, file
+		reportChoice("")
+		return
+	}
+	fake := newFakeKube(t, "docker-desktop")
+	t.Cleanup(func() { printOnlyFlag, chooseIntoFile = false, "" })
+
+	// --print-only: the choice goes to stdout instead of being switched to
+	printOnlyFlag = true
+	m := initialModel(fake.contexts, fake.current, loadConfig(), "", false)
+	m.chosen = testContexts[1]
+	out := runCommand(t, func(config) { runTUI(m, fake.current) }, "--print-only")
+	if out != testContexts[1]+"\n" || len(fake.switches) != 0 {
+		t.Errorf("--print-only printed %q and switched %v", out, fake.switches)
+	}
+
+	// --choose-into replaces the file in one rename: a link to the old one
+	// keeps the old content, and no temp file is left behind
+	printOnlyFlag = false
+	dir := t.TempDir()
+	chooseIntoFile = filepath.Join(dir, "choice")
+	if err := os.WriteFile(chooseIntoFile, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(chooseIntoFile, filepath.Join(dir, "old")); err != nil {
+		t.Fatal(err)
+	}
+	if out := runCommand(t, func(config) { reportChoice(testContexts[2]) }); out != "" {
+		t.Errorf("--choose-into printed %q", out)
+	}
+	data, _ := os.ReadFile(chooseIntoFile)
+	old, _ := os.ReadFile(filepath.Join(dir, "old"))
+	entries, _ := os.ReadDir(dir)
+	if string(data) != testContexts[2]+"\n" || string(old) != "old\n" || len(entries) != 2 {
+		t.Errorf("--choose-into wrote %q, left the old file %q and %d entries", data, old, len(entries))
+	}
+
+	// Nothing picked: exit 130, nothing printed and the file left alone
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReportChoice$")
+	cmd.Env = append(os.Environ(), "KSW_TEST_CANCEL_INTO="+chooseIntoFile)
+	out2, err := cmd.Output()
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != exitCancelled || len(out2) != 0 {
+		t.Errorf("cancelled: %v, printed %q; want exit %d", err, out2, exitCancelled)
+	}
+	if data, _ := os.ReadFile(chooseIntoFile); string(data) != testContexts[2]+"\n" {
+		t.Errorf("cancelled choice changed the file to %q", data)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	t.Cleanup(func() { overrideFlag = false })
+	day := time.Now().Format(expiryLayout)
+	cfg := config{Freeze: freezeConfig{Windows: []freezeWindow{
+		{Name: "release", Start: day, End: day, Reason: "v2 rollout"},
+		{Envs: []string{"QA"}, Start: "2000-01-01", End: "2000-01-02"},
+	}}}
+
+	// Without envs or match the window covers the protected contexts
+	err := checkFreeze(cfg, "docker-desktop", testContexts[2])
+	if exitCode(err) != exitFrozen || !strings.Contains(err.Error(), "v2 rollout") {
+		t.Fatalf("switch to payments-prod during a freeze: %v", err)
+	}
+	if err := checkFreeze(cfg, "docker-desktop", testContexts[1]); err != nil {
+		t.Errorf("payments-qa is only frozen in 2000: %v", err)
+	}
+	cfg.Freeze.Windows[1].End = day
+	if err := checkFreeze(cfg, "docker-desktop", testContexts[1]); exitCode(err) != exitFrozen {
+		t.Errorf("env qa not frozen: %v", err)
+	}
+
+	overrideFlag = true
+	if err := checkFreeze(cfg, "docker-desktop", testContexts[2]); err != nil {
+		t.Fatalf("--override: %v", err)
+	}
+	data, err := os.ReadFile(auditLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e auditEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Event != "freeze_override" || e.Context != testContexts[2] || e.Freeze != "release" {
+		t.Errorf("audit log = %s (%v)", data, err)
+	}
+	overrideFlag = false
+
+	// Windows from a URL, fetched once while the cache is fresh
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprintf(w, `{"windows": [{"match": ["*search*"], "start": %q, "end": %q}]}`, day, day)
+	}))
+	defer srv.Close()
+	cfg = config{Freeze: freezeConfig{URL: srv.URL}}
+	for range 2 {
+		if err := checkFreeze(cfg, "docker-desktop", testContexts[3]); exitCode(err) != exitFrozen {
+			t.Errorf("search-prod not frozen by the remote window: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1 while the cache is fresh", fetches)
+	}
+	srv.Close()
+	cfg.Freeze.URL = srv.URL + "/"
+	if err := checkFreeze(cfg, "docker-desktop", testContexts[3]); err != nil {
+		t.Errorf("unreachable URL without a cached copy should not freeze: %v", err)
+	}
+}
+
+func TestTeamPolicy(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	t.Cleanup(func() { clear(policyLoaded) })
+	day := time.Now().Format(expiryLayout)
+	policy := fmt.Sprintf(`{"protected": ["*qa*"], "freeze": [{"match": ["docker-*"], "start": %q, "end": %q}],
+		"ai_forbidden": ["rename", "group"]}`, day, day)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, policy)
+	}))
+	defer srv.Close()
+
+	cfg := config{PolicyURL: srv.URL, Protected: []string{"*search*"}}
+	if !isProtected(cfg, testContexts[1]) || !isProtected(cfg, testContexts[3]) || isProtected(cfg, testContexts[2]) {
+		t.Error("policy globs should add to protected, not replace it")
+	}
+	if err := checkFreeze(cfg, testContexts[0], "docker-desktop"); exitCode(err) != exitFrozen {
+		t.Errorf("policy freeze not enforced: %v", err)
+	}
+	for name, allowed := range map[string]bool{"rename": false, "group add": false, "grouped": true, "switch": true} {
+		if err := aiAllowed(cfg, name); (err == nil) != allowed {
+			t.Errorf("aiAllowed(%s) = %v", name, err)
+		}
+	}
+	plan := []aiResponse{{Action: "switch", Context: "payments-dev"}, {Action: "command", Command: "group rm", Args: []string{"x"}}}
+	if err := validatePlan(plan, testContexts, cfg); err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("plan with a forbidden step: %v", err)
+	}
+
+	// Plain http only on this machine
+	if _, err := fetchSource("http://example.com/policy.json"); err == nil {
+		t.Error("fetched a policy over plain http")
+	}
+
+	// From a Git repository
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "ksw"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "ksw", "policy.json"), []byte(`{"ai_forbidden": ["delete"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-qm", "policy"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	cfg = config{PolicyURL: "git+file://" + repo + "#ksw/policy.json"}
+	if err := aiAllowed(cfg, "delete"); err == nil {
+		t.Error("policy from git not applied")
+	}
+}
+
+func TestCachedFetchRetryAfter(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	requests, down := 0, false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "v1")
+	}))
+	defer srv.Close()
+
+	if body, err := cachedFetch("test", srv.URL, 0, false); err != nil || string(body) != "v1" {
+		t.Fatalf("first fetch = %q, %v", body, err)
+	}
+	// The source goes down: the stale copy is used, and fetched once
+	down = true
+	for range 3 {
+		if body, err := cachedFetch("test", srv.URL, 0, false); err != nil || string(body) != "v1" {
+			t.Errorf("fetch while down = %q, %v; want the stale copy", body, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("%d requests, want 2: a failed fetch isn't retried for %s", requests, fetchRetryAfter)
+	}
+	// force fetches at once, and still falls back to the copy
+	if body, err := cachedFetch("test", srv.URL, time.Hour, true); err != nil || string(body) != "v1" || requests != 3 {
+		t.Errorf("forced fetch while down = %q, %v after %d requests; want the stale copy after 3", body, err, requests)
+	}
+
+	// Without a copy the error is returned, also without fetching again
+	other := srv.URL + "/other"
+	for range 2 {
+		if _, err := cachedFetch("test", other, 0, false); err == nil || !strings.Contains(err.Error(), "502") {
+			t.Errorf("fetch without a copy: %v", err)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("%d requests, want 4", requests)
+	}
+}
+
+func TestReconcileAuto(t *testing.T) {
+	f := newFakeKube(t, "docker-desktop")
+	writeConfig(t, config{
+		Aliases: map[string]string{"s": testContexts[3]},
+		Pins:    []string{testContexts[3]},
+		Home:    "gone-for-good",
+	})
+	// search-prod was renamed in the kubeconfig
+	f.contexts[3] = "arn:aws:eks:us-east-1:222222222222:cluster/search-prd"
+
+	runCommand(t, handleReconcile, "reconcile", "--auto")
+	cfg := loadConfig()
+	if cfg.Aliases["s"] != f.contexts[3] || !slices.Equal(cfg.Pins, []string{f.contexts[3]}) {
+		t.Errorf("rename not applied: aliases = %v, pins = %v", cfg.Aliases, cfg.Pins)
+	}
+	if cfg.Home != "gone-for-good" {
+		t.Errorf("home = %q, --auto must leave entries without a rename alone", cfg.Home)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	runCommand(t, handleExpire, "expire", "payments-qa", "2d")
+	cfg := loadConfig()
+	want := time.Now().AddDate(0, 0, 2).Format(expiryLayout)
+	if cfg.Expiry[testContexts[1]] != want {
+		t.Fatalf("expiry = %v, want %s", cfg.Expiry, want)
+	}
+	if isExpired(cfg, testContexts[1]) {
+		t.Error("context expired before its date")
+	}
+	cfg.Expiry[testContexts[1]] = time.Now().AddDate(0, 0, -1).Format(expiryLayout)
+	if !isExpired(cfg, testContexts[1]) {
+		t.Error("context not expired after its date")
+	}
+	if _, err := parseExpiry("next week"); err == nil {
+		t.Error("parseExpiry accepted 'next week'")
+	}
+}
+
+func TestIDEServer(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	writeConfig(t, config{
+		Aliases: map[string]string{"sp": "search-prod"},
+		Pins:    []string{testContexts[3]},
+		Groups:  map[string][]string{"payments": testContexts[:3]},
+	})
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"list","params":{"group":"payments"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resolve","params":{"name":"payments"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"switch","params":{"name":"@sp"}}`,
+		`{"jsonrpc":"2.0","method":"current"}`,
+		`{"jsonrpc":"2.0","id":"c","method":"current"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"delete"}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	serveIDE(strings.NewReader(in), &out)
+
+	type response struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	var resps []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, r)
+	}
+	if len(resps) != 6 {
+		t.Fatalf("got %d responses, want 6 (the notification gets none):\n%s", len(resps), out.String())
+	}
+
+	var list []ideContext
+	_ = json.Unmarshal(resps[0].Result, &list)
+	if len(list) != 3 || list[0].Short != "payments-dev" || !list[0].Current {
+		t.Errorf("list payments = %+v", list)
+	}
+	if e := resps[1].Error; e == nil || e.Code != exitAmbiguous {
+		t.Errorf("resolve 'payments' error = %+v, want code %d", e, exitAmbiguous)
+	}
+	var switched ideContext
+	_ = json.Unmarshal(resps[2].Result, &switched)
+	if switched.Name != testContexts[3] || !switched.Current || !switched.Pinned || !slices.Equal(switched.Aliases, []string{"sp"}) {
+		t.Errorf("switch @sp = %+v", switched)
+	}
+	if f.current != testContexts[3] || loadConfig().Previous != testContexts[0] {
+		t.Errorf("after switch: current %s, previous %s", f.current, loadConfig().Previous)
+	}
+	var current ideContext
+	_ = json.Unmarshal(resps[3].Result, &current)
+	if string(resps[3].ID) != `"c"` || current.Name != testContexts[3] {
+		t.Errorf("current = %s %+v", resps[3].ID, current)
+	}
+	if e := resps[4].Error; e == nil || e.Code != rpcMethodNotFound {
+		t.Errorf("unknown method error = %+v", e)
+	}
+	if e := resps[5].Error; e == nil || e.Code != rpcParseError {
+		t.Errorf("bad JSON error = %+v", e)
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	cfg := config{
+		Aliases:      map[string]string{"sp": "search-prod"},
+		MultiAliases: map[string][]string{"prods": {"payments-prod", "search-prod"}},
+		Groups:       map[string][]string{"payments": {testContexts[0], testContexts[1], "gone"}},
+	}
+	tests := []struct {
+		target string
+		want   []string
+		code   int
+	}{
+		{"@sp", []string{testContexts[3]}, 0},
+		{"@prods", []string{testContexts[2], testContexts[3]}, 0},
+		{"payments", []string{testContexts[0], testContexts[1]}, 0},
+		{"*-prod", []string{testContexts[2], testContexts[3]}, 0},
+		{"docker", []string{"docker-desktop"}, 0},
+		{"@nope", nil, exitNotFound},
+		{"prod", nil, exitAmbiguous},
+	}
+	for _, tt := range tests {
+		got, err := resolveTargets(cfg, tt.target, testContexts)
+		if tt.code != 0 {
+			if exitCode(err) != tt.code {
+				t.Errorf("%s: err = %v, want exit code %d", tt.target, err, tt.code)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, %v; want %v", tt.target, got, err, tt.want)
+		}
+	}
+}
+
+func TestLogsCommand(t *testing.T) {
+	ctx := testContexts[0]
+	tests := []struct {
+		tool, namespace, selector string
+		extra                     []string
+		want                      string
+	}{
+		{"stern", "", "app=api", nil, "stern --context " + ctx + " -l app=api"},
+		{"stern", "payments", "api", []string{"--since", "10m"}, "stern --context " + ctx + " -n payments api --since 10m"},
+		{"kubectl", "", "app=api,tier!=db", nil, "kubectl --context " + ctx + " logs -f -l app=api,tier!=db --all-containers --prefix"},
+		{"kubectl", "payments", "deploy/api", []string{"--tail", "50"}, "kubectl --context " + ctx + " logs -f -n payments deploy/api --tail 50"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(logsCommand(tt.tool, ctx, tt.namespace, tt.selector, tt.extra), " ")
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestAWSProfiles(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_DEFAULT_PROFILE", "")
+	cfg := config{
+		AWSProfiles: map[string]string{testContexts[2]: "payments-admin", "111111111111": "payments"},
+		Provenance:  map[string]provenance{testContexts[3]: {Source: "eks", Detail: "profile search, us-east-1"}},
+	}
+	tests := []struct{ ctx, want, from string }{
+		{testContexts[2], "payments-admin", "mapped"},
+		{testContexts[0], "payments", "account 111111111111"},
+		{testContexts[3], "search", "imported"},
+		{"docker-desktop", "", ""},
+	}
+	for _, tt := range tests {
+		if got, from := requiredAWSProfile(cfg, tt.ctx); got != tt.want || from != tt.from {
+			t.Errorf("%s: profile %q (%s), want %q (%s)", shortName(tt.ctx), got, from, tt.want, tt.from)
+		}
+	}
+	if got := activeAWSProfile(); got != "default" {
+		t.Errorf("active profile = %q with nothing set, want default", got)
+	}
+
+	writeConfig(t, cfg)
+	runCommand(t, handleAWSProfile, "aws-profile", "payments-qa", "qa")
+	runCommand(t, handleAWSProfile, "aws-profile", "rm", "111111111111")
+	got := loadConfig().AWSProfiles
+	if got[testContexts[1]] != "qa" || got["111111111111"] != "" {
+		t.Errorf("aws_profiles = %v", got)
+	}
+}
+
+func TestClientCertExpiry(t *testing.T) {
+	notAfter := time.Now().Add(5*24*time.Hour + time.Hour).Truncate(time.Second)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	kubeconfig := `{
+		"users": [{"name": "kind", "user": {"client-certificate-data": "` + data + `"}}, {"name": "eks", "user": {"exec": {}}}],
+		"contexts": [{"name": "kind-dev", "context": {"user": "kind"}}, {"name": "payments-dev", "context": {"user": "eks"}}]
+	}`
+	expiries := parseClientCertExpiries([]byte(kubeconfig))
+	if len(expiries) != 1 || !expiries["kind-dev"].Equal(notAfter) {
+		t.Fatalf("expiries = %v, want kind-dev at %v", expiries, notAfter)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		left time.Duration
+		days int
+		want string
+	}{
+		{-time.Hour, 14, "expired"},
+		{time.Hour, 14, "today"},
+		{5*24*time.Hour + time.Hour, 14, "5d"},
+		{5*24*time.Hour + time.Hour, 3, ""},
+	}
+	for _, tt := range tests {
+		if got := certLeft(now.Add(tt.left), now, tt.days); got != tt.want {
+			t.Errorf("certLeft(%v, %d days) = %q, want %q", tt.left, tt.days, got, tt.want)
+		}
+	}
+	if w := certWarning(config{}, "kind-dev", expiries); !strings.Contains(w, "expires in 5d") {
+		t.Errorf("warning = %q", w)
+	}
+	if w := certWarning(config{CertWarnDays: 3}, "kind-dev", expiries); w != "" {
+		t.Errorf("warning with cert_warn_days 3 = %q, want none", w)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	defer func(d time.Duration) { commandTimeout = d }(commandTimeout)
+	commandTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err := output("sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("a hanging command held ksw for %s", time.Since(start))
+	}
+	if out, err := output("echo", "ok"); err != nil || strings.TrimSpace(string(out)) != "ok" {
+		t.Errorf("output = %q, %v", out, err)
+	}
+
+	// Quitting the TUI kills what it started, without calling it a timeout
+	commandTimeout = time.Minute
+	stop := killCommandsOnQuit()
+	cmd, done := command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if err := done(cmd.Wait()); err == nil || strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err after quitting = %v, want the kill", err)
+	}
+}
+
+func TestKubeconfigMissing(t *testing.T) {
+	newFakeKube(t, "")
+	kube = kubectlBackend{}
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "config"))
+	// Nothing to read, so kubectl is never needed
+	t.Setenv("PATH", "")
+
+	if contexts, err := getContexts(); err != nil || len(contexts) != 0 {
+		t.Errorf("contexts = %v, %v; want none and no error", contexts, err)
+	}
+	if current, contexts, err := getKubeconfigState(); err != nil || current != "" || len(contexts) != 0 {
+		t.Errorf("state = %q, %v, %v; want empty", current, contexts, err)
+	}
+	if msg := noContextsMessage(); !strings.Contains(msg, "doesn't exist yet") || !strings.Contains(msg, "ksw eks kubeconfig") {
+		t.Errorf("message = %q", msg)
+	}
+
+	// Config-only commands still work
+	runCommand(t, handleAWSProfile, "aws-profile", "111111111111", "payments")
+	if got := loadConfig().AWSProfiles["111111111111"]; got != "payments" {
+		t.Errorf("aws_profiles = %v", loadConfig().AWSProfiles)
+	}
+}
+
+func TestConfigDoctor(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	writeConfig(t, config{Pins: []string{testContexts[2]}})
+
+	// A hand edit gone wrong: pins is no longer a list and the file is cut off
+	damaged := "{\n  \"aliases\": {\"pdn\": \"payments-prod\"},\n  \"pins\": \"oops\",\n  \"previous\": \"x\",\n  \"groups\": {\"pay"
+	if err := os.WriteFile(configPath(), []byte(damaged), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configDamage(); err == nil || !strings.Contains(err.Error(), "cut off at line 5") {
+		t.Errorf("damage = %v", err)
+	}
+	if err := saveConfig(loadConfig()); err == nil {
+		t.Error("saved over a damaged config")
+	}
+
+	out := runCommand(t, handleConfig, "config", "doctor", "--fix")
+	if !strings.Contains(out, "kept 2 settings, dropped pins, groups") {
+		t.Errorf("doctor --fix printed %q", out)
+	}
+	cfg := loadConfig()
+	if cfg.Aliases["pdn"] != "payments-prod" || cfg.Previous != "x" || configDamage() != nil {
+		t.Errorf("repaired config = aliases %v, previous %q", cfg.Aliases, cfg.Previous)
+	}
+	if data, _ := os.ReadFile(configPath() + ".damaged"); string(data) != damaged {
+		t.Error("the damaged file wasn't kept")
+	}
+
+	// The backup is the last save, from before the damage
+	if err := os.WriteFile(configPath(), []byte(`{"pins": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backupPath(), []byte(`{"pins": ["`+testContexts[2]+`"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, handleConfig, "config", "doctor", "--fix", "--from-backup")
+	if got := loadConfig().Pins; !slices.Equal(got, []string{testContexts[2]}) {
+		t.Errorf("pins after restoring the backup = %v", got)
+	}
+}
+
+func TestSaveConfigBackup(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	writeConfig(t, config{Previous: testContexts[1]})
+	data, err := os.ReadFile(backupPath())
+	if err != nil || !strings.Contains(string(data), testContexts[1]) {
+		t.Errorf("backup = %q, %v", data, err)
+	}
+	if _, err := os.Stat(configPath() + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestImportKubectx(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	home, _ := os.UserHomeDir()
+	writeConfig(t, config{Aliases: map[string]string{"taken": testContexts[0]}})
+	files := map[string]string{
+		".kube/kubectx": "payments-qa\n",
+		".zshrc": "export EDITOR=vim\nalias pdn='kubectx payments-prod'\nalias kd=\"kctx docker-desktop\"\n" +
+			"alias back='kubectx -'\nalias taken='kubectx search-prod'\nalias gone='kubectx old-cluster'\n",
+		".config/fish/config.fish": "alias search 'kubectx search-prod'\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(home, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := runCommand(t, handleImport, "import", "kubectx", "--dry-run")
+	if !strings.Contains(out, "Would import") || len(loadConfig().Aliases) != 1 {
+		t.Errorf("--dry-run changed the config or printed %q", out)
+	}
+	out = runCommand(t, handleImport, "import", "kubectx")
+	cfg := loadConfig()
+	want := map[string]string{"taken": testContexts[0], "pdn": testContexts[2], "kd": "docker-desktop", "search": testContexts[3]}
+	if !maps.Equal(cfg.Aliases, want) {
+		t.Errorf("aliases = %v, want %v", cfg.Aliases, want)
+	}
+	if cfg.Previous != testContexts[1] || cfg.History[0] != testContexts[1] {
+		t.Errorf("previous = %q, history = %v", cfg.Previous, cfg.History)
+	}
+	for _, skip := range []string{"@taken: already an alias", "@gone → old-cluster: no such context"} {
+		if !strings.Contains(out, skip) {
+			t.Errorf("output doesn't report %q:\n%s", skip, out)
+		}
+	}
+}
+
+func TestKubieConfigFiles(t *testing.T) {
+	home := t.TempDir()
+	for _, f := range []string{"config", "dev.yaml", "prod.yaml"} {
+		if err := os.MkdirAll(filepath.Join(home, ".kube"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(home, ".kube", f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yaml := "shell: zsh\nconfigs:\n  include:\n    - ~/.kube/config\n    - \"~/.kube/*.yaml\"\n  exclude:\n    - ~/.kube/prod.yaml\nprompt:\n  disable: true\n"
+	got := kubieConfigFiles([]byte(yaml), home)
+	want := []string{filepath.Join(home, ".kube", "dev.yaml")}
+	if !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestExportKubectx(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	home, _ := os.UserHomeDir()
+	writeConfig(t, config{Previous: testContexts[1]})
+	kubectxFile := filepath.Join(home, ".kube", "kubectx")
+
+	switchHooks(loadConfig(), testContexts[0], testContexts[2])
+	if _, err := os.Stat(kubectxFile); !os.IsNotExist(err) {
+		t.Fatal("wrote kubectx's state without ksw export kubectx")
+	}
+
+	runCommand(t, handleExport, "export", "kubectx")
+	if data, _ := os.ReadFile(kubectxFile); string(data) != testContexts[1]+"\n" {
+		t.Errorf("kubectx previous = %q right after export, want %s", data, testContexts[1])
+	}
+	switchHooks(loadConfig(), testContexts[0], testContexts[2])
+	if data, _ := os.ReadFile(kubectxFile); string(data) != testContexts[0]+"\n" {
+		t.Errorf("kubectx previous = %q after a switch, want %s", data, testContexts[0])
+	}
+
+	runCommand(t, handleExport, "export", "kubectx", "off")
+	if loadConfig().KubectxSync {
+		t.Error("ksw export kubectx off left the sync on")
+	}
+}
+
+func TestVersionVerbose(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	if out := runCommand(t, handleVersion, "-v"); out != "ksw v"+version+"\n" {
+		t.Errorf("ksw -v = %q", out)
+	}
+
+	writeConfig(t, config{AI: aiConfig{Provider: "claude"}, KubectxSync: true})
+	out := runCommand(t, handleVersion, "version", "--verbose")
+	for _, want := range []string{"platform    " + runtime.GOOS + "/" + runtime.GOARCH, "config      " + configPath(),
+		"go          " + runtime.Version(), "features    ai (claude), kubectx sync"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ksw version --verbose lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	knownFeatures["trial"] = "a subsystem still settling"
+	t.Cleanup(func() { delete(knownFeatures, "trial") })
+	t.Setenv("KSW_FEATURES", "")
+
+	if featureOn(loadConfig(), "trial") {
+		t.Fatal("trial on by default")
+	}
+	runCommand(t, handleFeatures, "features", "on", "trial")
+	cfg := loadConfig()
+	if !featureOn(cfg, "trial") {
+		t.Fatalf("features on trial: config has %v", cfg.Features)
+	}
+	if out := runCommand(t, handleVersion, "version", "--verbose"); !strings.Contains(out, "experimental trial") {
+		t.Errorf("ksw version --verbose doesn't list trial:\n%s", out)
+	}
+
+	t.Setenv("KSW_FEATURES", "other, -trial")
+	if featureOn(cfg, "trial") {
+		t.Error("KSW_FEATURES=-trial didn't override the config")
+	}
+	t.Setenv("KSW_FEATURES", "")
+
+	// An entry from an older version is listed, and can still be removed
+	cfg.Features["retired"] = true
+	writeConfig(t, cfg)
+	if out := runCommand(t, handleFeatures, "features"); !strings.Contains(out, "retired") || !strings.Contains(out, "trial") {
+		t.Errorf("ksw features:\n%s", out)
+	}
+	runCommand(t, handleFeatures, "features", "off", "retired")
+	runCommand(t, handleFeatures, "features", "off", "trial")
+	if cfg := loadConfig(); len(cfg.Features) != 0 {
+		t.Errorf("features after off: %v", cfg.Features)
+	}
+}
+
+func TestCrashReport(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	path, err := writeCrashReport("index out of range", []byte("goroutine 1 [running]:\nmain.main()\n"),
+		[]string{"/usr/local/bin/ksw", "k", "--on", "payments-prod", "get", "secrets", "--token=abc123", "config", "doctor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != crashDir() || !strings.HasPrefix(crashDir(), os.Getenv("HOME")) {
+		t.Errorf("report at %s, want it in %s", path, crashDir())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{"version:  " + version, "panic: index out of range", "main.main()",
+		"args:     ksw k --on <redacted> <redacted> <redacted> --token=<redacted> config doctor"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+	for _, secret := range []string{"payments-prod", "abc123"} {
+		if strings.Contains(report, secret) {
+			t.Errorf("report leaks %q", secret)
+		}
+	}
+}
+
+func TestDebugTrace(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	defer func(d time.Duration) { commandTimeout = d }(commandTimeout)
+	commandTimeout = 200 * time.Millisecond
+
+	_, _ = output("echo", "untraced")
+	startTrace()
+	if traceFile == nil {
+		t.Fatal("--debug didn't open the log")
+	}
+	t.Cleanup(func() { traceFile.Close(); traceFile = nil })
+	_, _ = output("sh", "-c", "exit 3")
+	_, _ = output("sleep", "5")
+	_, _ = output("kubectl-that-isnt-installed", "version")
+	_, _ = output("echo", strings.Repeat("x", 500), "two words")
+	_, _ = output("echo", "--token", "s3cret", "--token=s3cret")
+
+	data, err := os.ReadFile(debugLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{"exit 3     sh -c 'exit 3'", "timed out  sleep 5", "failed: exec:",
+		"exit 0     echo '" + strings.Repeat("x", 80) + "…(500 bytes)' 'two words'"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("debug log lacks %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "untraced") {
+		t.Error("a call before --debug was logged")
+	}
+	if strings.Contains(log, "s3cret") || !strings.Contains(log, "echo --token <redacted> --token=<redacted>") {
+		t.Errorf("--token values weren't redacted:\n%s", log)
+	}
+}
+
+func TestBench(t *testing.T) {
+	f := newFakeKube(t, "docker-desktop")
+	writeConfig(t, config{Tunnels: []tunnelConfig{{}}})
+	out := runCommand(t, handleBench, "bench", "search-prod", "-n", "2")
+
+	if f.current != "docker-desktop" {
+		t.Errorf("left on %s after ksw bench", f.current)
+	}
+	if want := []string{testContexts[3], "docker-desktop", testContexts[3], "docker-desktop"}; !slices.Equal(f.switches, want) {
+		t.Errorf("switches = %v, want %v", f.switches, want)
+	}
+	for _, want := range append(slices.Clone(benchPhases), "total", "Not measured, since they change things outside ksw: tunnels") {
+		if !strings.Contains(out, want) {
+			t.Errorf("ksw bench lacks %q:\n%s", want, out)
+		}
+	}
+	if cfg := loadConfig(); len(cfg.HistoryLog) != 0 || cfg.Previous != "" {
+		t.Errorf("ksw bench recorded history: previous %q, log %v", cfg.Previous, cfg.HistoryLog)
+	}
+}
+
+func TestAliasSuggestions(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	now := time.Now()
+	var log []historyEntry
+	switches := func(ctx string, n int, ago time.Duration) {
+		for range n {
+			log = append(log, historyEntry{Context: ctx, Time: now.Add(-ago).Unix()})
+		}
+	}
+	switches(testContexts[2], 9, time.Hour)       // payments-prod: most used, gets @pay
+	switches(testContexts[0], 6, time.Hour)       // payments-dev: @pay is taken, so @pay-dev
+	switches(testContexts[1], 2, time.Hour)       // payments-qa: not enough
+	switches(testContexts[3], 8, 60*24*time.Hour) // search-prod: long ago
+	switches("docker-desktop", 20, time.Hour)     // short enough already
+	cfg := config{HistoryLog: log, Aliases: map[string]string{"s": testContexts[3]}}
+
+	got := aliasSuggestions(cfg, testContexts, now)
+	want := []aliasSuggestion{{testContexts[2], "pay", 9}, {testContexts[0], "pay-dev", 6}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("suggestions = %v, want %v", got, want)
+	}
+
+	cfg.Aliases["pay"] = "somewhere-else"
+	cfg.NoSuggest = []string{testContexts[0]}
+	if got := aliasSuggestions(cfg, testContexts, now); len(got) != 1 || got[0].alias != "pay-prod" {
+		t.Errorf("with @pay taken and payments-dev declined: %v", got)
+	}
+	cfg.Aliases["pp"] = testContexts[2]
+	if got := aliasSuggestions(cfg, testContexts, now); len(got) != 0 {
+		t.Errorf("a context with an alias is still suggested: %v", got)
+	}
+
+	// Not a terminal: the commands to run instead of a prompt
+	writeConfig(t, config{HistoryLog: log})
+	out := runCommand(t, handleSuggest, "suggest")
+	if !strings.Contains(out, "ksw alias pay "+testContexts[2]) || !strings.Contains(out, "ksw alias pay-dev "+testContexts[0]) {
+		t.Errorf("ksw suggest:\n%s", out)
+	}
+
+	// The hint after a switch comes back a week later at the soonest
+	suggestHint(loadConfig(), testContexts[2])
+	at := loadConfig().SuggestHintAt
+	if at == 0 {
+		t.Fatal("no hint after switching to payments-prod")
+	}
+	cfg = loadConfig()
+	cfg.SuggestHintAt = at - 1
+	writeConfig(t, cfg)
+	suggestHint(loadConfig(), testContexts[0])
+	if loadConfig().SuggestHintAt != at-1 {
+		t.Error("hinted twice in a week")
+	}
+}
+
+func TestShellenv(t *testing.T) {
+	fake := newFakeKube(t, "docker-desktop")
+	euDev := "arn:aws:eks:eu-west-1:333333333333:cluster/payments-dev"
+	fake.contexts = append(fake.contexts, euDev)
+	// kubectl hands out the minified context, or renders the subset file
+	bin := t.TempDir()
+	full, _ := json.Marshal(map[string]any{"contexts": []any{
+		map[string]any{"name": testContexts[2], "context": map[string]any{}},
+		map[string]any{"name": testContexts[3], "context": map[string]any{}},
+	}})
+	stub := "#!/bin/sh\ncase \"$*\" in\n" +
+		"*--minify*) echo \"current-context: $6\" ;;\n" +
+		"*'-o json'*) echo '" + string(full) + "' ;;\n" +
+		"*) cat \"$5\" ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	writeConfig(t, config{MultiAliases: map[string][]string{"prods": {"payments-prod", "search-prod"}}})
+
+	// exported returns the file shellenv pointed KUBECONFIG at, and its content
+	exported := func(target string) (string, string) {
+		t.Helper()
+		out := runCommand(t, handleShellenv, "shellenv", target)
+		if !strings.Contains(out, saveKubeconfigLine) {
+			t.Errorf("shellenv %s doesn't save the previous KUBECONFIG:\n%s", target, out)
+		}
+		_, path, _ := strings.Cut(out, "export KUBECONFIG='")
+		path, _, _ = strings.Cut(path, "'")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("shellenv %s: %v\n%s", target, err, out)
+		}
+		return path, string(data)
+	}
+
+	// Two payments-dev in different regions get a file each
+	usPath, us := exported(testContexts[0])
+	euPath, eu := exported(euDev)
+	if usPath == euPath || !strings.Contains(us, testContexts[0]) || !strings.Contains(eu, euDev) {
+		t.Errorf("payments-dev files: %s = %q, %s = %q", usPath, us, euPath, eu)
+	}
+
+	// A multi alias gets a file with all its contexts
+	if _, prods := exported("@prods"); !strings.Contains(prods, testContexts[2]) || !strings.Contains(prods, testContexts[3]) {
+		t.Errorf("@prods file = %q", prods)
+	}
+
+	out := runCommand(t, handleShellenv, "shellenv", "--reset")
+	if !strings.Contains(out, restoreKubeconfigLine) || strings.Contains(out, "unset KUBECONFIG KSW_CONTEXT") {
+		t.Errorf("--reset doesn't restore the previous KUBECONFIG:\n%s", out)
+	}
+}
+
+func TestAliasTarget(t *testing.T) {
+	// ksw exec and ksw wait only read aliases for @name
+	cfg := config{Aliases: map[string]string{"docker-desktop": "search-prod", "dev": "payments-dev"}}
+	for name, want := range map[string]string{"@dev": "payments-dev", "docker-desktop": "docker-desktop", "dev": "dev"} {
+		if got, err := aliasTarget(cfg, name); err != nil || got != want {
+			t.Errorf("aliasTarget(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := aliasTarget(cfg, "@nope"); exitCode(err) != exitNotFound {
+		t.Errorf("unknown alias: %v", err)
+	}
+}
+
+func TestEach(t *testing.T) {
+	newFakeKube(t, testContexts[0])
+	// kubectl only has to hand out each context's kubeconfig
+	bin := t.TempDir()
+	stub := "#!/bin/sh\necho \"current-context: $6\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := config{Groups: map[string][]string{"payments": testContexts[:3]}}
+	targets, err := resolveTargets(cfg, "@payments", testContexts)
+	if err != nil || !slices.Equal(targets, testContexts[:3]) {
+		t.Fatalf("@payments = %v, %v", targets, err)
+	}
+
+	script := `echo "on $KSW_CONTEXT"; grep -q payments-prod "$KUBECONFIG" && { echo denied >&2; exit 3; }; printf done`
+	var stdout, stderr bytes.Buffer
+	results := runEach(cfg, targets, []string{"sh", "-c", script}, eachOpts{jobs: 2}, &stdout, &stderr)
+	for _, want := range []string{
+		"payments-dev  │ on " + testContexts[0] + "\n",
+		"payments-qa   │ on " + testContexts[1] + "\n",
+		"payments-dev  │ done\n",
+		"payments-prod │ on " + testContexts[2] + "\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout lacks %q:\n%s", want, stdout.String())
+		}
+	}
+	if stderr.String() != "payments-prod │ denied\n" {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	var summary bytes.Buffer
+	if n := printEachSummary(&summary, results, time.Second, false); n != 1 {
+		t.Errorf("%d failed, want 1", n)
+	}
+	if got := summary.String(); !strings.Contains(got, "Failed on 1 of 3 context(s)") || !strings.Contains(got, "payments-prod  exit 3") {
+		t.Errorf("summary:\n%s", got)
+	}
+	summary.Reset()
+	if n := printEachSummary(&summary, results[:2], time.Second, false); n != 0 || !strings.Contains(summary.String(), "OK on 2 context(s)") {
+		t.Errorf("summary of successes (%d):\n%s", n, summary.String())
+	}
+
+	// -o json: output kept per context, nothing printed
+	stdout.Reset()
+	results = runEach(cfg, targets, []string{"sh", "-c", script}, eachOpts{jobs: 4, capture: true}, &stdout, &stderr)
+	if stdout.Len() != 0 {
+		t.Errorf("printed while capturing: %q", stdout.String())
+	}
+	if r := results[2].report(); r.Status != "failed" || *r.ExitCode != 3 || r.Stdout != "on "+testContexts[2]+"\n" || r.Stderr != "denied\n" {
+		t.Errorf("payments-prod report = %+v", r)
+	}
+	if r := results[0].report(); r.Status != "ok" || *r.ExitCode != 0 || r.Stdout != "on "+testContexts[0]+"\ndone" {
+		t.Errorf("payments-dev report = %+v", r)
+	}
+
+	// --fail-fast: the first failure kills what runs and starts nothing more
+	results = runEach(cfg, targets, []string{"sh", "-c", `[ "$KSW_CONTEXT" = ` + testContexts[0] + ` ] && exit 1; exec sleep 5`},
+		eachOpts{jobs: 2, failFast: true, capture: true}, &stdout, &stderr)
+	var statuses []string
+	for i := range results {
+		statuses = append(statuses, results[i].status())
+	}
+	if want := []string{"failed", "cancelled", "skipped"}; !slices.Equal(statuses, want) {
+		t.Errorf("--fail-fast statuses = %v, want %v", statuses, want)
+	}
+	summary.Reset()
+	if n := printEachSummary(&summary, results, time.Second, true); n != 1 || !strings.Contains(summary.String(), "2 stopped by --fail-fast") ||
+		!strings.Contains(summary.String(), "payments-qa    cancelled") {
+		t.Errorf("wide summary (%d):\n%s", n, summary.String())
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	fake := newFakeKube(t, testContexts[0])
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	// Every context points at srv, so each one answers
+	fake.servers = make(map[string]string)
+	for _, ctx := range testContexts {
+		fake.servers[ctx] = srv.URL
+	}
+
+	var report []checkResult
+	if err := json.Unmarshal([]byte(runCommand(t, handleCheck, "check", "payments", "-o", "json", "--fail-fast")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 3 {
+		t.Fatalf("report = %+v, want the 3 payments contexts", report)
+	}
+	for _, e := range report {
+		if e.Status != "ok" || e.Server != srv.URL || e.LatencyMS == nil {
+			t.Errorf("%+v", e)
+		}
+	}
+
+	out := runCommand(t, handleCheck, "check", "docker", "-o=wide")
+	if !strings.Contains(out, "✔ docker-desktop") || !strings.Contains(out, srv.URL) || !strings.Contains(out, "ms") {
+		t.Errorf("wide:\n%s", out)
+	}
+
+	var opts reportOpts
+	if _, err := opts.parse([]string{"-o", "yaml"}, 0); err == nil {
+		t.Error("-o yaml accepted")
+	}
+}
+
+func TestWait(t *testing.T) {
+	fake := newFakeKube(t, testContexts[0])
+	fake.contexts = append(fake.contexts, "kind-dev-old", "arn:aws:eks:eu-west-1:333333333333:cluster/payments-dev")
+	prev := waitInterval
+	waitInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitInterval = prev })
+	// The API server refuses the first two requests, like a cluster still starting
+	bin := t.TempDir()
+	count := filepath.Join(bin, "count")
+	stub := "#!/bin/sh\necho x >> " + count + "\n[ $(wc -l < " + count + ") -gt 2 ] && exit 0\necho 'The connection to the server was refused' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// An alias sharing the name doesn't apply without the @
+	writeConfig(t, config{Aliases: map[string]string{"docker-desktop": testContexts[3]}})
+	out := runCommand(t, handleWait, "wait", "docker-desktop", "--timeout=5s")
+	if !strings.Contains(out, "docker-desktop is ready") {
+		t.Errorf("ksw wait: %q", out)
+	}
+	if data, _ := os.ReadFile(count); strings.Count(string(data), "x") != 3 {
+		t.Errorf("%d probes, want 3", strings.Count(string(data), "x"))
+	}
+
+	// A context that never shows up times out with the reason, even when
+	// another one has its name in it
+	_, err := waitReady(t.Context(), "kind-dev", 50*time.Millisecond)
+	if exitCode(err) != exitVerify || !strings.Contains(err.Error(), "wasn't ready after 50ms") || !strings.Contains(err.Error(), "kind-dev") {
+		t.Errorf("missing context: %v (exit %d)", err, exitCode(err))
+	}
+	// An ambiguous name won't get better by waiting
+	if _, err := waitReady(t.Context(), "payments-dev", time.Minute); exitCode(err) != exitAmbiguous {
+		t.Errorf("ambiguous name: %v", err)
+	}
+}
+
+func TestAddContext(t *testing.T) {
+	fake := newFakeKube(t, testContexts[0])
+	fake.servers = map[string]string{"kind-kind": "https://127.0.0.1:6443"}
+	// kubectl logs what it's asked; the probe fails while fail exists, and
+	// config view --raw keeps the user file it's given to merge
+	bin := t.TempDir()
+	log, fail, user := filepath.Join(bin, "log"), filepath.Join(bin, "fail"), filepath.Join(bin, "user")
+	stub := "#!/bin/sh\necho \"$*\" >> " + log + "\ncase \"$*\" in\n" +
+		"'config view --raw') f=${KUBECONFIG##*:}; stat -c %a \"$f\" > " + user + "; cat \"$f\" >> " + user + "; echo merged;;\n" +
+		"*'get --raw /api') [ -e " + fail + " ] && { echo 'error: You must be logged in to the server (Unauthorized)' >&2; exit 1; };;\n" +
+		"esac\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	kubeconfig := filepath.Join(bin, "config")
+	if err := os.WriteFile(kubeconfig, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+	calls := func() []string {
+		data, _ := os.ReadFile(log)
+		os.Remove(log)
+		return nonEmptyLines(strings.Split(string(data), "\n"))
+	}
+
+	s := addSpec{name: "lab", server: "https://10.0.0.1:6443", token: "s3cret", namespace: "apps"}
+	if err := os.WriteFile(fail, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := addContext(s, true)
+	if exitCode(err) != exitVerify || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("unverified add: %v", err)
+	}
+	calls()
+	if data, _ := os.ReadFile(kubeconfig); string(data) != "original" {
+		t.Errorf("wrote the real kubeconfig although the probe failed: %q", data)
+	}
+
+	os.Remove(fail)
+	if err := addContext(s, true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"config set-cluster lab --server https://10.0.0.1:6443 --kubeconfig " + kubeconfig,
+		"config set-context lab --cluster lab --user lab --namespace apps --kubeconfig " + kubeconfig,
+		"config view --raw",
+	}
+	got := calls()
+	if len(got) != 7 || !strings.HasPrefix(got[3], "--kubeconfig ") || !slices.Equal(got[4:], want) {
+		t.Errorf("kubectl calls:\n%s", strings.Join(got, "\n"))
+	}
+	if strings.Contains(strings.Join(got, "\n"), "s3cret") {
+		t.Error("the token was passed on kubectl's command line")
+	}
+	if data, _ := os.ReadFile(user); !strings.HasPrefix(string(data), "600\n") || !strings.Contains(string(data), `"token":"s3cret"`) {
+		t.Errorf("user file handed to kubectl: %q", data)
+	}
+	if data, _ := os.ReadFile(kubeconfig); string(data) != "merged\n" {
+		t.Errorf("kubeconfig = %q, want kubectl's merge", data)
+	}
+
+	s = addSpec{name: "edge", server: "https://x", exec: []string{"aws", "eks", "get-token", "--cluster-name", "edge"}}
+	if data := string(s.userKubeconfig()); !strings.Contains(data, `"command":"aws"`) || !strings.Contains(data, `"args":["eks","get-token","--cluster-name","edge"]`) {
+		t.Errorf("exec user: %s", data)
+	}
+
+	for _, name := range []string{"docker-desktop", "kind-kind"} {
+		if err := addContext(addSpec{name: name, server: "https://x", token: "t"}, false); err == nil || !strings.Contains(err.Error(), "already has a") {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// ksw add records where the context came from, for ksw info
+	runCommand(t, handleAdd, "add", "edge", "--server", "https://x", "--token", "t", "--no-verify")
+	if p := loadConfig().Provenance["edge"]; p.Source != "manual" || p.Detail != "ksw add, https://x" {
+		t.Errorf("provenance after ksw add = %+v", p)
+	}
+
+	// The exec plugin's argv follows --, spaces and all
+	runCommand(t, handleAdd, "add", "corp", "--server", "https://x", "--no-verify", "--", "corp-login", "--realm", "Corp Users")
+	if data, _ := os.ReadFile(user); !strings.Contains(string(data), `"command":"corp-login"`) || !strings.Contains(string(data), `"args":["--realm","Corp Users"]`) {
+		t.Errorf("exec user from argv: %q", data)
+	}
+}
+
+func TestSlackEnable(t *testing.T) {
+	newFakeKube(t, "docker-desktop")
+	runCommand(t, handleIntegrations, "integrations", "slack", "enable", "--token", "xoxp-1", "--prod", "*live*")
+	runCommand(t, handleIntegrations, "integrations", "slack", "enable", "--prod", "*live*", "--prod", "*pdn*")
+	if s := loadConfig().Integrations.Slack; !s.Enabled || s.Token != "xoxp-1" || !slices.Equal(s.ProdPatterns, []string{"*live*", "*pdn*"}) {
+		t.Errorf("slack = %+v", s)
+	}
+}
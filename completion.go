@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── Completion cache ───────────────────────────────────
+
+// completionCache holds the context list from the last kubectl call, keyed
+// by the kubeconfig files' size and mtime so it is refreshed whenever any
+// of them changes. TAB completion then never waits on kubectl.
+type completionCache struct {
+	Key      string   `json:"key"`
+	Contexts []string `json:"contexts"`
+}
+
+func completionCachePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw-completion.json")
+}
+
+// kubeconfigPaths returns the files kubectl merges: $KUBECONFIG or ~/.kube/config
+func kubeconfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var paths []string
+		for _, p := range filepath.SplitList(env) {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+		return paths
+	}
+	home, _ := os.UserHomeDir()
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// kubeconfigKey fingerprints the kubeconfig files without reading them
+func kubeconfigKey() string {
+	var parts []string
+	for _, p := range kubeconfigPaths() {
+		fi, err := os.Stat(p)
+		if err != nil {
+			parts = append(parts, p+":-")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", p, fi.Size(), fi.ModTime().UnixNano()))
+	}
+	return strings.Join(parts, "|")
+}
+
+// cachedContexts returns the context list, calling kubectl only when the
+// kubeconfig changed since the cache was written
+func cachedContexts() ([]string, error) {
+	key := kubeconfigKey()
+	if data, err := os.ReadFile(completionCachePath()); err == nil {
+		var c completionCache
+		if json.Unmarshal(data, &c) == nil && c.Key == key {
+			return c.Contexts, nil
+		}
+	}
+	contexts, err := getContexts()
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(completionCache{Key: key, Contexts: contexts})
+	_ = os.WriteFile(completionCachePath(), data, 0600)
+	return contexts, nil
+}
+
+// handleCompletionCache prints bare names for the completion scripts:
+// ksw completion cache [contexts|aliases|groups]
+func handleCompletionCache(cfg config) {
+	kind := "contexts"
+	if len(os.Args) >= 4 {
+		kind = os.Args[3]
+	}
+	var names []string
+	switch kind {
+	case "contexts":
+		contexts, err := cachedContexts()
+		if err != nil {
+			os.Exit(exitKubeconfig)
+		}
+		for _, ctx := range contexts {
+			if !slices.Contains(cfg.Archived, ctx) {
+				names = append(names, ctx)
+			}
+		}
+	case "aliases":
+		for a := range cfg.Aliases {
+			names = append(names, a)
+		}
+		for a := range cfg.MultiAliases {
+			names = append(names, a)
+		}
+		sort.Strings(names)
+	case "groups":
+		for g := range cfg.Groups {
+			names = append(names, g)
+		}
+		sort.Strings(names)
+	case "clear":
+		_ = os.Remove(completionCachePath())
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown cache kind '%s'.\nUsage: ksw completion cache [contexts|aliases|groups|clear]\n", kind)
+		os.Exit(1)
+	}
+	for _, n := range names {
+		fmt.Println(n)
+	}
+}
+
+// ── Command tree ───────────────────────────────────────
+
+// compCommand describes one top-level command for completion
+type compCommand struct {
+	name    string
+	desc    string
+	subs    []string          // subcommands offered as the next word
+	args    string            // what the remaining words complete to: "contexts", "aliases", "groups" or ""
+	subArgs map[string]string // overrides args after a given subcommand
+}
+
+// completionTree drives `ksw __complete`; a command added here is completable
+// in every shell without touching the scripts
+var completionTree = []compCommand{
+	{name: "history", desc: "Show recent context history", subs: []string{"export"}},
+	{name: "home", desc: "Switch to your home context", subs: []string{"set", "rm", "show"},
+		subArgs: map[string]string{"set": "contexts"}},
+	{name: "exec", desc: "Run a command against a context without switching", args: "contexts"},
+	{name: "logs", desc: "Tail logs on a context without switching", args: "contexts"},
+	{name: "k", desc: "Run kubectl on a context, alias or group without switching", subs: []string{"--on"},
+		subArgs: map[string]string{"--on": "contexts"}},
+	{name: "each", desc: "Run a command on every context of a group at once", subs: []string{"-j", "-o", "--fail-fast"}, args: "groups"},
+	{name: "current", desc: "Print the context in effect", subs: []string{"--short"}},
+	{name: "prompt", desc: "Print a shell prompt segment"},
+	{name: "project", desc: "Use the repo's .ksw.yaml context", subs: []string{"show", "use", "check", "hook"}},
+	{name: "expire", desc: "Time-box access to a context", subs: []string{"ls", "rm", "clean"}, args: "contexts",
+		subArgs: map[string]string{"ls": "", "clean": ""}},
+	{name: "freeze", desc: "List current and upcoming change freezes", subs: []string{"ls"}},
+	{name: "policy", desc: "Show the team policy from policy_url", subs: []string{"refresh"}},
+	{name: "note", desc: "Reminders printed after switching to a context", subs: []string{"ls", "rm", "mute", "unmute"}, args: "contexts",
+		subArgs: map[string]string{"ls": ""}},
+	{name: "aws-profile", desc: "Map contexts or AWS accounts to AWS profiles", subs: []string{"ls", "rm"}, args: "contexts",
+		subArgs: map[string]string{"ls": ""}},
+	{name: "info", desc: "Show details and provenance of a context", args: "contexts"},
+	{name: "trash", desc: "List and restore removed aliases, pins and groups", subs: []string{"ls", "restore", "empty"}},
+	{name: "config", desc: "Check and repair ~/.ksw.json", subs: []string{"doctor"}},
+	{name: "import", desc: "Migrate from kubectx or kubie", subs: []string{"kubectx", "kubie"}},
+	{name: "export", desc: "Keep kubectx's previous context in sync", subs: []string{"kubectx"}},
+	{name: "features", desc: "List or toggle experimental features", subs: []string{"ls", "on", "off"}},
+	{name: "bench", desc: "Time each phase of a context switch", args: "contexts"},
+	{name: "suggest", desc: "Offer aliases for contexts you use often"},
+	{name: "reconcile", desc: "Fix config entries pointing at deleted contexts", subs: []string{"--auto"}},
+	{name: "stats", desc: "Show switch stats and heatmap", subs: []string{"--queries"}},
+	{name: "group", desc: "Manage context groups", subs: []string{"add", "rm", "ls", "use", "default", "add-ctx", "rmi", "auto", "export", "color"},
+		subArgs: map[string]string{"use": "groups", "default": "groups", "color": "groups", "rm": "groups", "add-ctx": "groups", "rmi": "groups", "export": "groups", "add": "contexts", "ls": ""}},
+	{name: "pin", desc: "Pin contexts to the top of the list", subs: []string{"ls", "rm", "mv", "use"}, args: "contexts",
+		subArgs: map[string]string{"ls": "", "use": ""}},
+	{name: "alias", desc: "Manage aliases", subs: []string{"ls", "rm", "check"}, args: "contexts",
+		subArgs: map[string]string{"rm": "aliases", "ls": "", "check": ""}},
+	{name: "rename", desc: "Rename a context", args: "contexts"},
+	{name: "which", desc: "Show how a name resolves without switching", args: "contexts"},
+	{name: "shellenv", desc: "Print exports pinning a context to this terminal", args: "contexts"},
+	{name: "forward", desc: "Port-forward saved services of a context", subs: []string{"ls", "stop", "save"}, args: "contexts",
+		subArgs: map[string]string{"ls": "", "stop": ""}},
+	{name: "tunnel", desc: "Manage API server tunnels", subs: []string{"ls", "set", "rm", "up", "status"}, args: "contexts"},
+	{name: "check", desc: "Check API server reachability", subs: []string{"--latency", "-o", "--fail-fast"}, args: "contexts"},
+	{name: "wait", desc: "Wait until a context's API server is ready", subs: []string{"--timeout"}, args: "contexts"},
+	{name: "add", desc: "Create a context from a server URL and a token or exec plugin",
+		subs: []string{"--server", "--token", "--ca", "--insecure", "--namespace", "--no-verify"}},
+	{name: "archive", desc: "Hide a context from the TUI and completion", subs: []string{"ls"}, args: "contexts"},
+	{name: "unarchive", desc: "Show an archived context again", args: "contexts"},
+	{name: "watch", desc: "Remind you to leave an idle prod context", subs: []string{"set"}},
+	{name: "profile", desc: "Manage config profiles", subs: []string{"ls", "use", "rm"}},
+	{name: "batch", desc: "Apply alias, pin, group and switch lines from stdin", subs: []string{"--dry-run"}},
+	{name: "eks", desc: "Sync EKS clusters into the kubeconfig", subs: []string{"kubeconfig"}},
+	{name: "local", desc: "List kind, minikube and k3d clusters", subs: []string{"ls"}},
+	{name: "integrations", desc: "Notify Slack on prod switches", subs: []string{"slack"}},
+	{name: "ide-server", desc: "Serve the editor extensions' JSON-RPC on stdin"},
+	{name: "ai", desc: "Ask in natural language", subs: []string{"config", "chat", "summarize", "tidy", "models", "learned"}},
+	{name: "completion", desc: "Print shell completion setup", subs: []string{"zsh", "bash", "nu", "elvish", "install", "cache"}},
+	{name: "-", desc: "Switch to previous context"},
+	{name: "-l", desc: "List contexts"},
+	{name: "-v", desc: "Show version"},
+	{name: "version", desc: "Show version and build details", subs: []string{"--verbose"}},
+	{name: "-h", desc: "Show help"},
+}
+
+// completeWords returns candidates for the last of words, which is the
+// (possibly empty) word being completed; the others are already typed.
+// Each candidate is "value<TAB>description".
+func completeWords(cfg config, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	word := words[len(words)-1]
+	prefixed := func(names []string, desc string) []string {
+		var out []string
+		for _, n := range names {
+			if strings.HasPrefix(n, word) {
+				out = append(out, n+"\t"+desc)
+			}
+		}
+		return out
+	}
+
+	if len(words) == 1 {
+		var out []string
+		for _, c := range completionTree {
+			if strings.HasPrefix(c.name, word) {
+				out = append(out, c.name+"\t"+c.desc)
+			}
+		}
+		return append(out, completeContexts(cfg, word)...)
+	}
+
+	i := slices.IndexFunc(completionTree, func(c compCommand) bool { return c.name == words[0] })
+	if i < 0 {
+		return nil // ksw <context> takes no further arguments
+	}
+	c := completionTree[i]
+	kind := c.args
+	var out []string
+	if len(words) == 2 {
+		out = prefixed(c.subs, "subcommand")
+	} else if k, ok := c.subArgs[words[1]]; ok {
+		kind = k
+	}
+
+	switch kind {
+	case "contexts":
+		out = append(out, completeContexts(cfg, word)...)
+	case "aliases":
+		names := append(slices.Collect(maps.Keys(cfg.Aliases)), slices.Collect(maps.Keys(cfg.MultiAliases))...)
+		sort.Strings(names)
+		out = append(out, prefixed(names, "alias")...)
+	case "groups":
+		names := slices.Sorted(maps.Keys(cfg.Groups))
+		out = append(out, prefixed(names, "group")...)
+	}
+	return out
+}
+
+// ── ksw __complete ─────────────────────────────────────
+
+// completeContexts offers the contexts word resolves to at runtime, plus
+// aliases whose name starts with word. Values are always full context
+// names so accepting a candidate expands a short name or alias in place.
+func completeContexts(cfg config, word string) []string {
+	contexts, err := cachedContexts()
+	if err != nil {
+		return nil
+	}
+	contexts = slices.DeleteFunc(contexts, func(ctx string) bool { return slices.Contains(cfg.Archived, ctx) })
+
+	var out []string
+	seen := make(map[string]bool)
+	add := func(ctx, desc string) {
+		if !seen[ctx] {
+			seen[ctx] = true
+			out = append(out, ctx+"\t"+desc)
+		}
+	}
+
+	name := strings.TrimPrefix(word, "@")
+	aliases := make([]string, 0, len(cfg.Aliases))
+	for a := range cfg.Aliases {
+		if strings.HasPrefix(a, name) {
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Strings(aliases)
+	for _, a := range aliases {
+		// Alias targets may themselves be short names
+		if ctx, err := resolveContext(cfg.Aliases[a], contexts); err == nil {
+			add(ctx, "@"+a)
+		}
+	}
+	if strings.HasPrefix(word, "@") {
+		return out
+	}
+
+	matches := contexts
+	if word != "" {
+		matches, _ = resolveContexts(word, contexts)
+	}
+	for _, ctx := range matches {
+		add(ctx, shortName(ctx))
+	}
+	return out
+}
+
+// handleComplete is the hidden endpoint behind every shell's completion:
+// ksw __complete <words...> <partial> prints one "value<TAB>description"
+// line per candidate, then a cobra-style ":<directive>" line (4 = don't
+// fall back to file completion)
+func handleComplete(cfg config) {
+	for _, line := range completeWords(cfg, os.Args[2:]) {
+		fmt.Println(line)
+	}
+	fmt.Println(":4")
+}
+
+// ── Scripts ────────────────────────────────────────────
+
+// The scripts only forward the typed words to `ksw __complete`; candidates
+// are already matched, so shells must not filter them again by prefix.
+
+const zshCompletionScript = `_ksw() {
+  local -a values displays
+  local line
+  for line in "${(@f)$(ksw __complete "${(@)words[2,CURRENT-1]}" "$PREFIX" 2>/dev/null)}"; do
+    [[ -z $line || $line == :* ]] && continue
+    values+=("${line%%$'\t'*}")
+    displays+=("${line%%$'\t'*}  -- ${line#*$'\t'}")
+  done
+  compadd -U -l -d displays -a values
+}
+
+compdef _ksw ksw
+`
+
+const bashCompletionScript = `_ksw_complete() {
+  local cur="${COMP_WORDS[COMP_CWORD]}"
+  COMPREPLY=( $(ksw __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null | grep -v '^:' | cut -f1) )
+}
+
+complete -F _ksw_complete ksw
+`
+
+const nuCompletionScript = `# ksw completion for nushell
+def "nu-complete ksw" [context: string] {
+  let words = ($context | split row -r '\s+' | skip 1)
+  ^ksw __complete ...$words
+  | lines
+  | where {|l| not ($l | str starts-with ':') }
+  | each {|l| let p = ($l | split row "\t"); {value: ($p | first), description: ($p | last)} }
+}
+
+extern "ksw" [
+  ...args: string@"nu-complete ksw"
+]
+`
+
+const elvishCompletionScript = `# ksw completion for elvish
+use str
+set edit:completion:arg-completer[ksw] = {|@words|
+  var args = $words[1..]
+  ksw __complete $@args | from-lines | each {|l|
+    if (not (str:has-prefix $l ':')) {
+      var p = [(str:split "\t" $l)]
+      edit:complex-candidate $p[0] &display=$p[0]'  '$p[1]
+    }
+  }
+}
+`
+
+func printCompletionScript(shell string) {
+	switch shell {
+	case "zsh":
+		fmt.Print(zshCompletionScript)
+	case "bash":
+		fmt.Print(bashCompletionScript)
+	case "nu", "nushell":
+		fmt.Print(nuCompletionScript)
+	case "elvish":
+		fmt.Print(elvishCompletionScript)
+	}
+}
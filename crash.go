@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Crash reports ──────────────────────────────────────
+
+// crashDir holds one report per crash, for attaching to an issue
+func crashDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw", "crash")
+}
+
+// recoverCrash is deferred first thing in main: a panic in any command ends
+// with a report on disk instead of a bare stack trace
+func recoverCrash() {
+	if r := recover(); r != nil {
+		reportCrash(r, debug.Stack())
+	}
+}
+
+// reportCrash saves a crash report, says where, and exits 1. If the report
+// can't be written the stack goes to stderr instead, so it isn't lost.
+func reportCrash(r any, stack []byte) {
+	fmt.Fprintf(os.Stderr, "%s ksw crashed: %v\n", warnStyle.Render("✗"), r)
+	path, err := writeCrashReport(r, stack, os.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Could not save a crash report (%v):\n\n%s\n", err, stack)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s\n", dimStyle.Render("Report saved to"), path)
+	fmt.Fprintln(os.Stderr, dimStyle.Render("  Attaching it to an issue at https://github.com/YonierGomez/ksw/issues helps a lot."))
+	os.Exit(1)
+}
+
+// writeCrashReport writes what's needed to reproduce a crash, the build, the
+// command line with its private parts redacted and the stack, and returns
+// the report's path
+func writeCrashReport(r any, stack []byte, args []string) (string, error) {
+	dir := crashDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	b := currentBuild()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ksw crash report\n\n")
+	fmt.Fprintf(&sb, "time:     %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "version:  %s\n", b.Version)
+	fmt.Fprintf(&sb, "commit:   %s\n", b.Commit)
+	fmt.Fprintf(&sb, "go:       %s\n", b.GoVersion)
+	fmt.Fprintf(&sb, "platform: %s\n", b.Platform)
+	fmt.Fprintf(&sb, "args:     %s\n\n", strings.Join(redactArgs(args), " "))
+	fmt.Fprintf(&sb, "panic: %v\n\n%s", r, stack)
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.Format("20060102-150405"), os.Getpid()))
+	return path, os.WriteFile(path, []byte(sb.String()), 0600)
+}
+
+// redactArgs keeps what says which command crashed (ksw's own command and
+// subcommand names, flag names) and hides the rest: context names, queries
+// and flag values can be private
+func redactArgs(args []string) []string {
+	known := make(map[string]bool)
+	for _, c := range completionTree {
+		known[c.name] = true
+		for _, s := range c.subs {
+			known[s] = true
+		}
+	}
+	out := make([]string, 0, len(args))
+	for i, a := range args {
+		switch {
+		case i == 0:
+			out = append(out, filepath.Base(a))
+		case strings.HasPrefix(a, "-"):
+			if name, _, ok := strings.Cut(a, "="); ok {
+				a = name + "=<redacted>"
+			}
+			out = append(out, a)
+		case known[a]:
+			out = append(out, a)
+		default:
+			out = append(out, "<redacted>")
+		}
+	}
+	return out
+}
+
+// tuiCrash is the first panic inside a TUI program
+type tuiCrash struct {
+	mu    sync.Mutex
+	value any
+	stack []byte
+	quit  func()
+}
+
+func (c *tuiCrash) record(r any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value == nil {
+		c.value, c.stack = r, debug.Stack()
+	}
+}
+
+// crashMsg is what a Cmd that panicked returns instead of its message
+type crashMsg struct{}
+
+// crashGuard wraps a program's model so a panic in Init, Update, View or a
+// Cmd quits the program the normal way, which restores the terminal, and is
+// reported once Run returns
+type crashGuard struct {
+	tea.Model
+	crash *tuiCrash
+}
+
+func (g crashGuard) Init() (cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r)
+			cmd = tea.Quit
+		}
+	}()
+	return g.guard(g.Model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (m tea.Model, cmd tea.Cmd) {
+	if _, ok := msg.(crashMsg); ok {
+		return g, tea.Quit
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r)
+			m, cmd = g, tea.Quit
+		}
+	}()
+	next, cmd := g.Model.Update(msg)
+	g.Model = next
+	return g, g.guard(cmd)
+}
+
+func (g crashGuard) View() (view string) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r)
+			// Called from the event loop, which Quit would block on
+			go g.crash.quit()
+			view = ""
+		}
+	}()
+	return g.Model.View()
+}
+
+// guard runs cmd with the same recovery, including the Cmds a Batch expands to
+func (g crashGuard) guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				g.crash.record(r)
+				msg = crashMsg{}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				guarded[i] = g.guard(c)
+			}
+			msg = guarded
+		}
+		return msg
+	}
+}
+
+// guardedProgram is a tea.Program running under a crashGuard
+type guardedProgram struct {
+	*tea.Program
+	crash *tuiCrash
+}
+
+// newProgram is tea.NewProgram for every TUI in ksw
+func newProgram(m tea.Model, opts ...tea.ProgramOption) *guardedProgram {
+	c := &tuiCrash{}
+	p := tea.NewProgram(crashGuard{Model: m, crash: c}, opts...)
+	c.quit = p.Quit
+	return &guardedProgram{Program: p, crash: c}
+}
+
+// Run runs the program and returns the final model as the caller built it. A
+// panic inside is reported after the terminal is back, and exits.
+func (p *guardedProgram) Run() (tea.Model, error) {
+	result, err := p.Program.Run()
+	p.crash.mu.Lock()
+	r, stack := p.crash.value, p.crash.stack
+	p.crash.mu.Unlock()
+	if r != nil {
+		reportCrash(r, stack)
+	}
+	if g, ok := result.(crashGuard); ok {
+		result = g.Model
+	}
+	return result, err
+}
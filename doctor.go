@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ── ksw config doctor ──────────────────────────────────
+
+// backupPath holds a copy of the last config ksw wrote, refreshed on every
+// save, for ksw config doctor --fix --from-backup
+func backupPath() string {
+	return configPath() + ".bak"
+}
+
+// configDamage reports why ~/.ksw.json can't be read as a config: a syntax
+// error (a truncated write, a bad hand edit) or a field of the wrong type.
+// loadConfig falls back to defaults for those, so saveConfig refuses to
+// overwrite the file until it's repaired. nil when it's fine or absent.
+func configDamage() error {
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return errors.New(describeJSONError(data, err))
+	}
+	return nil
+}
+
+// describeJSONError turns a json error into something a person can find in
+// the file: a line and column, or the field holding the wrong type
+func describeJSONError(data []byte, err error) string {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		line, col := lineCol(data, syntax.Offset)
+		if syntax.Offset >= int64(len(data)) {
+			return fmt.Sprintf("cut off at line %d, column %d", line, col)
+		}
+		return fmt.Sprintf("line %d, column %d: %s", line, col, syntax.Error())
+	case errors.As(err, &typ):
+		return fmt.Sprintf("%q should be %s, not %s", typ.Field, jsonKind(typ.Type.Kind().String()), typ.Value)
+	}
+	return err.Error()
+}
+
+func lineCol(data []byte, offset int64) (int, int) {
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, int(offset) - bytes.LastIndexByte(before, '\n')
+}
+
+// jsonKind names a Go kind the way the config file spells it
+func jsonKind(kind string) string {
+	switch kind {
+	case "slice", "array":
+		return "a list"
+	case "map", "struct":
+		return "an object"
+	case "string":
+		return "a string"
+	case "bool":
+		return "true or false"
+	}
+	return "a number"
+}
+
+// salvageConfig keeps every top-level setting of a damaged config that still
+// reads as the right type, in file order, up to where the file is cut off.
+// dropped names the settings left out.
+func salvageConfig(data []byte) (kept config, n int, dropped []string) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return kept, 0, nil
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			dropped = append(dropped, key)
+			break
+		}
+		field, _ := json.Marshal(map[string]json.RawMessage{key: raw})
+		// Try it alone first: a wrong type still half-fills what it decodes into
+		var probe config
+		if json.Unmarshal(field, &probe) != nil {
+			dropped = append(dropped, key)
+			continue
+		}
+		_ = json.Unmarshal(field, &kept)
+		n++
+	}
+	return kept, n, dropped
+}
+
+// handleConfig: ksw config doctor [--fix [--from-backup]]
+func handleConfig(cfg config) {
+	if len(os.Args) < 3 || os.Args[2] != "doctor" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw config doctor [--fix [--from-backup]]")
+		os.Exit(1)
+	}
+	fix := slices.Contains(os.Args[3:], "--fix")
+	fromBackup := slices.Contains(os.Args[3:], "--from-backup")
+	path := configPath()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Println(dimStyle.Render("· No " + path + " yet; ksw uses its defaults"))
+		return
+	}
+	if err != nil {
+		fatal(err)
+	}
+	damage := configDamage()
+	if damage == nil && !(fix && fromBackup) {
+		fmt.Printf("%s %s is fine\n", successStyle.Render("✔"), path)
+		return
+	}
+	backup, backupErr := os.ReadFile(backupPath())
+	if backupErr == nil {
+		var c config
+		if json.Unmarshal(backup, &c) != nil {
+			backupErr = errors.New("the backup is damaged too")
+		}
+	}
+
+	if !fix {
+		fmt.Fprintf(os.Stderr, "%s %s is damaged: %s\n", warnStyle.Render("✗"), path, damage)
+		fmt.Fprintln(os.Stderr, dimStyle.Render("  ksw is running on defaults and won't overwrite it. Repair it with:"))
+		fmt.Fprintln(os.Stderr, "  ksw config doctor --fix                "+dimStyle.Render("keep every setting that still reads"))
+		if backupErr == nil {
+			fmt.Fprintln(os.Stderr, "  ksw config doctor --fix --from-backup  "+dimStyle.Render("restore the copy from ksw's last save"))
+		}
+		os.Exit(1)
+	}
+
+	// Whatever happens next, the damaged file stays around for a closer look
+	aside := path + ".damaged"
+	if damage != nil {
+		if err := os.WriteFile(aside, data, 0644); err != nil {
+			fatal(err)
+		}
+	}
+	var repaired []byte
+	var summary string
+	if fromBackup {
+		if backupErr != nil {
+			fatal(withExitCode(exitNotFound, fmt.Errorf("no usable backup at %s: %v", backupPath(), backupErr)))
+		}
+		repaired, summary = backup, "restored from "+backupPath()
+	} else {
+		kept, n, dropped := salvageConfig(data)
+		if repaired, err = json.MarshalIndent(kept, "", "  "); err != nil {
+			fatal(err)
+		}
+		summary = fmt.Sprintf("kept %d settings", n)
+		if len(dropped) > 0 {
+			summary += ", dropped " + strings.Join(dropped, ", ")
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, repaired, 0644); err != nil {
+		fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%s Repaired %s: %s\n", successStyle.Render("✔"), path, summary)
+	if damage != nil {
+		fmt.Println(dimStyle.Render("  The damaged file is at " + aside))
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── ksw each ───────────────────────────────────────────
+
+// eachJobs is how many contexts ksw each runs the command on at once,
+// unless -j says otherwise
+const eachJobs = 4
+
+// eachPalette colors the context prefixes so interleaved output stays
+// readable
+var eachPalette = []string{"#8be9fd", "#50fa7b", "#f1fa8c", "#bd93f9", "#ff79c6", "#ffb86c"}
+
+// eachOpts is how runEach runs the command
+type eachOpts struct {
+	jobs     int  // contexts at once
+	failFast bool // at the first failure, kill the running commands and start no more
+	capture  bool // keep each context's output in its result instead of printing it
+}
+
+// eachResult is how the command went on one context
+type eachResult struct {
+	ctx            string
+	label          string
+	code           int   // the command's exit code
+	err            error // set when it couldn't be run at all
+	duration       time.Duration
+	skipped        bool // --fail-fast stopped before it started
+	cancelled      bool // --fail-fast killed it
+	stdout, stderr bytes.Buffer
+}
+
+func (r *eachResult) failed() bool {
+	return !r.skipped && !r.cancelled && (r.err != nil || r.code != 0)
+}
+
+func (r *eachResult) status() string {
+	switch {
+	case r.skipped:
+		return "skipped"
+	case r.cancelled:
+		return "cancelled"
+	case r.failed():
+		return "failed"
+	}
+	return "ok"
+}
+
+// eachReport is one context in ksw each -o json
+type eachReport struct {
+	Context    string `json:"context"`
+	Status     string `json:"status"` // "ok", "failed", or "cancelled"/"skipped" after --fail-fast stopped
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+}
+
+// handleEach runs a command on every context of a target at once, each
+// through its own minimal kubeconfig, without switching:
+// ksw each <@group|@alias|group|glob> [-j N] [-o table|wide|json] [--fail-fast] -- <command> [args...]
+func handleEach(cfg config) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: ksw each <@group|@alias|glob> [-j N] [-o table|wide|json] [--fail-fast] -- <command> [args...]")
+		os.Exit(1)
+	}
+	sep := slices.Index(os.Args, "--")
+	if sep < 0 || sep == len(os.Args)-1 {
+		usage()
+	}
+	target, jobs := "", eachJobs
+	var report reportOpts
+	for i := 2; i < sep; i++ {
+		n, err := report.parse(os.Args[:sep], i)
+		if err != nil {
+			fatal(err)
+		}
+		if n > 0 {
+			i += n - 1
+			continue
+		}
+		switch a := os.Args[i]; {
+		case a == "-j" || a == "--jobs":
+			if i+1 == sep {
+				usage()
+			}
+			i++
+			a = os.Args[i]
+			fallthrough
+		case strings.HasPrefix(a, "-j=") || strings.HasPrefix(a, "--jobs="):
+			_, v, _ := strings.Cut(a, "=")
+			if v == "" {
+				v = a
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "%s -j wants a number of contexts at once, got '%s'\n", warnStyle.Render("✗"), v)
+				os.Exit(1)
+			}
+			jobs = n
+		case target == "":
+			target = a
+		default:
+			usage()
+		}
+	}
+	if target == "" {
+		usage()
+	}
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	targets, err := resolveTargets(cfg, target, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	start := time.Now()
+	opts := eachOpts{jobs: jobs, failFast: report.failFast, capture: report.format == "json"}
+	results := runEach(cfg, targets, os.Args[sep+1:], opts, os.Stdout, os.Stderr)
+	failed := 0
+	if report.format == "json" {
+		out := make([]eachReport, len(results))
+		for i := range results {
+			if results[i].failed() {
+				failed++
+			}
+			out[i] = results[i].report()
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		failed = printEachSummary(os.Stderr, results, time.Since(start), report.format == "wide")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// eachLabels are the prefixes of each context's lines: short names padded to
+// the same width, or full names when two short names collide
+func eachLabels(targets []string) []string {
+	labels := make([]string, len(targets))
+	seen := make(map[string]int)
+	for _, ctx := range targets {
+		seen[shortName(ctx)]++
+	}
+	width := 0
+	for i, ctx := range targets {
+		labels[i] = shortName(ctx)
+		if seen[labels[i]] > 1 {
+			labels[i] = ctx
+		}
+		width = max(width, lipgloss.Width(labels[i]))
+	}
+	for i := range labels {
+		labels[i] += strings.Repeat(" ", width-lipgloss.Width(labels[i]))
+	}
+	return labels
+}
+
+func (r *eachResult) report() eachReport {
+	e := eachReport{Context: r.ctx, Status: r.status(), DurationMS: r.duration.Milliseconds(),
+		Stdout: r.stdout.String(), Stderr: r.stderr.String()}
+	if r.err != nil {
+		e.Error = r.err.Error()
+	} else if !r.skipped && !r.cancelled {
+		e.ExitCode = &r.code
+	}
+	return e
+}
+
+// runEach runs argv on every target, at most opts.jobs at once, prefixing
+// each line it prints with the context it came from
+func runEach(cfg config, targets, argv []string, opts eachOpts, stdout, stderr io.Writer) []eachResult {
+	labels := eachLabels(targets)
+	results := make([]eachResult, len(targets))
+	for i, ctx := range targets {
+		results[i] = eachResult{ctx: ctx, label: strings.TrimSpace(labels[i])}
+	}
+	// Kubeconfigs are written per run rather than to ~/.kube/ksw, so they go
+	// away with it
+	dir, err := os.MkdirTemp("", "ksw-each-")
+	if err != nil {
+		for i := range results {
+			results[i].err = err
+		}
+		return results
+	}
+	defer os.RemoveAll(dir)
+
+	// With --fail-fast the first failure cancels run: running commands are
+	// killed and the rest never start
+	run, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.jobs)
+	for i, ctx := range targets {
+		// Contexts start in order, as slots free up
+		select {
+		case sem <- struct{}{}:
+		case <-run.Done():
+		}
+		if run.Err() != nil {
+			for j := i; j < len(results); j++ {
+				results[j].skipped = true
+			}
+			break
+		}
+		wg.Add(1)
+		go func(i int, ctx string, r *eachResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var out, errOut io.WriteCloser = nopWriteCloser{&r.stdout}, nopWriteCloser{&r.stderr}
+			if !opts.capture {
+				color := lipgloss.NewStyle().Foreground(lipgloss.Color(eachPalette[i%len(eachPalette)]))
+				prefix := color.Render(labels[i]) + dimStyle.Render(" │ ")
+				out = &prefixWriter{mu: &mu, w: stdout, prefix: prefix}
+				errOut = &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+			}
+			start := time.Now()
+			r.code, r.err = runEachOne(run, cfg, ctx, filepath.Join(dir, strconv.Itoa(i)+".yaml"), argv, out, errOut)
+			r.duration = time.Since(start)
+			if r.err != nil || r.code != 0 {
+				if run.Err() != nil {
+					r.cancelled = true
+				} else if opts.failFast {
+					cancel()
+				}
+			}
+			out.Close()
+			if r.err != nil && !r.cancelled {
+				errOut.Write([]byte(warnStyle.Render("✗ "+r.err.Error()) + "\n"))
+			}
+			errOut.Close()
+		}(i, ctx, &results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// nopWriteCloser is an io.WriteCloser whose Close does nothing
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// runEachOne runs argv against ctx with KUBECONFIG pointing at a kubeconfig
+// written to path, and returns its exit code. The command is killed when
+// run is cancelled.
+func runEachOne(run context.Context, cfg config, ctx, path string, argv []string, stdout, stderr io.Writer) (int, error) {
+	data, err := minifiedKubeconfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return 0, err
+	}
+	cmd := exec.CommandContext(run, argv[0], argv[1:]...)
+	// A killed shell may leave a child holding its output open; don't wait on it
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(append(os.Environ(), "KUBECONFIG="+path, "KSW_CONTEXT="+ctx), awsProfileEnv(cfg, ctx)...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return ee.ExitCode(), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+// printEachSummary reports which contexts failed, with exit codes and times,
+// and returns how many did. wide lists every context instead, as a table.
+func printEachSummary(w io.Writer, results []eachResult, total time.Duration, wide bool) int {
+	failed, stopped := 0, 0
+	width := 0
+	for i := range results {
+		r := &results[i]
+		switch {
+		case r.failed():
+			failed++
+		case r.skipped || r.cancelled:
+			stopped++
+		}
+		if wide || r.failed() {
+			width = max(width, lipgloss.Width(r.label))
+		}
+	}
+	row := func(indent string, r *eachResult) {
+		why := fmt.Sprintf("exit %d", r.code)
+		switch {
+		case r.skipped, r.cancelled:
+			why = r.status()
+		case r.err != nil:
+			why = "didn't run"
+		}
+		took := ""
+		if !r.skipped {
+			took = dimStyle.Render(formatBenchDuration(r.duration))
+		}
+		fmt.Fprintf(w, "%s%-*s  %-9s  %s\n", indent, width, r.label, why, took)
+	}
+	if wide {
+		for i := range results {
+			r := &results[i]
+			mark := successStyle.Render("✔")
+			switch {
+			case r.failed():
+				mark = warnStyle.Render("✗")
+			case r.skipped || r.cancelled:
+				mark = dimStyle.Render("·")
+			}
+			row("  "+mark+" ", r)
+		}
+	}
+	switch {
+	case failed == 0:
+		fmt.Fprintf(w, "%s OK on %d context(s) %s\n", successStyle.Render("✔"), len(results), dimStyle.Render("("+formatBenchDuration(total)+")"))
+		return 0
+	case wide:
+		fmt.Fprintf(w, "%s Failed on %d of %d context(s)\n", warnStyle.Render("✗"), failed, len(results))
+	default:
+		fmt.Fprintf(w, "%s Failed on %d of %d context(s):\n", warnStyle.Render("✗"), failed, len(results))
+		for i := range results {
+			if results[i].failed() {
+				row("    ", &results[i])
+			}
+		}
+	}
+	if stopped > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dimStyle.Render("·"), dimStyle.Render(fmt.Sprintf("%d stopped by --fail-fast", stopped)))
+	}
+	return failed
+}
+
+// prefixWriter writes whole lines to w, each after prefix. Lines from
+// commands running side by side don't mix, since they share mu.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.emit(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Close writes a last line the command didn't end with a newline
+func (p *prefixWriter) Close() error {
+	if len(p.buf) > 0 {
+		p.emit(append(p.buf, '\n'))
+		p.buf = nil
+	}
+	return nil
+}
+
+func (p *prefixWriter) emit(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+}
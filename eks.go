@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -131,7 +132,7 @@ func parseAWSProfiles(configPath string) ([]awsProfile, error) {
 // getDefaultRegion obtiene la región por defecto de AWS CLI.
 // Si falla, retorna "us-east-1" como fallback.
 func getDefaultRegion() string {
-	out, err := exec.Command("aws", "configure", "get", "region").Output()
+	out, err := output("aws", "configure", "get", "region")
 	if err != nil {
 		return "us-east-1"
 	}
@@ -188,11 +189,11 @@ func parseListClustersJSON(data []byte) ([]string, error) {
 // y retorna la lista de nombres de clústeres descubiertos.
 // Maneja errores de credenciales y red sin interrumpir la ejecución.
 func listEKSClusters(profile, region string) ([]string, error) {
-	out, err := exec.Command("aws", "eks", "list-clusters",
+	out, err := output("aws", "eks", "list-clusters",
 		"--profile", profile,
 		"--region", region,
 		"--output", "json",
-	).Output()
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list EKS clusters for profile '%s' in %s: %w", profile, region, err)
 	}
@@ -205,7 +206,7 @@ func listEKSClusters(profile, region string) ([]string, error) {
 // que corresponden a clústeres EKS (contienen "arn:aws:eks:").
 // Retorna un mapa donde las claves son los nombres de contexto EKS.
 func getExistingEKSContexts() (map[string]bool, error) {
-	out, err := exec.Command("kubectl", "config", "get-contexts", "-o", "name").Output()
+	out, err := output("kubectl", "config", "get-contexts", "-o", "name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig contexts: %w", err)
 	}
@@ -242,8 +243,7 @@ func updateKubeconfig(cluster, profile, region, tmpFile string) error {
 	if tmpFile != "" {
 		args = append(args, "--kubeconfig", tmpFile)
 	}
-	cmd := exec.Command("aws", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := combinedOutput("aws", args...); err != nil {
 		return fmt.Errorf("failed to update kubeconfig for cluster '%s': %s", cluster, strings.TrimSpace(string(out)))
 	}
 	return nil
@@ -258,9 +258,10 @@ func mergeKubeconfigs(mainKubeconfig string, tmpFiles []string) error {
 	paths = append(paths, tmpFiles...)
 	kubeconfigEnv := strings.Join(paths, ":")
 
-	cmd := exec.Command("kubectl", "config", "view", "--flatten")
+	cmd, done := command("kubectl", "config", "view", "--flatten")
 	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeconfigEnv)
 	out, err := cmd.Output()
+	err = done(err)
 	if err != nil {
 		return fmt.Errorf("failed to merge kubeconfigs: %w", err)
 	}
@@ -339,19 +340,23 @@ func handleEksKubeconfig(profileFilter string) {
 	}
 
 	var allDiscovered []eksCluster
-	for range profiles {
-		r := <-resultsCh
-		if r.err != nil {
-			fmt.Printf("  %s Scanning profile '%s' (%s)... %s\n",
-				warnStyle.Render("✗"), r.profile.Name, r.profile.Region, dimStyle.Render(r.err.Error()))
-			continue
-		}
-		fmt.Printf("  Scanning profile '%s' (%s)... %s\n",
-			r.profile.Name, r.profile.Region, successStyle.Render(fmt.Sprintf("%d clusters found", len(r.clusters))))
-		for _, c := range r.clusters {
-			allDiscovered = append(allDiscovered, eksCluster{Name: c, Profile: r.profile.Name, Region: r.profile.Region})
+	exitIfInterrupted(runWithProgress(context.Background(), "Scanning AWS profiles", len(profiles), func(_ context.Context, pr *progress) error {
+		for range profiles {
+			r := <-resultsCh
+			pr.Step()
+			if r.err != nil {
+				pr.Println(fmt.Sprintf("  %s Scanning profile '%s' (%s)... %s",
+					warnStyle.Render("✗"), r.profile.Name, r.profile.Region, dimStyle.Render(r.err.Error())))
+				continue
+			}
+			pr.Println(fmt.Sprintf("  Scanning profile '%s' (%s)... %s",
+				r.profile.Name, r.profile.Region, successStyle.Render(fmt.Sprintf("%d clusters found", len(r.clusters)))))
+			for _, c := range r.clusters {
+				allDiscovered = append(allDiscovered, eksCluster{Name: c, Profile: r.profile.Name, Region: r.profile.Region})
+			}
 		}
-	}
+		return nil
+	}))
 	fmt.Println()
 
 	if len(allDiscovered) == 0 {
@@ -402,25 +407,39 @@ func handleEksKubeconfig(profileFilter string) {
 
 	var result syncResult
 	var tmpFiles []string
-	for range newClusters {
-		s := <-syncCh
-		if s.err != nil {
-			fmt.Printf("  %s Failed: %s (%s)\n",
-				warnStyle.Render("✗"), s.cluster.Name, dimStyle.Render(s.err.Error()))
-			result.Failed++
-		} else {
-			fmt.Printf("  %s Added: %s (profile: %s)\n",
-				successStyle.Render("✔"), s.cluster.Name, s.cluster.Profile)
-			tmpFiles = append(tmpFiles, s.tmpFile)
-			result.Added++
+	exitIfInterrupted(runWithProgress(context.Background(), "Adding clusters", len(newClusters), func(_ context.Context, pr *progress) error {
+		for range newClusters {
+			s := <-syncCh
+			pr.Step()
+			if s.err != nil {
+				pr.Println(fmt.Sprintf("  %s Failed: %s (%s)",
+					warnStyle.Render("✗"), s.cluster.Name, dimStyle.Render(s.err.Error())))
+				result.Failed++
+			} else {
+				pr.Println(fmt.Sprintf("  %s Added: %s (profile: %s)",
+					successStyle.Render("✔"), s.cluster.Name, s.cluster.Profile))
+				tmpFiles = append(tmpFiles, s.tmpFile)
+				result.Added++
+			}
 		}
-	}
+		return nil
+	}))
 
 	// Merge todos los kubeconfigs temporales al principal
 	if len(tmpFiles) > 0 {
+		before, _ := getContexts()
 		if err := mergeKubeconfigs(mainKubeconfig, tmpFiles); err != nil {
 			fmt.Fprintf(os.Stderr, "\n%s %s\n", warnStyle.Render("✗"), err)
 		}
+		// Registrar la procedencia de los contextos nuevos (ksw info)
+		recordNewContexts(before, "eks", func(ctx string) string {
+			for _, c := range newClusters {
+				if strings.HasSuffix(ctx, ":cluster/"+c.Name) && strings.Contains(ctx, ":"+c.Region+":") {
+					return fmt.Sprintf("profile %s, %s", c.Profile, c.Region)
+				}
+			}
+			return ""
+		})
 	}
 
 	// 8. Mostrar clústeres existentes omitidos
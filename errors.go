@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ── Errors and exit codes ──────────────────────────────
+
+// Exit codes wrapper scripts can branch on. Any other failure exits with 1.
+const (
+	exitNotFound   = 2   // context, alias or group doesn't exist
+	exitAmbiguous  = 3   // a name matched more than one context
+	exitKubeconfig = 4   // kubectl couldn't read or update the kubeconfig
+	exitAI         = 5   // the AI provider failed or its answer was unusable
+	exitAlreadyOn  = 6   // already on the target, when already_on is "exit"
+	exitVerify     = 7   // --verify, ksw wait or ksw add: the API server rejected or didn't answer
+	exitFrozen     = 8   // a change freeze covers the target and --override wasn't given
+	exitCancelled  = 130 // Ctrl+C
+)
+
+// exitError tags an error with the exit code ksw should end with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with code; nil stays nil
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode maps err to the process exit code
+func exitCode(err error) int {
+	var ee *exitError
+	switch {
+	case errors.Is(err, errInterrupted), errors.Is(err, context.Canceled):
+		return exitCancelled
+	case errors.As(err, &ee):
+		return ee.code
+	default:
+		return 1
+	}
+}
+
+// fatal prints err the standard way and exits with its exit code
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+	os.Exit(exitCode(err))
+}
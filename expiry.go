@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Context expiry ─────────────────────────────────────
+
+// expiryLayout is how expiry dates are stored in config and accepted on the command line
+const expiryLayout = "2006-01-02"
+
+// expiresOn returns the day ctx expires, if one is set
+func expiresOn(cfg config, ctx string) (time.Time, bool) {
+	s, ok := cfg.Expiry[ctx]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(expiryLayout, s, time.Local)
+	return t, err == nil
+}
+
+// isExpired reports whether ctx's access window has ended; a context stays
+// valid through its expiry day
+func isExpired(cfg config, ctx string) bool {
+	t, ok := expiresOn(cfg, ctx)
+	return ok && !time.Now().Before(t.AddDate(0, 0, 1))
+}
+
+// parseExpiry accepts a date (2026-11-30) or a duration from today (30d, 12h)
+func parseExpiry(s string) (string, error) {
+	if t, err := time.ParseInLocation(expiryLayout, s, time.Local); err == nil {
+		return t.Format(expiryLayout), nil
+	}
+	d, err := parseSince(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid expiry '%s' (use a date like 2026-11-30 or a duration like 30d)", s)
+	}
+	return time.Now().Add(d).Format(expiryLayout), nil
+}
+
+// warnIfExpired is run after a switch so time-boxed access isn't used past its end
+func warnIfExpired(cfg config, ctx string) {
+	if isExpired(cfg, ctx) {
+		fmt.Fprintf(os.Stderr, "  %s %s expired on %s · %s\n", warnStyle.Render("⚠"), shortName(ctx), cfg.Expiry[ctx],
+			dimStyle.Render("ksw expire clean to remove it"))
+	}
+}
+
+// handleExpire manages time-boxed contexts:
+// ksw expire <ctx> <date|30d> | ksw expire ls | ksw expire rm <ctx> | ksw expire clean [--yes]
+func handleExpire(cfg config) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw expire <ctx> <date|30d> | ls | rm <ctx> | clean [--yes]")
+		os.Exit(1)
+	}
+	save := func() {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch sub := os.Args[2]; sub {
+	case "ls", "list":
+		if len(cfg.Expiry) == 0 {
+			fmt.Println(dimStyle.Render("No expiring contexts. Use: ksw expire <ctx> <date|30d>"))
+			return
+		}
+		names := make([]string, 0, len(cfg.Expiry))
+		for ctx := range cfg.Expiry {
+			names = append(names, ctx)
+		}
+		sort.Slice(names, func(i, j int) bool { return cfg.Expiry[names[i]] < cfg.Expiry[names[j]] })
+		for _, ctx := range names {
+			state := dimStyle.Render(cfg.Expiry[ctx])
+			if isExpired(cfg, ctx) {
+				state = warnStyle.Render("expired " + cfg.Expiry[ctx])
+			}
+			fmt.Printf("  %-40s %s\n", ctx, state)
+		}
+
+	case "rm", "remove":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw expire rm <ctx>")
+			os.Exit(1)
+		}
+		ctx := os.Args[3]
+		if _, ok := cfg.Expiry[ctx]; !ok {
+			contexts, err := getContexts()
+			if err != nil {
+				fatal(err)
+			}
+			if ctx, err = resolveContext(ctx, contexts); err != nil {
+				fatal(err)
+			}
+		}
+		if _, ok := cfg.Expiry[ctx]; !ok {
+			fmt.Fprintf(os.Stderr, "%s %s has no expiry.\n", warnStyle.Render("✗"), ctx)
+			os.Exit(exitNotFound)
+		}
+		delete(cfg.Expiry, ctx)
+		save()
+		fmt.Printf("%s %s no longer expires\n", successStyle.Render("✔"), ctx)
+
+	case "clean":
+		yes := len(os.Args) >= 4 && (os.Args[3] == "--yes" || os.Args[3] == "-y")
+		var expired []string
+		for ctx := range cfg.Expiry {
+			if isExpired(cfg, ctx) {
+				expired = append(expired, ctx)
+			}
+		}
+		sort.Strings(expired)
+		if len(expired) == 0 {
+			fmt.Println(dimStyle.Render("No expired contexts."))
+			return
+		}
+		removed := 0
+		for _, ctx := range expired {
+			if !yes {
+				fmt.Printf("  %s %s expired on %s. Delete from kubeconfig? [y/N]: ", warnStyle.Render("⚠"), ctx, cfg.Expiry[ctx])
+				var answer string
+				fmt.Scanln(&answer)
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					continue
+				}
+			}
+			if out, err := kubeconfigEdit(ctx, "delete-context", ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "  %s %s: %s\n", warnStyle.Render("✗"), ctx, strings.TrimSpace(string(out)))
+				continue
+			}
+			dropContextRefs(&cfg, ctx, "")
+			delete(cfg.Expiry, ctx)
+			removed++
+			fmt.Printf("  %s Deleted %s\n", successStyle.Render("✔"), ctx)
+		}
+		if removed > 0 {
+			save()
+		}
+
+	default:
+		// ksw expire <ctx> <date|30d>
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw expire <ctx> <date|30d>")
+			os.Exit(1)
+		}
+		contexts, err := getContexts()
+		if err != nil {
+			fatal(err)
+		}
+		ctx, err := resolveContext(sub, contexts)
+		if err != nil {
+			fatal(err)
+		}
+		date, err := parseExpiry(os.Args[3])
+		if err != nil {
+			fatal(err)
+		}
+		if cfg.Expiry == nil {
+			cfg.Expiry = make(map[string]string)
+		}
+		cfg.Expiry[ctx] = date
+		save()
+		fmt.Printf("%s %s expires on %s\n", successStyle.Render("✔"), ctx, date)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ── Feature flags ──────────────────────────────────────
+
+// knownFeatures are the experimental subsystems that ship behind a flag, by
+// name with a one-line description. Each is off until turned on in the
+// config's features map (ksw features on <name>) or KSW_FEATURES, and its
+// code checks featureOn. Once a subsystem is stable its entry goes away and
+// it's simply on.
+var knownFeatures = map[string]string{}
+
+// featureOn reports whether the named experimental feature is turned on.
+// KSW_FEATURES ("name,-other") wins over the config, to try one out in a
+// single shell or CI job.
+func featureOn(cfg config, name string) bool {
+	for _, f := range strings.Split(os.Getenv("KSW_FEATURES"), ",") {
+		switch strings.TrimSpace(f) {
+		case name:
+			return true
+		case "-" + name:
+			return false
+		}
+	}
+	return cfg.Features[name]
+}
+
+func featureNames() []string {
+	names := make([]string, 0, len(knownFeatures))
+	for name := range knownFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleFeatures: ksw features [ls] | on <name> | off <name>
+func handleFeatures(cfg config) {
+	sub := "ls"
+	if len(os.Args) > 2 {
+		sub = os.Args[2]
+	}
+	switch sub {
+	case "ls", "list":
+		if len(knownFeatures) == 0 {
+			fmt.Println(dimStyle.Render("No experimental features in this version."))
+		}
+		for _, name := range featureNames() {
+			state := dimStyle.Render("off")
+			if featureOn(cfg, name) {
+				state = successStyle.Render("on ")
+			}
+			fmt.Printf("  %s  %-20s %s\n", state, name, dimStyle.Render(knownFeatures[name]))
+		}
+		// Left over from an older version, or a typo
+		var stale []string
+		for name := range cfg.Features {
+			if _, ok := knownFeatures[name]; !ok {
+				stale = append(stale, name)
+			}
+		}
+		sort.Strings(stale)
+		for _, name := range stale {
+			fmt.Printf("  %s  %-20s %s\n", warnStyle.Render("?  "), name, dimStyle.Render("unknown here; ksw features off "+name+" removes it"))
+		}
+
+	case "on", "off":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: ksw features %s <name>\n", sub)
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		_, known := knownFeatures[name]
+		_, set := cfg.Features[name]
+		if !known && !(sub == "off" && set) {
+			fmt.Fprintf(os.Stderr, "%s Unknown feature '%s'.", warnStyle.Render("✗"), name)
+			if len(knownFeatures) > 0 {
+				fmt.Fprintf(os.Stderr, " Available: %s", strings.Join(featureNames(), ", "))
+			}
+			fmt.Fprintln(os.Stderr)
+			os.Exit(exitNotFound)
+		}
+		if sub == "on" {
+			if cfg.Features == nil {
+				cfg.Features = make(map[string]bool)
+			}
+			cfg.Features[name] = true
+		} else {
+			delete(cfg.Features, name)
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s %s is %s\n", successStyle.Render("✔"), name, sub)
+
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: ksw features [ls] | on <name> | off <name>")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ── Kubeconfig files ───────────────────────────────────
+
+// kubeconfigLabels names each kubeconfig file as briefly as the TUI can show
+// it: the base name, or parent/base when two files share one
+func kubeconfigLabels(files map[string]string) map[string]string {
+	byBase := make(map[string][]string)
+	for _, p := range files {
+		base := filepath.Base(p)
+		if !slices.Contains(byBase[base], p) {
+			byBase[base] = append(byBase[base], p)
+		}
+	}
+	labels := make(map[string]string)
+	for base, paths := range byBase {
+		for _, p := range paths {
+			labels[p] = base
+			if len(paths) > 1 {
+				labels[p] = filepath.Join(filepath.Base(filepath.Dir(p)), base)
+			}
+		}
+	}
+	return labels
+}
+
+// splitFileFilter takes the "file:<text>" operator out of a search. ok is
+// false when there is none, leaving the query untouched.
+func splitFileFilter(query string) (rest, file string, ok bool) {
+	if !strings.Contains(query, "file:") {
+		return query, "", false
+	}
+	var words []string
+	for _, w := range strings.Fields(query) {
+		if v, found := strings.CutPrefix(w, "file:"); found {
+			file, ok = strings.ToLower(v), true
+			continue
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " "), file, ok
+}
+
+// fileLabel names the kubeconfig file defining ctx, as its row shows it. With
+// a single file m.files is nil and every context comes from it.
+func (m *model) fileLabel(ctx string) string {
+	if m.files == nil {
+		return filepath.Base(kubeconfigPaths()[0])
+	}
+	return m.fileLabels[m.files[ctx]]
+}
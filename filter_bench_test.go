@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keystrokeBudget is how long filtering may take per keystroke on a 10k
+// context kubeconfig: one frame at 60Hz, so typing never lags the terminal.
+// BenchmarkApplyFilter shows where the time goes when this fails.
+const keystrokeBudget = 16 * time.Millisecond
+
+// syntheticContexts returns n EKS-style context names spread over a few
+// accounts, services and environments, like a large organization's kubeconfig
+func syntheticContexts(n int) []string {
+	services := []string{"payments", "search", "checkout", "identity", "ledger", "catalog", "notifications", "risk"}
+	envs := []string{"dev", "qa", "stg", "prod"}
+	contexts := make([]string, n)
+	for i := range contexts {
+		contexts[i] = fmt.Sprintf("arn:aws:eks:us-east-1:%012d:cluster/%s-%s-%d",
+			100000000000+i%37, services[i%len(services)], envs[(i/len(services))%len(envs)], i)
+	}
+	return contexts
+}
+
+// syntheticModel is a TUI model over n contexts with a handful of pins and aliases
+func syntheticModel(n int) model {
+	contexts := syntheticContexts(n)
+	cfg := config{Aliases: make(map[string]string)}
+	for i := 0; i < min(n, 20); i++ {
+		cfg.Pins = append(cfg.Pins, contexts[i*n/20])
+		cfg.Aliases[fmt.Sprintf("a%d", i)] = contexts[(i*n/20+1)%n]
+	}
+	return model{contexts: contexts, cfg: cfg, terminalHeight: 40, terminalWidth: 120}
+}
+
+func BenchmarkFuzzyMatch(b *testing.B) {
+	str := "arn:aws:eks:us-east-1:123456789012:cluster/payments-prod-42"
+	for _, pattern := range []string{"p", "pay", "payprod", "zzz"} {
+		b.Run(pattern, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				fuzzyMatch(str, pattern)
+			}
+		})
+	}
+}
+
+// BenchmarkFuzzyScore is the per-context cost inside applyFilter, where
+// names are lowercased once up front
+func BenchmarkFuzzyScore(b *testing.B) {
+	str := []rune("arn:aws:eks:us-east-1:123456789012:cluster/payments-prod-42")
+	for _, pattern := range []string{"p", "pay", "payprod", "zzz"} {
+		b.Run(pattern, func(b *testing.B) {
+			p := []rune(pattern)
+			b.ReportAllocs()
+			for b.Loop() {
+				fuzzyScore(str, p)
+			}
+		})
+	}
+}
+
+func BenchmarkApplyFilter(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			m := syntheticModel(n)
+			m.resetFilter()
+			b.ReportAllocs()
+			for b.Loop() {
+				// One keystroke at a time, as the user types
+				for _, q := range []string{"p", "pa", "pay", "payp", "payprod"} {
+					m.search = q
+					m.applyFilter()
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkViewScroll renders a frame per cursor move through a long list
+func BenchmarkViewScroll(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			m := syntheticModel(n)
+			m.view = newViewCache()
+			m.resetFilter()
+			b.ReportAllocs()
+			for b.Loop() {
+				m = sendMsg(m, tea.KeyMsg{Type: tea.KeyDown})
+				if m.cursor == len(m.filtered)-1 {
+					m.cursor, m.scrollOffset = 0, 0
+				}
+				_ = m.View()
+			}
+		})
+	}
+}
+
+func TestFilterLatencyBudget(t *testing.T) {
+	m := syntheticModel(10000)
+	m.resetFilter()
+	query := "payments-prod"
+	for i := 1; i <= len(query); i++ {
+		m.search = query[:i]
+		// Best of a few runs, so a busy machine doesn't fail the build
+		best := time.Duration(1<<63 - 1)
+		for range 5 {
+			start := time.Now()
+			m.applyFilter()
+			best = min(best, time.Since(start))
+		}
+		if best > keystrokeBudget {
+			t.Errorf("filtering 10k contexts for %q took %v, budget is %v", m.search, best, keystrokeBudget)
+		}
+	}
+	if len(m.filtered) == 0 {
+		t.Errorf("no contexts match %q", query)
+	}
+}
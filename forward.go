@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ── Port-forwards ──────────────────────────────────────
+
+// forwardDef is a saved port-forward for a context (ksw forward save)
+type forwardDef struct {
+	Target    string `json:"target"`              // svc:port or svc:local:remote
+	Namespace string `json:"namespace,omitempty"` // empty = context default
+}
+
+// forwardProc is a running kubectl port-forward started by ksw
+type forwardProc struct {
+	PID       int    `json:"pid"`
+	Context   string `json:"context"`
+	Namespace string `json:"namespace,omitempty"`
+	Target    string `json:"target"`
+	Local     int    `json:"local"`
+	Started   int64  `json:"started"`
+}
+
+func forwardsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw-forwards.json")
+}
+
+// loadForwards returns the port-forwards that are still alive
+func loadForwards() []forwardProc {
+	data, err := os.ReadFile(forwardsPath())
+	if err != nil {
+		return nil
+	}
+	var procs []forwardProc
+	_ = json.Unmarshal(data, &procs)
+	var alive []forwardProc
+	for _, p := range procs {
+		if syscall.Kill(p.PID, 0) == nil {
+			alive = append(alive, p)
+		}
+	}
+	return alive
+}
+
+func saveForwards(procs []forwardProc) {
+	data, _ := json.MarshalIndent(procs, "", "  ")
+	_ = os.WriteFile(forwardsPath(), data, 0644)
+}
+
+// parseForwardTarget splits "svc:port" or "svc:local:remote" into kubectl's
+// resource and port spec. A bare name is treated as a service.
+func parseForwardTarget(target string) (resource string, local, remote int, err error) {
+	parts := strings.Split(target, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", 0, 0, fmt.Errorf("invalid target '%s' (use svc:port or svc:local:remote)", target)
+	}
+	resource = parts[0]
+	if !strings.Contains(resource, "/") {
+		resource = "svc/" + resource
+	}
+	remote, err = strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid port in '%s'", target)
+	}
+	local = remote
+	if len(parts) == 3 {
+		local, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("invalid local port in '%s'", target)
+		}
+	}
+	return resource, local, remote, nil
+}
+
+// startForward launches kubectl port-forward in the background, detached from the terminal
+func startForward(ctx string, def forwardDef) (forwardProc, error) {
+	resource, local, remote, err := parseForwardTarget(def.Target)
+	if err != nil {
+		return forwardProc{}, err
+	}
+	args := []string{"--context", ctx, "port-forward", resource, fmt.Sprintf("%d:%d", local, remote)}
+	if def.Namespace != "" {
+		args = append(args, "-n", def.Namespace)
+	}
+
+	logDir := filepath.Join(os.TempDir(), "ksw-forwards")
+	_ = os.MkdirAll(logDir, 0755)
+	logFile, err := os.Create(filepath.Join(logDir, fmt.Sprintf("%s-%d.log", shortName(ctx), local)))
+	if err != nil {
+		return forwardProc{}, err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return forwardProc{}, fmt.Errorf("failed to start port-forward: %w", err)
+	}
+	// Give kubectl a moment to fail fast (bad service, port in use)
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case <-exited:
+		out, _ := os.ReadFile(logFile.Name())
+		return forwardProc{}, fmt.Errorf("port-forward exited: %s", truncate(strings.TrimSpace(string(out)), 200))
+	case <-time.After(700 * time.Millisecond):
+	}
+
+	return forwardProc{
+		PID:       cmd.Process.Pid,
+		Context:   ctx,
+		Namespace: def.Namespace,
+		Target:    def.Target,
+		Local:     local,
+		Started:   time.Now().Unix(),
+	}, nil
+}
+
+// ── handleForward ──────────────────────────────────────
+
+func handleForward(cfg config) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw forward <context> [svc:port] [-n <namespace>]")
+		fmt.Fprintln(os.Stderr, "       ksw forward save <context> <svc:port> [-n <namespace>]")
+		fmt.Fprintln(os.Stderr, "       ksw forward ls | stop <id|all>")
+		os.Exit(1)
+	}
+
+	// Pull out -n <namespace> wherever it appears
+	var args []string
+	namespace := ""
+	for i := 2; i < len(os.Args); i++ {
+		if (os.Args[i] == "-n" || os.Args[i] == "--namespace") && i+1 < len(os.Args) {
+			namespace = os.Args[i+1]
+			i++
+			continue
+		}
+		args = append(args, os.Args[i])
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		procs := loadForwards()
+		saveForwards(procs)
+		if len(procs) == 0 {
+			fmt.Println(dimStyle.Render("No port-forwards running."))
+		}
+		for i, p := range procs {
+			fmt.Printf("  %d  %s %s %s %s\n", i+1, currentValueStyle.Render(fmt.Sprintf("localhost:%d", p.Local)),
+				dimStyle.Render("→"), p.Target, dimStyle.Render("("+shortName(p.Context)+", pid "+strconv.Itoa(p.PID)+")"))
+		}
+		// Saved definitions
+		if len(cfg.Forwards) > 0 {
+			fmt.Println()
+			fmt.Println(dimStyle.Render("  Saved:"))
+			for ctx, defs := range cfg.Forwards {
+				for _, d := range defs {
+					fmt.Printf("    %s %s\n", normalItemStyle.Render(shortName(ctx)), d.Target)
+				}
+			}
+		}
+
+	case "stop":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw forward stop <id|all>")
+			os.Exit(1)
+		}
+		procs := loadForwards()
+		var kept []forwardProc
+		stopped := 0
+		for i, p := range procs {
+			if args[1] == "all" || args[1] == strconv.Itoa(i+1) {
+				_ = syscall.Kill(p.PID, syscall.SIGTERM)
+				fmt.Printf("%s Stopped localhost:%d → %s\n", successStyle.Render("✔"), p.Local, p.Target)
+				stopped++
+				continue
+			}
+			kept = append(kept, p)
+		}
+		saveForwards(kept)
+		if stopped == 0 {
+			fmt.Fprintf(os.Stderr, "%s No port-forward '%s'. Use 'ksw forward ls' to list.\n", warnStyle.Render("✗"), args[1])
+			os.Exit(1)
+		}
+
+	case "save":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw forward save <context> <svc:port> [-n <namespace>]")
+			os.Exit(1)
+		}
+		ctx := mustResolveContext(args[1])
+		if _, _, _, err := parseForwardTarget(args[2]); err != nil {
+			fatal(err)
+		}
+		if cfg.Forwards == nil {
+			cfg.Forwards = make(map[string][]forwardDef)
+		}
+		cfg.Forwards[ctx] = append(cfg.Forwards[ctx], forwardDef{Target: args[2], Namespace: namespace})
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Saved forward %s for %s\n", successStyle.Render("✔"), args[2], shortName(ctx))
+
+	default:
+		// ksw forward <context> [svc:port]
+		ctx := mustResolveContext(args[0])
+		var defs []forwardDef
+		if len(args) >= 2 {
+			defs = []forwardDef{{Target: args[1], Namespace: namespace}}
+		} else {
+			defs = cfg.Forwards[ctx]
+			if len(defs) == 0 {
+				fmt.Fprintf(os.Stderr, "%s No saved forwards for %s. Use: ksw forward save %s <svc:port>\n", warnStyle.Render("✗"), shortName(ctx), shortName(ctx))
+				os.Exit(1)
+			}
+		}
+		procs := loadForwards()
+		failed := false
+		for _, d := range defs {
+			p, err := startForward(ctx, d)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s %s: %v\n", warnStyle.Render("✗"), d.Target, err)
+				failed = true
+				continue
+			}
+			procs = append(procs, p)
+			fmt.Printf("%s localhost:%d → %s %s\n", successStyle.Render("✔"), p.Local, d.Target, dimStyle.Render("("+shortName(ctx)+")"))
+		}
+		saveForwards(procs)
+		if failed {
+			os.Exit(1)
+		}
+	}
+}
+
+// mustResolveContext resolves name to a single context or exits with an error
+func mustResolveContext(name string) string {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	ctx, err := resolveContext(name, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	return ctx
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ── Change freezes ─────────────────────────────────────
+
+// overrideFlag is --override: switch to a context even though a change
+// freeze covers it, and log that to the audit log
+var overrideFlag bool
+
+// freezeCacheTTL is how long windows fetched from freeze.url are used before
+// fetching them again
+const freezeCacheTTL = 5 * time.Minute
+
+// freezeWindow is a change freeze: between Start and End, switching to the
+// contexts it covers takes --override. Without Envs or Match it covers the
+// protected contexts.
+type freezeWindow struct {
+	Name   string   `json:"name,omitempty"`
+	Envs   []string `json:"envs,omitempty"`  // environment tags (prod, pdn...), as in ksw info
+	Match  []string `json:"match,omitempty"` // context globs
+	Start  string   `json:"start"`           // RFC 3339, "2006-01-02 15:04" or a day, local time
+	End    string   `json:"end"`             // a day alone lasts through its end
+	Reason string   `json:"reason,omitempty"`
+}
+
+type freezeConfig struct {
+	Windows []freezeWindow `json:"windows,omitempty"`
+	URL     string         `json:"url,omitempty"` // JSON list of windows shared by a team, fetched on switch
+}
+
+// parseFreezeTime reads a window bound; end makes a bare day last through
+// its end
+func parseFreezeTime(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(expiryLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid freeze time '%s' (use 2026-12-20, 2026-12-20 18:00 or RFC 3339)", s)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// covers reports whether the window applies to ctx, at any time
+func (w freezeWindow) covers(cfg config, ctx string) bool {
+	if len(w.Envs) == 0 && len(w.Match) == 0 {
+		return isProtected(cfg, ctx)
+	}
+	if env := contextEnv(ctx); env != "" && slices.ContainsFunc(w.Envs, func(e string) bool { return strings.EqualFold(e, env) }) {
+		return true
+	}
+	return slices.ContainsFunc(w.Match, func(p string) bool { return globMatch(p, ctx) })
+}
+
+// activeAt reports whether now is inside the window; windows with bounds
+// that don't parse never are
+func (w freezeWindow) activeAt(now time.Time) bool {
+	start, err := parseFreezeTime(w.Start, false)
+	if err != nil {
+		return false
+	}
+	end, err := parseFreezeTime(w.End, true)
+	return err == nil && !now.Before(start) && now.Before(end)
+}
+
+func (w freezeWindow) label() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return "change freeze"
+}
+
+// freezeWindows are the configured windows, then the team policy's, then
+// those from freeze.url, if any
+func freezeWindows(cfg config) []freezeWindow {
+	windows := slices.Concat(cfg.Freeze.Windows, loadedPolicy(cfg).Freeze)
+	if cfg.Freeze.URL != "" {
+		windows = append(windows, remoteFreezeWindows(cfg.Freeze.URL)...)
+	}
+	return windows
+}
+
+// activeFreeze returns the first window covering ctx at now
+func activeFreeze(cfg config, ctx string, now time.Time) (freezeWindow, bool) {
+	for _, w := range freezeWindows(cfg) {
+		if w.activeAt(now) && w.covers(cfg, ctx) {
+			return w, true
+		}
+	}
+	return freezeWindow{}, false
+}
+
+// checkFreeze is run before a switch to ctx: during a freeze covering it,
+// it fails with exitFrozen, unless --override was given, in which case the
+// override is logged and the switch goes ahead
+func checkFreeze(cfg config, from, ctx string) error {
+	w, ok := activeFreeze(cfg, ctx, time.Now())
+	if !ok {
+		return nil
+	}
+	end, _ := parseFreezeTime(w.End, true)
+	why := w.label()
+	if w.Reason != "" {
+		why += ": " + w.Reason
+	}
+	if !overrideFlag {
+		return withExitCode(exitFrozen, fmt.Errorf("%s is frozen until %s (%s). Add --override to switch anyway; it's logged to %s",
+			shortName(ctx), end.Local().Format("Jan 2 15:04"), why, auditLogPath()))
+	}
+	if err := writeAudit(auditEntry{Event: "freeze_override", Context: ctx, From: from, Freeze: w.label(), Reason: w.Reason}); err != nil {
+		return fmt.Errorf("--override: couldn't write the audit log, not switching: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s\n", warnStyle.Render("⚠"), warnStyle.Render("Overriding "+why))
+	return nil
+}
+
+// ── Remote windows ─────────────────────────────────────
+
+// remoteFreezeWindows returns the windows published at src, a JSON list, bare
+// or as {"windows": [...]}. Windows that can't be read are reported and
+// skipped.
+func remoteFreezeWindows(src string) []freezeWindow {
+	b, err := cachedFetch("freeze", src, freezeCacheTTL, false)
+	if err == nil {
+		var windows []freezeWindow
+		if err = json.Unmarshal(b, &windows); err == nil {
+			return windows
+		}
+		var wrapped freezeConfig
+		if err = json.Unmarshal(b, &wrapped); err == nil {
+			return wrapped.Windows
+		}
+		err = fmt.Errorf("%s isn't a list of freeze windows: %v", src, err)
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s\n", warnStyle.Render("⚠"), warnStyle.Render("freeze: "+err.Error()))
+	return nil
+}
+
+// ── Audit log ──────────────────────────────────────────
+
+// auditEntry is one line of the audit log: something ksw was told to do
+// despite a guard
+type auditEntry struct {
+	Time    string `json:"time"`
+	User    string `json:"user,omitempty"`
+	Event   string `json:"event"`
+	Context string `json:"context"`
+	From    string `json:"from,omitempty"`
+	Freeze  string `json:"freeze,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func auditLogPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw", "audit.log")
+}
+
+// writeAudit appends e to the audit log, one JSON object per line
+func writeAudit(e auditEntry) error {
+	e.Time = time.Now().Format(time.RFC3339)
+	if u, err := user.Current(); err == nil {
+		e.User = u.Username
+	}
+	path := auditLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(e)
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ── ksw freeze ─────────────────────────────────────────
+
+// handleFreeze: ksw freeze [ls]. Lists the freeze windows, configured and
+// from freeze.url, that haven't ended yet.
+func handleFreeze(cfg config) {
+	if len(os.Args) >= 3 && os.Args[2] != "ls" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw freeze [ls]")
+		os.Exit(1)
+	}
+	now := time.Now()
+	shown := 0
+	for _, w := range freezeWindows(cfg) {
+		start, err1 := parseFreezeTime(w.Start, false)
+		end, err2 := parseFreezeTime(w.End, true)
+		if err := errors.Join(err1, err2); err != nil {
+			fmt.Printf("  %s %s: %v\n", warnStyle.Render("✗"), w.label(), err)
+			continue
+		}
+		if !now.Before(end) {
+			continue
+		}
+		state := dimStyle.Render("upcoming")
+		if w.activeAt(now) {
+			state = warnStyle.Render("active")
+		}
+		covers := "protected contexts"
+		if len(w.Envs) > 0 || len(w.Match) > 0 {
+			covers = strings.Join(append(slices.Clone(w.Envs), w.Match...), ", ")
+		}
+		fmt.Printf("  %s %s  %s → %s  %s\n", state, w.label(), start.Local().Format("Jan 2 15:04"), end.Local().Format("Jan 2 15:04"),
+			dimStyle.Render(covers))
+		if w.Reason != "" {
+			fmt.Printf("    %s\n", w.Reason)
+		}
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println(dimStyle.Render("No current or upcoming change freezes."))
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Group colors ───────────────────────────────────────
+
+// groupColorNames are the names ksw group color accepts besides #rgb and
+// #rrggbb, from the same palette as the rest of the TUI
+var groupColorNames = map[string]string{
+	"red":    "#ff5555",
+	"orange": "#ffb86c",
+	"yellow": "#f1fa8c",
+	"green":  "#50fa7b",
+	"cyan":   "#8be9fd",
+	"blue":   "#6272a4",
+	"purple": "#bd93f9",
+	"pink":   "#ff79c6",
+	"gray":   "#888888",
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseGroupColor turns a color name or hex value into what lipgloss takes
+func parseGroupColor(s string) (string, bool) {
+	if hex, ok := groupColorNames[strings.ToLower(s)]; ok {
+		return hex, true
+	}
+	if hexColorRe.MatchString(s) {
+		return strings.ToLower(s), true
+	}
+	return "", false
+}
+
+// groupColor is the color of ctx's primary group: the active group filter,
+// then a group it's the default context of, then the first group by name
+// that contains it. "" when none of them has a color.
+func (m *model) groupColor(ctx string) string {
+	colors := m.cfg.GroupColors
+	if len(colors) == 0 {
+		return ""
+	}
+	if c := colors[m.activeGroup]; c != "" && slices.Contains(m.cfg.Groups[m.activeGroup], ctx) {
+		return c
+	}
+	names := make([]string, 0, len(colors))
+	for g := range colors {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		if m.cfg.GroupDefault[g] == ctx {
+			return colors[g]
+		}
+	}
+	for _, g := range names {
+		if slices.Contains(m.cfg.Groups[g], ctx) {
+			return colors[g]
+		}
+	}
+	return ""
+}
+
+// groupMarker is the thin bar in front of a row in its group's color, or the
+// blank it replaces
+func (m *model) groupMarker(ctx string) string {
+	if c := m.groupColor(ctx); c != "" {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c)).Render("▎")
+	}
+	return " "
+}
+
+// groupStyle tints text in group's color, or returns fallback without one
+func (m *model) groupStyle(group string, fallback lipgloss.Style) lipgloss.Style {
+	if c := m.cfg.GroupColors[group]; c != "" {
+		return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(c))
+	}
+	return fallback
+}
+
+// handleGroupColor: ksw group color <name> [<color>|off]
+func handleGroupColor(cfg config) {
+	if len(os.Args) < 4 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw group color <name> [<color>|off]")
+		os.Exit(1)
+	}
+	groupName := os.Args[3]
+	if _, ok := cfg.Groups[groupName]; !ok {
+		fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
+		os.Exit(exitNotFound)
+	}
+	if len(os.Args) < 5 {
+		if c := cfg.GroupColors[groupName]; c != "" {
+			fmt.Printf("%s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color(c)).Render("▎"), c)
+		} else {
+			fmt.Println(dimStyle.Render("No color for group " + groupName))
+		}
+		return
+	}
+
+	arg := os.Args[4]
+	if arg == "off" || arg == "none" {
+		delete(cfg.GroupColors, groupName)
+	} else {
+		c, ok := parseGroupColor(arg)
+		if !ok {
+			names := make([]string, 0, len(groupColorNames))
+			for n := range groupColorNames {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			fmt.Fprintf(os.Stderr, "%s Unknown color '%s'. Use #rrggbb or one of: %s\n", warnStyle.Render("✗"), arg, strings.Join(names, ", "))
+			os.Exit(1)
+		}
+		if cfg.GroupColors == nil {
+			cfg.GroupColors = make(map[string]string)
+		}
+		cfg.GroupColors[groupName] = c
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if c := cfg.GroupColors[groupName]; c != "" {
+		fmt.Printf("%s Group %s is now %s\n", successStyle.Render("✔"),
+			lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(c)).Render(groupName), c)
+	} else {
+		fmt.Printf("%s Group %s has no color\n", successStyle.Render("✔"), aliasStyle.Render(groupName))
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Help overlay (?) ───────────────────────────────────
+
+// tuiKeys is every TUI binding, in the order the overlay lists them
+var tuiKeys = []struct{ key, action string }{
+	{"type", "Fuzzy filter in real time"},
+	{"file:<name>", "Only contexts from a matching kubeconfig file"},
+	{"↑ / ↓", "Move up / down"},
+	{"Home / End", "Go to top / bottom"},
+	{"PgUp / PgDn", "Jump 10 items"},
+	{"Backspace", "Delete filter character"},
+	{"Enter", "Switch to highlighted context (twice if protected)"},
+	{"Ctrl+P", "Pin / unpin highlighted context"},
+	{"Ctrl+T", "Jump to first pinned context"},
+	{"Ctrl+↑ / Ctrl+↓", "Move the highlighted pin up / down"},
+	{"Ctrl+F", "Toggle pinned-only filter"},
+	{"Ctrl+B", "Toggle the groups sidebar"},
+	{"Ctrl+S", "Cycle the sort order"},
+	{"← / →", "Move between the sidebar and the list"},
+	{"Ctrl+H", "Toggle short names"},
+	{"Ctrl+E", "Toggle the Recent section"},
+	{"Ctrl+R", "Search the history only, newest first; again for the next match"},
+	{"Ctrl+N", "Toggle default namespace per row"},
+	{"Ctrl+L", "Toggle API server latency, and the server in the preview"},
+	{"?<sentence> Enter", "Ask the AI to filter the list"},
+	{"?", "This help (empty filter only)"},
+	{"Esc", "Leave history search / Clear filter / Quit"},
+	{"Ctrl+C", "Quit"},
+}
+
+// updateHelp handles a key while the overlay is open. Typing closes it and
+// starts a "?" AI query with that text, so "?" still works as its prefix.
+func (m model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.showHelp = false
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyRunes:
+		if string(msg.Runes) == "?" {
+			return m, nil
+		}
+		m.search = "?"
+		return m.Update(msg)
+	}
+	return m, nil
+}
+
+// helpView renders the overlay: key bindings, then what is on right now
+func (m model) helpView() string {
+	var b strings.Builder
+	b.WriteString("  " + currentLabelStyle.Render("  keys ") + "\n\n")
+	width := 0
+	for _, k := range tuiKeys {
+		width = max(width, lipgloss.Width(k.key))
+	}
+	for _, k := range tuiKeys {
+		pad := strings.Repeat(" ", width-lipgloss.Width(k.key))
+		b.WriteString("    " + aliasStyle.Render(k.key) + pad + "  " + k.action + "\n")
+	}
+
+	b.WriteString("\n  " + currentLabelStyle.Render("  now ") + "\n\n")
+	row := func(label, value string) {
+		b.WriteString(fmt.Sprintf("    %-14s %s\n", label, value))
+	}
+	switch {
+	case m.historySearch:
+		row("showing", "history, newest first")
+	case m.activeGroup != "":
+		row("showing", "group "+m.activeGroup)
+	case m.activeAlias != "":
+		row("showing", "targets of @"+m.activeAlias)
+	case m.recentOnly:
+		row("showing", "recent contexts")
+	case m.showArchived:
+		row("showing", "archived contexts")
+	default:
+		row("showing", "all contexts")
+	}
+	if m.cfg.profile != "" {
+		row("profile", m.cfg.profile)
+	}
+	row("sort", m.sortMode())
+	row("pinned only", onOff(m.showPinnedOnly))
+	row("short names", onOff(m.shortNames))
+	row("recent", onOff(m.showRecent))
+	row("namespaces", onOff(m.showNamespaces))
+	row("latency", onOff(m.showLatency))
+	truncate := m.cfg.Truncate
+	if truncate == "" {
+		truncate = "middle"
+	}
+	row("long names", "cut at the "+truncate)
+	row("contexts", fmt.Sprintf("%d shown of %d", len(m.filtered)-m.recentCount, len(m.contexts)))
+
+	// Keep the close hint on screen when the terminal is shorter than the overlay
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if room := m.terminalHeight - 3; room > 0 && len(lines) > room {
+		lines = append(lines[:room], "    "+dimStyle.Render("…"))
+	}
+	return strings.Join(lines, "\n") + "\n\n  " + helpStyle.Render("  esc or ? to close · type to ask the AI") + "\n"
+}
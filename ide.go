@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── ksw ide-server ─────────────────────────────────────
+
+// ksw ide-server speaks JSON-RPC 2.0 over stdin/stdout, one message per line,
+// so editor extensions get aliases, groups and pins instead of parsing the
+// raw kubeconfig. Config and kubeconfig are re-read on every request, so
+// changes made with ksw in a terminal show up right away.
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError codes are JSON-RPC's own for protocol errors, and ksw's exit codes
+// (exitNotFound, exitAmbiguous...) for failed calls
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// ideContext is one context as editors see it
+type ideContext struct {
+	Name      string   `json:"name"`
+	Short     string   `json:"short"`
+	Current   bool     `json:"current"`
+	Aliases   []string `json:"aliases"`
+	Groups    []string `json:"groups"`
+	Pinned    bool     `json:"pinned"`
+	Archived  bool     `json:"archived"`
+	Protected bool     `json:"protected"`
+	Expired   bool     `json:"expired"`
+	Env       string   `json:"env,omitempty"`
+}
+
+// ideState is what one request needs: the config and kubeconfig as they are now
+type ideState struct {
+	cfg      config
+	current  string
+	contexts []string
+	aliases  map[string][]string // context → aliases resolving to it
+}
+
+func loadIDEState() (*ideState, error) {
+	current, contexts, err := getKubeconfigState()
+	if err != nil {
+		return nil, err
+	}
+	s := &ideState{cfg: loadConfig(), current: current, contexts: contexts, aliases: make(map[string][]string)}
+	for a, target := range s.cfg.Aliases {
+		if ctx, err := resolveContext(target, contexts); err == nil {
+			s.aliases[ctx] = append(s.aliases[ctx], a)
+		}
+	}
+	return s, nil
+}
+
+func (s *ideState) context(ctx string) ideContext {
+	c := ideContext{
+		Name:      ctx,
+		Short:     shortName(ctx),
+		Current:   ctx == s.current,
+		Aliases:   slices.Clone(s.aliases[ctx]),
+		Groups:    []string{},
+		Pinned:    slices.Contains(s.cfg.Pins, ctx),
+		Archived:  slices.Contains(s.cfg.Archived, ctx),
+		Protected: isProtected(s.cfg, ctx),
+		Expired:   isExpired(s.cfg, ctx),
+		Env:       contextEnv(ctx),
+	}
+	if c.Aliases == nil {
+		c.Aliases = []string{}
+	}
+	for g, members := range s.cfg.Groups {
+		if slices.Contains(members, ctx) {
+			c.Groups = append(c.Groups, g)
+		}
+	}
+	sort.Strings(c.Aliases)
+	sort.Strings(c.Groups)
+	return c
+}
+
+// resolve accepts what ksw <name> does: @alias, full or short name, glob
+func (s *ideState) resolve(name string) (string, error) {
+	if alias, ok := strings.CutPrefix(name, "@"); ok {
+		target, ok := s.cfg.Aliases[alias]
+		if !ok {
+			return "", withExitCode(exitNotFound, fmt.Errorf("alias '@%s' not found", alias))
+		}
+		name = target
+	}
+	matches, err := resolveContexts(name, s.contexts)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) > 1 {
+		return "", &ambiguousError{name: name, matches: matches}
+	}
+	return matches[0], nil
+}
+
+// ambiguousError carries the candidates, so editors can offer them
+type ambiguousError struct {
+	name    string
+	matches []string
+}
+
+func (e *ambiguousError) Error() string { return fmt.Sprintf("ambiguous '%s'", e.name) }
+
+// handleIDEServer serves requests until stdin closes
+func handleIDEServer(cfg config) {
+	// Anything else printed while serving (warnings from switch hooks, say)
+	// must not corrupt the protocol stream
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = out }()
+	serveIDE(os.Stdin, out)
+}
+
+func serveIDE(in io.Reader, out io.Writer) {
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		result, rerr := callIDE(req.Method, req.Params)
+		if req.ID == nil {
+			continue
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		_ = enc.Encode(resp)
+	}
+}
+
+// callIDE runs one method: list, current, resolve or switch
+func callIDE(method string, raw json.RawMessage) (any, *rpcError) {
+	var params struct {
+		Name     string `json:"name"`     // resolve, switch
+		Group    string `json:"group"`    // list: only this group's contexts
+		Pinned   bool   `json:"pinned"`   // list: only pinned contexts
+		Archived bool   `json:"archived"` // list: include archived contexts
+	}
+	if len(raw) > 0 && json.Unmarshal(raw, &params) != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "params must be an object"}
+	}
+	switch method {
+	case "list", "current", "resolve", "switch":
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method '" + method + "'; use list, current, resolve or switch"}
+	}
+	if (method == "resolve" || method == "switch") && params.Name == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: method + " needs a name"}
+	}
+
+	s, err := loadIDEState()
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	switch method {
+	case "list":
+		if params.Group != "" {
+			if _, ok := s.cfg.Groups[params.Group]; !ok {
+				return nil, toRPCError(withExitCode(exitNotFound, fmt.Errorf("group '%s' not found", params.Group)))
+			}
+		}
+		return s.list(params.Group, params.Pinned, params.Archived), nil
+	case "current":
+		if s.current == "" {
+			return nil, nil
+		}
+		return s.context(s.current), nil
+	}
+
+	ctx, err := s.resolve(params.Name)
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	if method == "switch" && ctx != s.current {
+		if err := checkFreeze(s.cfg, s.current, ctx); err != nil {
+			return nil, toRPCError(err)
+		}
+		if err := switchContext(ctx); err != nil {
+			return nil, toRPCError(withExitCode(exitKubeconfig, fmt.Errorf("failed to switch to '%s': %w", ctx, err)))
+		}
+		recordHistory(&s.cfg, s.current, ctx)
+		_ = saveConfig(s.cfg)
+		switchHooks(s.cfg, s.current, ctx)
+		s.current = ctx
+	}
+	return s.context(ctx), nil
+}
+
+// list orders contexts like the TUI does by default: pins in pin order, then
+// the rest in kubeconfig order
+func (s *ideState) list(group string, pinnedOnly, archived bool) []ideContext {
+	order := make([]string, 0, len(s.contexts))
+	for _, p := range s.cfg.Pins {
+		if slices.Contains(s.contexts, p) {
+			order = append(order, p)
+		}
+	}
+	for _, ctx := range s.contexts {
+		if !slices.Contains(order, ctx) {
+			order = append(order, ctx)
+		}
+	}
+	items := []ideContext{}
+	for _, ctx := range order {
+		c := s.context(ctx)
+		if (group != "" && !slices.Contains(c.Groups, group)) || (pinnedOnly && !c.Pinned) || (c.Archived && !archived) {
+			continue
+		}
+		items = append(items, c)
+	}
+	return items
+}
+
+func toRPCError(err error) *rpcError {
+	var amb *ambiguousError
+	if errors.As(err, &amb) {
+		return &rpcError{Code: exitAmbiguous, Message: amb.Error(), Data: map[string][]string{"matches": amb.matches}}
+	}
+	return &rpcError{Code: exitCode(err), Message: err.Error()}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── ksw import kubectx|kubie ───────────────────────────
+
+// Neither kubectx nor kubie has aliases or groups of its own; what people
+// build up with them is a previous context, per-context namespaces and
+// shell aliases like alias pdn='kubectx payments-prod'. ksw import carries
+// those over.
+
+// importPlan is what ksw import found in another tool's state
+type importPlan struct {
+	previous   string            // kubectx's previous context
+	aliases    map[string]string // shell alias → context it switches to
+	sources    map[string]string // shell alias → file it's defined in
+	namespaces map[string]string // context → namespace kubie last used in it
+	notes      []string          // things ksw can't take over, for the user to act on
+}
+
+// shellAliasFiles are the shell startup files aliases are looked for in
+func shellAliasFiles(home string) []string {
+	return []string{
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_aliases"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".config", "fish", "config.fish"),
+	}
+}
+
+// shellAliasRe matches alias pdn='kubectx payments-prod' (bash, zsh) and
+// alias pdn 'kubectx payments-prod' (fish); the command is a placeholder
+const shellAliasRe = `^\s*alias\s+([A-Za-z0-9_.-]+)(?:=|\s+)['"]?\s*%s\s+([^\s'";&|-][^\s'";&|]*)\s*['"]?\s*$`
+
+// parseShellAliases finds aliases that only switch to a context with command
+func parseShellAliases(data []byte, command string) map[string]string {
+	re := regexp.MustCompile(fmt.Sprintf(shellAliasRe, command))
+	aliases := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if m := re.FindStringSubmatch(scanner.Text()); m != nil {
+			aliases[m[1]] = m[2]
+		}
+	}
+	return aliases
+}
+
+func (p *importPlan) addShellAliases(home, command string) {
+	for _, file := range shellAliasFiles(home) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for name, ctx := range parseShellAliases(data, command) {
+			p.aliases[name] = ctx
+			p.sources[name] = file
+		}
+	}
+}
+
+func newImportPlan() *importPlan {
+	return &importPlan{aliases: make(map[string]string), sources: make(map[string]string), namespaces: make(map[string]string)}
+}
+
+// kubectxStatePath is where kubectx keeps its previous context, for kubectx -
+func kubectxStatePath(home string) string {
+	return filepath.Join(home, ".kube", "kubectx")
+}
+
+// kubectxPlan reads kubectx's previous context (~/.kube/kubectx) and shell
+// aliases around kubectx or its kctx/kx shorthands
+func kubectxPlan(home string) *importPlan {
+	p := newImportPlan()
+	if data, err := os.ReadFile(kubectxStatePath(home)); err == nil {
+		p.previous = strings.TrimSpace(string(data))
+	}
+	p.addShellAliases(home, `(?:kubectx|kctx|kx)`)
+	return p
+}
+
+// kubiePlan reads the namespace kubie last used in each context
+// (~/.local/state/kubie/state.json), shell aliases around kubie ctx, and the
+// kubeconfig files kubie.yaml points it at
+func kubiePlan(home string) *importPlan {
+	p := newImportPlan()
+	if data, err := os.ReadFile(filepath.Join(home, ".local", "state", "kubie", "state.json")); err == nil {
+		var state struct {
+			NamespaceHistory map[string]*string `json:"namespace_history"`
+		}
+		if json.Unmarshal(data, &state) == nil {
+			for ctx, ns := range state.NamespaceHistory {
+				if ns != nil && *ns != "" {
+					p.namespaces[ctx] = *ns
+				}
+			}
+		}
+	}
+	p.addShellAliases(home, `kubie\s+ctx`)
+	if data, err := os.ReadFile(filepath.Join(home, ".kube", "kubie.yaml")); err == nil {
+		if files := kubieConfigFiles(data, home); len(files) > 0 {
+			p.notes = append(p.notes, "kubie also read these kubeconfigs; ksw reads $KUBECONFIG, so add them there:\n    export KUBECONFIG="+strings.Join(files, string(filepath.ListSeparator)))
+		}
+	}
+	return p
+}
+
+// kubieConfigFiles expands configs.include in kubie.yaml to the kubeconfig
+// files it matches, minus configs.exclude and the default ~/.kube/config.
+// Only the block-list form kubie's docs use is read; there is no YAML
+// dependency.
+func kubieConfigFiles(data []byte, home string) []string {
+	var files []string
+	excluded := map[string]bool{filepath.Join(home, ".kube", "config"): true}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			section = strings.TrimSuffix(trimmed, ":")
+		case strings.HasPrefix(section, "configs") && strings.HasSuffix(trimmed, ":") && !strings.HasPrefix(trimmed, "- "):
+			section = "configs." + strings.TrimSuffix(trimmed, ":")
+		case (section == "configs.include" || section == "configs.exclude") && strings.HasPrefix(trimmed, "- "):
+			pattern := strings.Trim(strings.TrimSpace(trimmed[2:]), `"'`)
+			if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+				pattern = filepath.Join(home, rest)
+			}
+			matches, _ := filepath.Glob(pattern)
+			for _, m := range matches {
+				if section == "configs.exclude" {
+					excluded[m] = true
+				} else if !slices.Contains(files, m) {
+					files = append(files, m)
+				}
+			}
+		case section == "configs.include" || section == "configs.exclude":
+			section = "configs"
+		}
+	}
+	return slices.DeleteFunc(files, func(f string) bool { return excluded[f] })
+}
+
+// handleImport: ksw import kubectx|kubie [--dry-run]
+func handleImport(cfg config) {
+	if len(os.Args) < 3 || (os.Args[2] != "kubectx" && os.Args[2] != "kubie") {
+		fmt.Fprintln(os.Stderr, "Usage: ksw import kubectx|kubie [--dry-run]")
+		os.Exit(1)
+	}
+	tool := os.Args[2]
+	dryRun := slices.Contains(os.Args[3:], "--dry-run")
+	home, _ := os.UserHomeDir()
+	plan := kubectxPlan(home)
+	if tool == "kubie" {
+		plan = kubiePlan(home)
+	}
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	if len(contexts) == 0 {
+		noContexts()
+	}
+
+	var done, skipped []string
+	if plan.previous != "" {
+		if ctx, err := resolveContext(plan.previous, contexts); err == nil {
+			cfg.Previous = ctx
+			if !slices.Contains(cfg.History, ctx) {
+				cfg.History = append([]string{ctx}, cfg.History...)
+				if len(cfg.History) > maxHistory {
+					cfg.History = cfg.History[:maxHistory]
+				}
+			}
+			done = append(done, "previous context "+shortName(ctx)+dimStyle.Render(" (ksw - goes there)"))
+		} else {
+			skipped = append(skipped, "previous context "+plan.previous+": no longer in the kubeconfig")
+		}
+	}
+
+	names := make([]string, 0, len(plan.aliases))
+	for name := range plan.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := plan.aliases[name]
+		ctx, err := resolveContext(target, contexts)
+		switch {
+		case err != nil:
+			skipped = append(skipped, "@"+name+" → "+target+": no such context")
+		case cfg.Aliases[name] == ctx:
+			// Already there, from an earlier import or by hand
+		case cfg.Aliases[name] != "" || len(cfg.MultiAliases[name]) > 0:
+			skipped = append(skipped, "@"+name+": already an alias for something else")
+		default:
+			cfg.Aliases[name] = ctx
+			done = append(done, fmt.Sprintf("%s → %s %s", aliasStyle.Render("@"+name), shortName(ctx),
+				dimStyle.Render("("+filepath.Base(plan.sources[name])+")")))
+		}
+	}
+
+	// A namespace kubie remembered becomes the context's default, unless the
+	// kubeconfig already sets one
+	current := getContextNamespaces()
+	ctxs := make([]string, 0, len(plan.namespaces))
+	for ctx := range plan.namespaces {
+		ctxs = append(ctxs, ctx)
+	}
+	sort.Strings(ctxs)
+	for _, ctx := range ctxs {
+		ns := plan.namespaces[ctx]
+		switch {
+		case !slices.Contains(contexts, ctx):
+			skipped = append(skipped, "namespace "+ns+" for "+ctx+": no such context")
+		case current[ctx] != "":
+			// The kubeconfig's own namespace wins
+		case dryRun:
+			done = append(done, "namespace "+ns+" for "+shortName(ctx))
+		default:
+			if out, err := kubeconfigEdit(ctx, "set-context", ctx, "--namespace", ns); err != nil {
+				skipped = append(skipped, "namespace "+ns+" for "+shortName(ctx)+": "+strings.TrimSpace(string(out)))
+				continue
+			}
+			done = append(done, "namespace "+ns+" for "+shortName(ctx))
+		}
+	}
+
+	if len(done) == 0 && len(skipped) == 0 && len(plan.notes) == 0 {
+		fmt.Println(dimStyle.Render("Nothing to import from " + tool + "."))
+		return
+	}
+	if len(done) > 0 {
+		verb := "Imported"
+		if dryRun {
+			verb = "Would import"
+		}
+		fmt.Printf("%s %s from %s:\n", successStyle.Render("✔"), verb, tool)
+		for _, d := range done {
+			fmt.Printf("  %s %s\n", dimStyle.Render("·"), d)
+		}
+	}
+	for _, s := range skipped {
+		fmt.Printf("  %s %s\n", warnStyle.Render("!"), s)
+	}
+	for _, n := range plan.notes {
+		fmt.Printf("  %s %s\n", dimStyle.Render("·"), n)
+	}
+	if dryRun || len(done) == 0 {
+		return
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ── ksw export kubectx ─────────────────────────────────
+
+// writeKubectxPrevious records prev as kubectx's previous context, so
+// kubectx - on a shared machine or in a script goes where ksw - does
+func writeKubectxPrevious(prev string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	path := kubectxStatePath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(prev+"\n"), 0644)
+}
+
+// handleExport: ksw export kubectx [off]. On, every switch also updates
+// ~/.kube/kubectx (see switchHooks); the current state is written right away.
+func handleExport(cfg config) {
+	if len(os.Args) < 3 || os.Args[2] != "kubectx" || (len(os.Args) > 3 && os.Args[3] != "off") {
+		fmt.Fprintln(os.Stderr, "Usage: ksw export kubectx [off]")
+		os.Exit(1)
+	}
+	home, _ := os.UserHomeDir()
+	off := len(os.Args) > 3
+	cfg.KubectxSync = !off
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+	if off {
+		fmt.Printf("%s No longer updating %s\n", successStyle.Render("✔"), kubectxStatePath(home))
+		return
+	}
+	if cfg.Previous != "" {
+		if err := writeKubectxPrevious(cfg.Previous); err != nil {
+			fatal(err)
+		}
+	}
+	fmt.Printf("%s Keeping %s in sync: kubectx - now goes where ksw - does\n", successStyle.Render("✔"), kubectxStatePath(home))
+}
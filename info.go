@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── ksw info ───────────────────────────────────────────
+
+// contextInfo is everything ksw knows about one context (ksw info --json)
+type contextInfo struct {
+	Name       string      `json:"name"`
+	Short      string      `json:"short"`
+	Current    bool        `json:"current"`
+	Aliases    []string    `json:"aliases"`
+	Groups     []string    `json:"groups"`
+	Pinned     bool        `json:"pinned"`
+	Archived   bool        `json:"archived"`
+	Protected  bool        `json:"protected"`
+	Home       bool        `json:"home"`
+	Env        string      `json:"env,omitempty"`
+	Expires    string      `json:"expires,omitempty"`
+	Cluster    string      `json:"cluster,omitempty"`
+	User       string      `json:"user,omitempty"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Server     string      `json:"server,omitempty"`
+	File       string      `json:"file,omitempty"`
+	Provenance *provenance `json:"provenance,omitempty"`
+	LastUsed   int64       `json:"last_used,omitempty"`
+	Switches   int         `json:"switches"`
+	Reachable  bool        `json:"reachable"`
+	LatencyMS  int64       `json:"latency_ms,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// contextEnv returns the environment marker at the end of a context's
+// short name (dev, qa, prod...), the same rule group auto uses
+func contextEnv(ctx string) string {
+	short := shortName(ctx)
+	if idx := strings.LastIndexAny(short, "-_"); idx >= 0 {
+		if env := strings.ToLower(short[idx+1:]); envSuffixes[env] {
+			return env
+		}
+	}
+	return ""
+}
+
+// fillKubeconfigInfo reads the context's cluster, user, namespace and server
+func fillKubeconfigInfo(info *contextInfo) {
+	entries, err := kube.Entries()
+	if err != nil {
+		return
+	}
+	c := entries.Contexts[info.Name]
+	info.Cluster, info.User, info.Namespace = c.Cluster, c.User, c.Namespace
+	info.Server = entries.Clusters[c.Cluster]
+}
+
+// buildContextInfo gathers config and kubeconfig details for ctx; the health
+// check is a TCP connect to the API server, so it needs no credentials
+func buildContextInfo(cfg config, ctx, current string, contexts []string) contextInfo {
+	info := contextInfo{
+		Name:      ctx,
+		Short:     shortName(ctx),
+		Current:   ctx == current,
+		Aliases:   []string{},
+		Groups:    []string{},
+		Pinned:    slices.Contains(cfg.Pins, ctx),
+		Archived:  slices.Contains(cfg.Archived, ctx),
+		Protected: isProtected(cfg, ctx),
+		Home:      cfg.Home == ctx,
+		Env:       contextEnv(ctx),
+		Expires:   cfg.Expiry[ctx],
+		File:      kubeconfigFileFor(ctx),
+	}
+	for a, target := range cfg.Aliases {
+		if t, err := resolveContext(target, contexts); err == nil && t == ctx {
+			info.Aliases = append(info.Aliases, a)
+		}
+	}
+	for g, members := range cfg.Groups {
+		if slices.Contains(members, ctx) {
+			info.Groups = append(info.Groups, g)
+		}
+	}
+	sort.Strings(info.Aliases)
+	sort.Strings(info.Groups)
+	if p, ok := cfg.Provenance[ctx]; ok {
+		info.Provenance = &p
+	}
+	for _, e := range cfg.HistoryLog {
+		if e.Context == ctx {
+			info.Switches++
+			info.LastUsed = e.Time
+		}
+	}
+
+	fillKubeconfigInfo(&info)
+	if info.Server != "" {
+		r := measureLatency(info.Server)
+		info.Reachable = r.Err == nil
+		if r.Err != nil {
+			info.Error = r.Err.Error()
+		} else {
+			info.LatencyMS = r.RTT.Milliseconds()
+		}
+	}
+	return info
+}
+
+// handleInfo prints everything ksw knows about one context:
+// ksw info [ctx] [--json] (default: KSW_CONTEXT, then current)
+func handleInfo(cfg config) {
+	asJSON := false
+	name := ""
+	for _, a := range os.Args[2:] {
+		if a == "--json" {
+			asJSON = true
+		} else if name == "" {
+			name = a
+		}
+	}
+
+	current, contexts, err := getKubeconfigState()
+	if err != nil {
+		fatal(err)
+	}
+	// KSW_CONTEXT overrides the kubeconfig's current context in this terminal
+	if env := os.Getenv("KSW_CONTEXT"); env != "" {
+		current = env
+	}
+	ctx := current
+	if name != "" {
+		if ctx, err = resolveContext(name, contexts); err != nil {
+			fatal(err)
+		}
+	}
+	if ctx == "" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw info <ctx> [--json]")
+		os.Exit(1)
+	}
+
+	if recordFileOrigins(&cfg, getContextFiles()) {
+		_ = saveConfig(cfg)
+	}
+	info := buildContextInfo(cfg, ctx, current, contexts)
+	if asJSON {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Printf("  %s %s\n", currentLabelStyle.Render(fmt.Sprintf("%-10s", label)), value)
+		}
+	}
+	title := info.Name
+	if info.Current {
+		title += " " + activeTag
+	}
+	fmt.Println("  " + activeItemStyle.Render(title))
+	if info.Short != info.Name {
+		row("short", info.Short)
+	}
+	if len(info.Aliases) > 0 {
+		row("aliases", aliasStyle.Render("@"+strings.Join(info.Aliases, " @")))
+	}
+	row("groups", strings.Join(info.Groups, ", "))
+	row("env", info.Env)
+	if info.Expires != "" {
+		expires := info.Expires
+		if isExpired(cfg, ctx) {
+			expires = warnStyle.Render("expired " + expires)
+		}
+		row("expires", expires)
+	}
+
+	var flags []string
+	for flag, on := range map[string]bool{"pinned": info.Pinned, "archived": info.Archived, "protected": info.Protected, "home": info.Home} {
+		if on {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Strings(flags)
+	row("flags", strings.Join(flags, ", "))
+
+	row("cluster", info.Cluster)
+	row("user", info.User)
+	row("namespace", info.Namespace)
+	row("server", info.Server)
+	row("file", info.File)
+	if info.Provenance != nil {
+		row("source", info.Provenance.String())
+		row("added", time.Unix(info.Provenance.Added, 0).Format("2006-01-02 15:04"))
+	} else {
+		row("source", dimStyle.Render("unknown (added outside ksw)"))
+	}
+
+	used := dimStyle.Render("never")
+	if info.LastUsed > 0 {
+		used = time.Unix(info.LastUsed, 0).Format("2006-01-02 15:04")
+	}
+	row("last used", used)
+	row("switches", fmt.Sprintf("%d", info.Switches))
+	if info.Server != "" {
+		health := successStyle.Render("✔ reachable") + " " + dimStyle.Render(fmt.Sprintf("%dms", info.LatencyMS))
+		if !info.Reachable {
+			health = warnStyle.Render("✗ unreachable") + " " + dimStyle.Render(info.Error)
+		}
+		row("health", health)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ── Integrations config ────────────────────────────────
+
+type integrationsConfig struct {
+	Slack slackConfig `json:"slack,omitempty"`
+}
+
+type slackConfig struct {
+	Enabled      bool     `json:"enabled,omitempty"`
+	Token        string   `json:"token,omitempty"`         // user token (xoxp-) with users.profile:write
+	ProdPatterns []string `json:"prod_patterns,omitempty"` // glob patterns that mark a context as prod
+	Emoji        string   `json:"emoji,omitempty"`         // defaults to :fire:
+}
+
+// defaultProdPatterns are used when no prod_patterns are configured
+var defaultProdPatterns = []string{"*prod*", "*pdn*"}
+
+// ── Switch hooks ───────────────────────────────────────
+
+// runSwitchHooks is called after every successful context switch
+func runSwitchHooks(cfg config, from, to string) {
+	switchHooks(cfg, from, to)
+	if verifyFlag || rollbackOnFailFlag {
+		verifySwitch(cfg, from, to)
+	}
+}
+
+func switchHooks(cfg config, from, to string) {
+	showNote(cfg, to)
+	warnIfExpired(cfg, to)
+	warnAWSProfile(cfg, to)
+	warnCertExpiry(cfg, to)
+	if cfg.KubectxSync && from != "" {
+		_ = writeKubectxPrevious(from)
+	}
+	if len(cfg.Tunnels) > 0 {
+		ensureTunnel(cfg.Tunnels, to)
+	}
+	if cfg.Integrations.Slack.Enabled {
+		slackOnSwitch(cfg.Integrations.Slack, from, to)
+	}
+}
+
+// ── Slack ──────────────────────────────────────────────
+
+func (s slackConfig) isProd(ctx string) bool {
+	patterns := s.ProdPatterns
+	if len(patterns) == 0 {
+		patterns = defaultProdPatterns
+	}
+	for _, p := range patterns {
+		if globMatch(p, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// slackOnSwitch sets the status when entering a prod context and clears it
+// when leaving one. A failure is only noted: it mustn't fail the switch.
+func slackOnSwitch(s slackConfig, from, to string) {
+	if err := updateSlackStatus(s, from, to); err != nil {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", dimStyle.Render("·"), dimStyle.Render("slack: "+err.Error()))
+	}
+}
+
+// updateSlackStatus is slackOnSwitch returning the error
+func updateSlackStatus(s slackConfig, from, to string) error {
+	switch {
+	case s.isProd(to):
+		emoji := s.Emoji
+		if emoji == "" {
+			emoji = ":fire:"
+		}
+		return setSlackStatus(s.Token, "in prod: "+shortName(to), emoji)
+	case from != "" && s.isProd(from):
+		return setSlackStatus(s.Token, "", "")
+	}
+	return nil
+}
+
+func setSlackStatus(token, text, emoji string) error {
+	body := map[string]any{
+		"profile": map[string]any{
+			"status_text":       text,
+			"status_emoji":      emoji,
+			"status_expiration": 0,
+		},
+	}
+	data, _ := json.Marshal(body)
+
+	req, _ := http.NewRequest("POST", "https://slack.com/api/users.profile.set", bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return fmt.Errorf("unexpected response: %s", truncate(string(b), 200))
+	}
+	if !result.OK {
+		return fmt.Errorf("%s", result.Error)
+	}
+	return nil
+}
+
+// ── handleIntegrations ─────────────────────────────────
+
+func handleIntegrations(cfg config) {
+	if len(os.Args) < 3 || os.Args[2] != "slack" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw integrations slack [enable|disable|test] [--token <xoxp-...>] [--prod <pattern>]...")
+		os.Exit(1)
+	}
+	s := &cfg.Integrations.Slack
+
+	sub := ""
+	if len(os.Args) >= 4 {
+		sub = os.Args[3]
+	}
+
+	switch sub {
+	case "", "status":
+		state := warnStyle.Render("disabled")
+		if s.Enabled {
+			state = successStyle.Render("enabled")
+		}
+		patterns := s.ProdPatterns
+		if len(patterns) == 0 {
+			patterns = defaultProdPatterns
+		}
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render("slack   "), state)
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render("prod    "), strings.Join(patterns, ", "))
+		if s.Token == "" {
+			fmt.Printf("  %s %s\n", currentLabelStyle.Render("token   "), dimStyle.Render("not set"))
+		}
+
+	case "enable":
+		for i := 4; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--token":
+				if i+1 < len(os.Args) {
+					s.Token = os.Args[i+1]
+					i++
+				}
+			case "--prod":
+				if i+1 < len(os.Args) {
+					if !slices.Contains(s.ProdPatterns, os.Args[i+1]) {
+						s.ProdPatterns = append(s.ProdPatterns, os.Args[i+1])
+					}
+					i++
+				}
+			case "--emoji":
+				if i+1 < len(os.Args) {
+					s.Emoji = os.Args[i+1]
+					i++
+				}
+			}
+		}
+		if s.Token == "" {
+			fmt.Fprintf(os.Stderr, "%s A Slack user token is required: ksw integrations slack enable --token <xoxp-...>\n", warnStyle.Render("✗"))
+			os.Exit(1)
+		}
+		s.Enabled = true
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Slack status integration enabled\n", successStyle.Render("✔"))
+
+	case "disable":
+		s.Enabled = false
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Slack status integration disabled\n", successStyle.Render("✔"))
+
+	case "test":
+		if s.Token == "" {
+			fmt.Fprintf(os.Stderr, "%s Slack token not set. Run: ksw integrations slack enable --token <xoxp-...>\n", warnStyle.Render("✗"))
+			os.Exit(1)
+		}
+		current := getCurrentContext()
+		if !s.isProd(current) {
+			fmt.Printf("%s %s is not a prod context; nothing to set\n", dimStyle.Render("·"), current)
+			return
+		}
+		if err := updateSlackStatus(*s, "", current); err != nil {
+			fmt.Fprintf(os.Stderr, "%s Couldn't set the Slack status: %v\n", warnStyle.Render("✗"), err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Slack status set for %s\n", successStyle.Render("✔"), shortName(current))
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown slack subcommand '%s'.\nUsage: ksw integrations slack <status|enable|disable|test>\n", sub)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ── Kubeconfig backend ─────────────────────────────────
+
+// kubeBackend lists and switches contexts. The kubectl implementation is the
+// only one ksw ships; tests swap in an in-memory one so handlers and the TUI
+// run without a kubeconfig.
+type kubeBackend interface {
+	// Contexts returns every context name in kubeconfig order
+	Contexts() ([]string, error)
+	// State returns the current context and every context name in one read
+	State() (string, []string, error)
+	// Current returns the current context, or "" when none is set
+	Current() string
+	// Namespaces returns the default namespace configured for each context
+	Namespaces() map[string]string
+	// Files returns the kubeconfig file defining each context, or nil when
+	// $KUBECONFIG doesn't merge several
+	Files() map[string]string
+	// Entries returns each context's cluster, user and namespace, and each
+	// cluster's API server, from one read of the merged kubeconfig
+	Entries() (kubeconfigEntries, error)
+	// Use makes name the current context
+	Use(name string) error
+}
+
+// kubeconfigEntries is the part of the merged kubeconfig ksw reads
+type kubeconfigEntries struct {
+	Contexts map[string]contextEntry // by context name
+	Clusters map[string]string       // cluster name → API server URL
+	Users    []string
+}
+
+// contextEntry is what a kubeconfig context points at
+type contextEntry struct {
+	Cluster, User, Namespace string
+}
+
+// kube is the backend every kubeconfig helper goes through
+var kube kubeBackend = kubectlBackend{}
+
+// kubectlBackend reads and writes the kubeconfig through kubectl
+type kubectlBackend struct{}
+
+func (kubectlBackend) Contexts() ([]string, error) {
+	if kubeconfigMissing() {
+		return nil, nil
+	}
+	out, err := output("kubectl", "config", "get-contexts", "-o", "name")
+	if err != nil {
+		return nil, withExitCode(exitKubeconfig, fmt.Errorf("failed to get contexts: %w", err))
+	}
+	return nonEmptyLines(strings.Split(strings.TrimSpace(string(out)), "\n")), nil
+}
+
+// State reads everything in one kubectl call, so a name can be resolved
+// before switching instead of trial-switching and retrying
+func (kubectlBackend) State() (string, []string, error) {
+	if kubeconfigMissing() {
+		return "", nil, nil
+	}
+	out, err := output("kubectl", "config", "view", "-o",
+		`jsonpath={.current-context}{"\n"}{range .contexts[*]}{.name}{"\n"}{end}`)
+	if err != nil {
+		return "", nil, withExitCode(exitKubeconfig, fmt.Errorf("failed to get contexts: %w", err))
+	}
+	lines := strings.Split(string(out), "\n")
+	return strings.TrimSpace(lines[0]), nonEmptyLines(lines[1:]), nil
+}
+
+func (kubectlBackend) Current() string {
+	if kubeconfigMissing() {
+		return ""
+	}
+	out, err := output("kubectl", "config", "current-context")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (kubectlBackend) Namespaces() map[string]string {
+	out, err := output("kubectl", "config", "view", "-o",
+		`jsonpath={range .contexts[*]}{.name}{"\t"}{.context.namespace}{"\n"}{end}`)
+	ns := make(map[string]string)
+	if err != nil {
+		return ns
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		name, namespace, ok := strings.Cut(line, "\t")
+		if ok && namespace != "" {
+			ns[name] = namespace
+		}
+	}
+	return ns
+}
+
+// Files reads each kubeconfig on its own; like kubectl's merge, the first
+// file defining a context wins
+func (kubectlBackend) Files() map[string]string {
+	paths := kubeconfigPaths()
+	if len(paths) < 2 {
+		return nil
+	}
+	files := make(map[string]string)
+	for _, p := range paths {
+		out, err := output("kubectl", "config", "get-contexts", "-o", "name", "--kubeconfig", p)
+		if err != nil {
+			continue
+		}
+		for _, ctx := range nonEmptyLines(strings.Split(string(out), "\n")) {
+			if _, ok := files[ctx]; !ok {
+				files[ctx] = p
+			}
+		}
+	}
+	return files
+}
+
+func (kubectlBackend) Entries() (kubeconfigEntries, error) {
+	entries := kubeconfigEntries{Contexts: make(map[string]contextEntry), Clusters: make(map[string]string)}
+	if kubeconfigMissing() {
+		return entries, nil
+	}
+	out, err := output("kubectl", "config", "view", "-o", "json")
+	if err != nil {
+		return entries, withExitCode(exitKubeconfig, fmt.Errorf("failed to read kubeconfig: %w", err))
+	}
+	var kc struct {
+		Clusters []struct {
+			Name    string `json:"name"`
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+		Contexts []struct {
+			Name    string       `json:"name"`
+			Context contextEntry `json:"context"`
+		} `json:"contexts"`
+		Users []struct {
+			Name string `json:"name"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal(out, &kc); err != nil {
+		return entries, withExitCode(exitKubeconfig, fmt.Errorf("unexpected kubeconfig: %w", err))
+	}
+	for _, c := range kc.Clusters {
+		entries.Clusters[c.Name] = c.Cluster.Server
+	}
+	for _, c := range kc.Contexts {
+		entries.Contexts[c.Name] = c.Context
+	}
+	for _, u := range kc.Users {
+		entries.Users = append(entries.Users, u.Name)
+	}
+	return entries, nil
+}
+
+// Use only changes current-context, which kubectl writes to the file it
+// reads it from, so it never needs --kubeconfig
+func (kubectlBackend) Use(name string) error {
+	cmd, done := command("kubectl", "config", "use-context", name)
+	return done(cmd.Run())
+}
+
+// kubeconfigMissing reports that no kubeconfig file exists or all are empty.
+// The backend then has no contexts rather than failing, so commands that only
+// touch ~/.ksw.json (aliases, groups, history) work before the first cluster,
+// and without kubectl installed.
+func kubeconfigMissing() bool {
+	for _, p := range kubeconfigPaths() {
+		if fi, err := os.Stat(p); err == nil && fi.Size() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// noContextsMessage explains an empty kubeconfig and how to fill it
+func noContextsMessage() string {
+	paths := kubeconfigPaths()
+	why := "has no contexts"
+	if kubeconfigMissing() {
+		why = "doesn't exist yet"
+		if len(paths) > 1 {
+			why = "don't exist yet"
+		}
+	}
+	return fmt.Sprintf(`%s No contexts found: %s %s. Add some with:
+  ksw eks kubeconfig    Sync EKS clusters from your AWS profiles
+  ksw local import      Add kind, minikube and k3d clusters on this machine
+  ksw add <name>        Add one cluster from its API server URL and a token or exec plugin
+  or your cloud's CLI: gcloud container clusters get-credentials, az aks get-credentials
+`, warnStyle.Render("✗"), strings.Join(paths, ", "), why)
+}
+
+// noContexts prints noContextsMessage and exits
+func noContexts() {
+	fmt.Fprint(os.Stderr, noContextsMessage())
+	os.Exit(exitKubeconfig)
+}
+
+func nonEmptyLines(lines []string) []string {
+	var out []string
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
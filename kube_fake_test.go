@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// fakeKube is an in-memory kubeconfig
+type fakeKube struct {
+	current    string
+	contexts   []string
+	namespaces map[string]string
+	files      map[string]string // context → kubeconfig file, nil for a single file
+	servers    map[string]string // cluster → API server; each context has a cluster and user of its name
+	switches   []string          // every Use call, in order
+}
+
+func (f *fakeKube) Contexts() ([]string, error) {
+	return slices.Clone(f.contexts), nil
+}
+
+func (f *fakeKube) State() (string, []string, error) {
+	return f.current, slices.Clone(f.contexts), nil
+}
+
+func (f *fakeKube) Current() string {
+	return f.current
+}
+
+func (f *fakeKube) Namespaces() map[string]string {
+	ns := make(map[string]string)
+	for k, v := range f.namespaces {
+		ns[k] = v
+	}
+	return ns
+}
+
+func (f *fakeKube) Files() map[string]string {
+	if f.files == nil {
+		return nil
+	}
+	files := make(map[string]string)
+	for k, v := range f.files {
+		files[k] = v
+	}
+	return files
+}
+
+func (f *fakeKube) Entries() (kubeconfigEntries, error) {
+	entries := kubeconfigEntries{Contexts: make(map[string]contextEntry), Clusters: make(map[string]string)}
+	for name, server := range f.servers {
+		entries.Clusters[name] = server
+	}
+	for _, ctx := range f.contexts {
+		entries.Contexts[ctx] = contextEntry{Cluster: ctx, User: ctx, Namespace: f.namespaces[ctx]}
+		entries.Clusters[ctx] = f.servers[ctx]
+		entries.Users = append(entries.Users, ctx)
+	}
+	return entries, nil
+}
+
+func (f *fakeKube) Use(name string) error {
+	if !slices.Contains(f.contexts, name) {
+		return fmt.Errorf("no context exists with the name: %q", name)
+	}
+	f.current = name
+	f.switches = append(f.switches, name)
+	return nil
+}
+
+// testContexts is the kubeconfig most tests start from
+var testContexts = []string{
+	"arn:aws:eks:us-east-1:111111111111:cluster/payments-dev",
+	"arn:aws:eks:us-east-1:111111111111:cluster/payments-qa",
+	"arn:aws:eks:us-east-1:222222222222:cluster/payments-prod",
+	"arn:aws:eks:us-east-1:222222222222:cluster/search-prod",
+	"docker-desktop",
+}
+
+// newFakeKube installs a fake backend on testContexts, starting on current,
+// and points ~/.ksw.json at a fresh temporary home
+func newFakeKube(t *testing.T, current string) *fakeKube {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KSW_CONTEXT", "")
+	t.Setenv("KUBECONFIG", "")
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	f := &fakeKube{current: current, contexts: slices.Clone(testContexts)}
+	prev, prevMode, prevProfile := kube, matchMode, profileOverride
+	kube = f
+	t.Cleanup(func() { kube, matchMode, profileOverride = prev, prevMode, prevProfile })
+	return f
+}
+
+// runCommand runs handler with os.Args set to "ksw args..." and returns what
+// it printed to stdout
+func runCommand(t *testing.T, handler func(config), args ...string) string {
+	t.Helper()
+	prevArgs, prevStdout := os.Args, os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Args = append([]string{"ksw"}, args...)
+	os.Stdout = w
+	defer func() { os.Args, os.Stdout = prevArgs, prevStdout }()
+
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		done <- buf.Bytes()
+	}()
+	handler(loadConfig())
+	w.Close()
+	return string(<-done)
+}
+
+// writeConfig saves cfg as the test home's ~/.ksw.json
+func writeConfig(t *testing.T, cfg config) {
+	t.Helper()
+	if cfg.Aliases == nil {
+		cfg.Aliases = make(map[string]string)
+	}
+	if cfg.MultiAliases == nil {
+		cfg.MultiAliases = make(map[string][]string)
+	}
+	if cfg.Groups == nil {
+		cfg.Groups = make(map[string][]string)
+	}
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
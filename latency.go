@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── API server latency ─────────────────────────────────
+
+const latencyTimeout = 3 * time.Second
+
+// latencyResult is one measured round trip to a context's API server
+type latencyResult struct {
+	RTT time.Duration
+	Err error
+}
+
+// latencyMsg delivers a measurement to the TUI
+type latencyMsg struct {
+	ctx    string
+	result latencyResult
+}
+
+// getContextServers maps each context to its cluster's API server URL
+func getContextServers() map[string]string {
+	servers := make(map[string]string)
+	entries, err := kube.Entries()
+	if err != nil {
+		return servers
+	}
+	for name, c := range entries.Contexts {
+		if s := entries.Clusters[c.Cluster]; s != "" {
+			servers[name] = s
+		}
+	}
+	return servers
+}
+
+// serversMsg delivers every context's API server to the TUI, which loads
+// them after the first paint for the provider badges and Ctrl+L
+type serversMsg map[string]string
+
+func loadServersCmd() tea.Msg {
+	return serversMsg(getContextServers())
+}
+
+// measureLatency times a TCP connect to the API server. It needs no
+// credentials, so it stays fast even for exec-based auth like EKS.
+func measureLatency(server string) latencyResult {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return latencyResult{Err: fmt.Errorf("invalid server URL '%s'", server)}
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", host, latencyTimeout)
+	if err != nil {
+		return latencyResult{Err: err}
+	}
+	rtt := time.Since(start)
+	conn.Close()
+	return latencyResult{RTT: rtt}
+}
+
+func measureLatencyCmd(ctx, server string) tea.Cmd {
+	return func() tea.Msg {
+		return latencyMsg{ctx: ctx, result: measureLatency(server)}
+	}
+}
+
+// measureAll measures every context's API server concurrently, stepping pr (if any) per context
+func measureAll(contexts []string, pr *progress) map[string]latencyResult {
+	return measureServers(contexts, getContextServers(), pr, nil)
+}
+
+// measureServers is measureAll with the servers already read. When stop
+// reports true for a result it returns right away, with the results so far.
+func measureServers(contexts []string, servers map[string]string, pr *progress, stop func(ctx string, r latencyResult) bool) map[string]latencyResult {
+	type measured struct {
+		ctx    string
+		result latencyResult
+	}
+	results := make(map[string]latencyResult, len(contexts))
+	done := make(chan measured, len(contexts))
+	pending := 0
+	for _, ctx := range contexts {
+		server, ok := servers[ctx]
+		if !ok {
+			r := latencyResult{Err: fmt.Errorf("no server configured")}
+			results[ctx] = r
+			if stop != nil && stop(ctx, r) {
+				return results
+			}
+			continue
+		}
+		pending++
+		go func(ctx, server string) {
+			r := measureLatency(server)
+			pr.Step()
+			done <- measured{ctx, r}
+		}(ctx, server)
+	}
+	for range pending {
+		m := <-done
+		results[m.ctx] = m.result
+		if stop != nil && stop(m.ctx, m.result) {
+			break
+		}
+	}
+	return results
+}
+
+// formatLatency renders an RTT colored by how usable it is
+func formatLatency(r latencyResult) string {
+	if r.Err != nil {
+		return warnStyle.Render("unreachable")
+	}
+	ms := fmt.Sprintf("%dms", r.RTT.Milliseconds())
+	switch {
+	case r.RTT < 100*time.Millisecond:
+		return successStyle.Render(ms)
+	case r.RTT < 300*time.Millisecond:
+		return pinItemStyle.Render(ms)
+	default:
+		return warnStyle.Render(ms)
+	}
+}
+
+// ── Switch verification ────────────────────────────
+
+// Set by the global --verify and --rollback-on-fail flags
+var verifyFlag, rollbackOnFailFlag bool
+
+const verifyTimeout = "5s"
+
+// probeContext makes one authenticated request to the context's API server.
+// /api is used rather than /version because /version is readable
+// anonymously, so it would not catch expired credentials.
+func probeContext(ctx string) error {
+	return probe("--context", ctx)
+}
+
+// probe is probeContext with the kubectl flags that pick the context
+func probe(flags ...string) error {
+	out, err := combinedOutput("kubectl", append(flags, "--request-timeout", verifyTimeout, "get", "--raw", "/api")...)
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		// kubectl can print several lines; the last one is the actual error
+		if i := strings.LastIndex(msg, "\n"); i >= 0 {
+			msg = msg[i+1:]
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// verifySwitch checks the context just switched to and, with
+// --rollback-on-fail, switches back to from when it doesn't answer
+func verifySwitch(cfg config, from, to string) {
+	var err error
+	exitIfInterrupted(runWithProgress(context.Background(), "Verifying "+shortName(to), 1, func(_ context.Context, pr *progress) error {
+		err = probeContext(to)
+		pr.Step()
+		return nil
+	}))
+	if err == nil {
+		fmt.Printf("  %s %s\n", successStyle.Render("✔"), dimStyle.Render("API server answered"))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s Verification failed for %s: %v\n", warnStyle.Render("✗"), to, err)
+	if rollbackOnFailFlag && from != "" && from != to {
+		if rbErr := switchContext(from); rbErr != nil {
+			fmt.Fprintf(os.Stderr, "%s Rollback to '%s' failed: %v\n", warnStyle.Render("✗"), from, rbErr)
+			os.Exit(exitKubeconfig)
+		}
+		switchHooks(cfg, to, from)
+		fmt.Fprintf(os.Stderr, "%s Rolled back to %s\n", dimStyle.Render("↺"), from)
+	}
+	os.Exit(exitVerify)
+}
+
+// ── handleCheck ────────────────────────────────────────
+
+// checkResult is one context in ksw check -o json
+type checkResult struct {
+	Context     string `json:"context"`
+	Status      string `json:"status"` // "ok", "failed", or "skipped" after --fail-fast stopped
+	Server      string `json:"server,omitempty"`
+	LatencyMS   *int64 `json:"latency_ms,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CertExpires string `json:"cert_expires,omitempty"`
+}
+
+// handleCheck tests whether each context's API server is reachable:
+// ksw check [<pattern>...] [--latency] [-o table|wide|json] [--fail-fast].
+// With --latency it also prints the round-trip time, fastest first; wide
+// adds the latency, server and certificate expiry of every context.
+func handleCheck(cfg config) {
+	showLatency := false
+	var opts reportOpts
+	var patterns []string
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		n, err := opts.parse(args, i)
+		if err != nil {
+			fatal(err)
+		}
+		if n > 0 {
+			i += n - 1
+			continue
+		}
+		if args[i] == "--latency" {
+			showLatency = true
+			continue
+		}
+		patterns = append(patterns, args[i])
+	}
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	if len(patterns) > 0 {
+		var selected []string
+		for _, p := range patterns {
+			matches, err := resolveContexts(p, contexts)
+			if err != nil {
+				fatal(err)
+			}
+			selected = append(selected, matches...)
+		}
+		contexts = selected
+	}
+
+	certs := clientCertExpiries()
+	// An expired client certificate can't authenticate, however fast the server answers
+	fails := func(ctx string, r latencyResult) bool {
+		notAfter, ok := certs[ctx]
+		return r.Err != nil || ok && !notAfter.After(time.Now())
+	}
+	servers := getContextServers()
+	var results map[string]latencyResult
+	exitIfInterrupted(runWithProgress(context.Background(), "Checking API servers", len(contexts), func(_ context.Context, pr *progress) error {
+		var stop func(string, latencyResult) bool
+		if opts.failFast {
+			stop = fails
+		}
+		results = measureServers(contexts, servers, pr, stop)
+		return nil
+	}))
+
+	if showLatency || opts.format == "wide" {
+		// Fastest first, unreachable then unchecked last
+		rank := func(ctx string) int {
+			r, ok := results[ctx]
+			switch {
+			case !ok:
+				return 2
+			case r.Err != nil:
+				return 1
+			}
+			return 0
+		}
+		sort.SliceStable(contexts, func(i, j int) bool {
+			a, b := contexts[i], contexts[j]
+			if rank(a) != rank(b) {
+				return rank(a) < rank(b)
+			}
+			return results[a].RTT < results[b].RTT
+		})
+	}
+
+	report := make([]checkResult, 0, len(contexts))
+	failed := 0
+	for _, ctx := range contexts {
+		r, checked := results[ctx]
+		e := checkResult{Context: ctx, Status: "ok", Server: servers[ctx]}
+		switch {
+		case !checked:
+			e.Status = "skipped"
+		case fails(ctx, r):
+			e.Status = "failed"
+			failed++
+		}
+		if checked && r.Err == nil {
+			ms := r.RTT.Milliseconds()
+			e.LatencyMS = &ms
+		}
+		if r.Err != nil {
+			e.Error = r.Err.Error()
+		}
+		if notAfter, ok := certs[ctx]; ok {
+			e.CertExpires = notAfter.Format(time.RFC3339)
+		}
+		report = append(report, e)
+	}
+
+	if opts.format == "json" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printCheckReport(cfg, report, results, certs, showLatency, opts.format == "wide")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printCheckReport prints ksw check's table: a line per context, and with
+// wide its latency, server and certificate expiry in columns
+func printCheckReport(cfg config, report []checkResult, results map[string]latencyResult, certs map[string]time.Time, showLatency, wide bool) {
+	names := make([]string, len(report))
+	width, serverWidth := 0, 0
+	for i, e := range report {
+		names[i] = e.Context
+		if cfg.ShortNames {
+			names[i] = shortName(e.Context)
+		}
+		width = max(width, lipgloss.Width(names[i]))
+		serverWidth = max(serverWidth, len(e.Server))
+	}
+	skipped := 0
+	for i, e := range report {
+		if e.Status == "skipped" {
+			skipped++
+			continue
+		}
+		mark := successStyle.Render("✔")
+		if e.Status == "failed" {
+			mark = warnStyle.Render("✗")
+		}
+		line := fmt.Sprintf("  %s %s", mark, names[i])
+		if wide {
+			latency := formatLatency(results[e.Context])
+			latency += strings.Repeat(" ", max(0, len("unreachable")-lipgloss.Width(latency)))
+			line = fmt.Sprintf("  %s %-*s  %s  %-*s", mark, width, names[i], latency, serverWidth, e.Server)
+			if notAfter, ok := certs[e.Context]; ok {
+				line += "  " + dimStyle.Render("cert until "+notAfter.Format("2006-01-02"))
+			}
+		} else if showLatency {
+			line += "  " + formatLatency(results[e.Context])
+		}
+		if e.Error != "" {
+			line += "  " + dimStyle.Render(e.Error)
+		}
+		if w := certWarning(cfg, e.Context, certs); w != "" {
+			line += "  " + warnStyle.Render(w)
+		}
+		fmt.Println(line)
+	}
+	if skipped > 0 {
+		fmt.Printf("  %s %s\n", dimStyle.Render("·"), dimStyle.Render(fmt.Sprintf("%d not checked (--fail-fast stopped at the first failure)", skipped)))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ── Layout ─────────────────────────────────────────────
+
+// helpItem is one hint in the footer. When the terminal is too narrow for
+// every hint, all of them switch to their short label first, then the least
+// important ones are dropped.
+type helpItem struct {
+	long, short string
+	priority    int // 0 is always shown
+}
+
+var helpItems = []helpItem{
+	{"↑↓ navigate", "↑↓", 0},
+	{"enter select", "enter", 0},
+	{"ctrl+p pin/unpin", "^p pin", 1},
+	{"ctrl+t jump-pin", "^t pins", 3},
+	{"ctrl+f pinned", "^f pinned", 2},
+	{"ctrl+h short", "^h short", 2},
+	{"? help", "? help", 1},
+	{"esc", "esc", 1},
+	{"ctrl+c quit", "^c quit", 0},
+}
+
+// fitHelp returns the most complete help line that fits in width
+func fitHelp(width int) string {
+	line := ""
+	for maxPriority := 3; maxPriority >= 0; maxPriority-- {
+		for _, long := range []bool{true, false} {
+			var parts []string
+			for _, h := range helpItems {
+				if h.priority > maxPriority {
+					continue
+				}
+				if long {
+					parts = append(parts, h.long)
+				} else {
+					parts = append(parts, h.short)
+				}
+			}
+			line = "  " + strings.Join(parts, " · ")
+			if lipgloss.Width(line) <= width {
+				return line
+			}
+		}
+	}
+	return line
+}
+
+// truncateName shortens a context name to width cells. strategy is the
+// "truncate" setting: "start" and "end" put the ellipsis there, "middle" (the
+// default) keeps both ends, and for EKS ARNs keeps the account and cluster:
+// arn:aws:eks:…:111122223333:cluster/payments-pdn
+func truncateName(s string, width int, strategy string) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	switch strategy {
+	case "start":
+		return truncateStart(s, width)
+	case "end":
+		return truncateEnd(s, width)
+	}
+
+	if prefix, rest, ok := strings.Cut(s, "arn:aws:eks:"); ok && prefix == "" {
+		// rest is region:account:cluster/name
+		if parts := strings.SplitN(rest, ":", 3); len(parts) == 3 {
+			account, cluster := parts[1], parts[2]
+			for _, c := range []string{
+				"arn:aws:eks:…:" + account + ":" + cluster,
+				"arn:aws:eks:…:" + cluster,
+				"…:" + cluster,
+			} {
+				if lipgloss.Width(c) <= width {
+					return c
+				}
+			}
+			return truncateStart(cluster, width)
+		}
+	}
+
+	// The end of a name usually tells contexts apart, so it gets two thirds
+	r := []rune(s)
+	n := 0
+	for n < len(r) && lipgloss.Width(string(r[:n+1])) <= (width-1)/3 {
+		n++
+	}
+	head := string(r[:n])
+	return head + truncateStart(string(r[n:]), width-lipgloss.Width(head))
+}
+
+// truncateStart keeps the end of s, with a leading ellipsis
+func truncateStart(s string, width int) string {
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > width {
+		r = r[1:]
+	}
+	return "…" + string(r)
+}
+
+// truncateEnd keeps the start of s, with a trailing ellipsis
+func truncateEnd(s string, width int) string {
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// minNameWidth keeps names readable when a row's extras take most of the line
+const minNameWidth = 16
+
+// nameWidth is how many cells a row's name can use next to its extras
+func (m *model) nameWidth(extras string) int {
+	return max(minNameWidth, m.listWidth()-5-lipgloss.Width(extras)) // "  " indent + " ❯ " pointer
+}
+
+// header renders the current context, search bar and separator
+func (m *model) header() string {
+	var b strings.Builder
+
+	// ── Current context ──
+	currentAlias := m.aliasFor(m.current)
+	currentName := m.current
+	if m.shortNames {
+		currentName = shortName(m.current)
+	}
+	filterLabel := ""
+	if m.historySearch {
+		filterLabel = "  " + dimStyle.Render("[history]")
+	} else if m.activeGroup != "" {
+		filterLabel = "  " + m.groupStyle(m.activeGroup, pinItemStyle).Render("["+m.activeGroup+"]")
+	} else if m.activeAlias != "" {
+		filterLabel = "  " + aliasStyle.Render("[@"+m.activeAlias+"]")
+	} else if m.showPinnedOnly {
+		filterLabel = "  " + pinItemStyle.Render("[★ pinned]")
+	} else if m.recentOnly {
+		filterLabel = "  " + dimStyle.Render("[recent]")
+	} else if m.showArchived {
+		filterLabel = "  " + dimStyle.Render("[archived]")
+	}
+	if m.cfg.profile != "" {
+		filterLabel += "  " + dimStyle.Render("("+m.cfg.profile+")")
+	}
+	label := "  " + currentLabelStyle.Render("  current ")
+	prefix := ""
+	if m.shortNames {
+		prefix = dimStyle.Render("[short] ")
+	}
+	aliasLabel := ""
+	if currentAlias != "" {
+		aliasLabel = " " + aliasStyle.Render("@"+currentAlias)
+	}
+	room := max(minNameWidth, m.terminalWidth-lipgloss.Width(label+prefix+aliasLabel+filterLabel))
+	currentDisplay := prefix + currentValueStyle.Render(truncateName(currentName, room, m.cfg.Truncate)+aliasLabel)
+	b.WriteString(label + currentDisplay + filterLabel + "\n")
+	b.WriteString("\n")
+
+	// ── Search bar ──
+	if m.historySearch {
+		b.WriteString("  " + searchActiveStyle.Render("  (reverse-i-search) "+m.search+"█") + "\n")
+	} else if m.search != "" {
+		aiNote := ""
+		if strings.HasPrefix(m.search, "?") {
+			switch {
+			case m.aiPending:
+				aiNote = "  " + dimStyle.Render("asking AI…")
+			case m.aiErr != "":
+				aiNote = "  " + warnStyle.Render(truncate(m.aiErr, 60))
+			case m.aiQuery == m.search:
+				aiNote = "  " + dimStyle.Render(fmt.Sprintf("AI: %d match(es)", len(m.filtered)))
+			default:
+				aiNote = "  " + dimStyle.Render("enter to ask AI")
+			}
+		}
+		b.WriteString("  " + searchActiveStyle.Render("  ❯ "+m.search+"█") + aiNote + "\n")
+	} else {
+		b.WriteString("  " + searchPlaceholderStyle.Render("  ❯ type to search...") + "\n")
+	}
+
+	// ── Separator, with the sort mode at its end ──
+	sortLabel := " ↕ " + m.sortMode()
+	b.WriteString("  " + m.groupStyle(m.activeGroup, dimStyle).UnsetBold().Render("  "+strings.Repeat("─", max(1, min(41, m.terminalWidth-4)-lipgloss.Width(sortLabel)))+sortLabel))
+	return b.String()
+}
+
+// footer renders the status line, the counter and as much of the help line
+// as fits
+func (m *model) footer() string {
+	status := ""
+	if m.confirmProd != "" {
+		status = ansi.Truncate("    "+warnStyle.Render("! "+shortName(m.confirmProd)+" is protected · Enter again to switch, any other key cancels"), m.terminalWidth, "…")
+	} else if m.status != "" {
+		status = ansi.Truncate("    "+m.status, m.terminalWidth, "…")
+	}
+	counter := counterStyle.Render(fmt.Sprintf("  %d/%d", len(m.filtered)-m.recentCount, len(m.contexts)))
+	help := fitHelp(m.terminalWidth - 2 - lipgloss.Width(counter))
+	return status + "\n  " + counter + helpStyle.Render(help)
+}
+
+// maxVisible is how many list rows fit between the header and footer
+func (m *model) maxVisible() int {
+	used := lipgloss.Height(m.header()) + lipgloss.Height(m.footer())
+	used += 2 // ▲/▼ scroll indicators
+	used++    // View's trailing newline
+	if m.recentCount > 0 {
+		used += 2 // "Recent" and "All" labels
+	}
+	used++ // preview history line
+	if m.showLatency {
+		used++ // preview server line
+	}
+	return max(3, m.terminalHeight-used)
+}
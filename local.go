@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Local dev clusters (kind / minikube / k3d) ─────────
+
+// localCluster is a cluster managed by a local dev tool
+type localCluster struct {
+	Tool    string // kind | minikube | k3d
+	Name    string // cluster/profile name as the tool knows it
+	Context string // kubeconfig context the tool creates
+	Running bool
+}
+
+// detectLocalClusters asks every installed local tool for its clusters.
+// Tools that aren't installed are skipped silently.
+func detectLocalClusters() []localCluster {
+	var clusters []localCluster
+	if _, err := exec.LookPath("kind"); err == nil {
+		out, err := output("kind", "get", "clusters")
+		if err == nil {
+			for _, name := range strings.Fields(string(out)) {
+				running := false
+				state, err := output("docker", "inspect", "-f", "{{.State.Running}}", name+"-control-plane")
+				if err == nil {
+					running = strings.TrimSpace(string(state)) == "true"
+				}
+				clusters = append(clusters, localCluster{Tool: "kind", Name: name, Context: "kind-" + name, Running: running})
+			}
+		}
+	}
+	if _, err := exec.LookPath("minikube"); err == nil {
+		out, err := output("minikube", "profile", "list", "-o", "json")
+		if err == nil {
+			var result struct {
+				Valid []struct {
+					Name   string `json:"Name"`
+					Status string `json:"Status"`
+				} `json:"valid"`
+			}
+			if json.Unmarshal(out, &result) == nil {
+				for _, p := range result.Valid {
+					clusters = append(clusters, localCluster{Tool: "minikube", Name: p.Name, Context: p.Name, Running: p.Status == "Running"})
+				}
+			}
+		}
+	}
+	if _, err := exec.LookPath("k3d"); err == nil {
+		out, err := output("k3d", "cluster", "list", "-o", "json")
+		if err == nil {
+			var result []struct {
+				Name           string `json:"name"`
+				ServersRunning int    `json:"serversRunning"`
+			}
+			if json.Unmarshal(out, &result) == nil {
+				for _, c := range result {
+					clusters = append(clusters, localCluster{Tool: "k3d", Name: c.Name, Context: "k3d-" + c.Name, Running: c.ServersRunning > 0})
+				}
+			}
+		}
+	}
+	return clusters
+}
+
+// localClustersMsg delivers which local clusters are running to the TUI
+type localClustersMsg map[string]bool
+
+func loadLocalClustersCmd() tea.Msg {
+	running := make(map[string]bool)
+	for _, c := range detectLocalClusters() {
+		running[c.Context] = c.Running
+	}
+	return localClustersMsg(running)
+}
+
+// hasLocalContexts reports whether any context looks like it belongs to a local tool,
+// so the TUI only pays for detection when it can matter
+func hasLocalContexts(contexts []string) bool {
+	for _, ctx := range contexts {
+		if strings.HasPrefix(ctx, "kind-") || strings.HasPrefix(ctx, "k3d-") || strings.HasPrefix(ctx, "minikube") {
+			return true
+		}
+	}
+	return false
+}
+
+func findLocalCluster(clusters []localCluster, name string) (localCluster, bool) {
+	for _, c := range clusters {
+		if c.Context == name || c.Name == name {
+			return c, true
+		}
+	}
+	return localCluster{}, false
+}
+
+func (c localCluster) start() error {
+	var cmd *exec.Cmd
+	switch c.Tool {
+	case "kind":
+		cmd = exec.Command("docker", "start", c.Name+"-control-plane")
+	case "minikube":
+		cmd = exec.Command("minikube", "start", "-p", c.Name)
+	case "k3d":
+		cmd = exec.Command("k3d", "cluster", "start", c.Name)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to start %s cluster '%s': %s", c.Tool, c.Name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (c localCluster) stop() error {
+	var cmd *exec.Cmd
+	switch c.Tool {
+	case "kind":
+		cmd = exec.Command("docker", "stop", c.Name+"-control-plane")
+	case "minikube":
+		cmd = exec.Command("minikube", "stop", "-p", c.Name)
+	case "k3d":
+		cmd = exec.Command("k3d", "cluster", "stop", c.Name)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stop %s cluster '%s': %s", c.Tool, c.Name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// importContext writes the cluster's context into the default kubeconfig
+func (c localCluster) importContext() error {
+	var cmd *exec.Cmd
+	switch c.Tool {
+	case "kind":
+		cmd = exec.Command("kind", "export", "kubeconfig", "--name", c.Name)
+	case "minikube":
+		cmd = exec.Command("minikube", "update-context", "-p", c.Name)
+	case "k3d":
+		cmd = exec.Command("k3d", "kubeconfig", "merge", c.Name, "--kubeconfig-merge-default")
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to import context for %s cluster '%s': %s", c.Tool, c.Name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ensureLocalRunning starts a stopped local cluster before switching to it
+func ensureLocalRunning(ctx string) {
+	if !hasLocalContexts([]string{ctx}) {
+		return
+	}
+	c, ok := findLocalCluster(detectLocalClusters(), ctx)
+	if !ok || c.Running {
+		return
+	}
+	fmt.Printf("%s Starting %s cluster %s...\n", dimStyle.Render("·"), c.Tool, c.Name)
+	if err := c.start(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+	}
+}
+
+// ── handleLocal ────────────────────────────────────────
+
+func handleLocal() {
+	sub := "ls"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+
+	clusters := detectLocalClusters()
+	contexts, _ := getContexts()
+	known := make(map[string]bool, len(contexts))
+	for _, ctx := range contexts {
+		known[ctx] = true
+	}
+
+	switch sub {
+	case "ls", "list":
+		if len(clusters) == 0 {
+			fmt.Println(dimStyle.Render("No kind, minikube or k3d clusters found."))
+			return
+		}
+		missing := 0
+		for _, c := range clusters {
+			state := dimStyle.Render("stopped")
+			if c.Running {
+				state = successStyle.Render("running")
+			}
+			note := ""
+			if !known[c.Context] {
+				note = " " + warnStyle.Render("(no context)")
+				missing++
+			}
+			fmt.Printf("  %-9s %s %s%s\n", dimStyle.Render(c.Tool), normalItemStyle.Render(c.Context), state, note)
+		}
+		if missing > 0 {
+			fmt.Printf("\n  Import missing contexts with: %s\n", dimStyle.Render("ksw local import"))
+		}
+
+	case "import":
+		imported := 0
+		for _, c := range clusters {
+			if known[c.Context] {
+				continue
+			}
+			if err := c.importContext(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+				continue
+			}
+			imported++
+			fmt.Printf("%s Imported %s\n", successStyle.Render("✔"), c.Context)
+		}
+		if imported == 0 {
+			fmt.Println(dimStyle.Render("All local clusters already have a context."))
+			return
+		}
+		recordNewContexts(contexts, "local", func(ctx string) string {
+			if c, ok := findLocalCluster(clusters, ctx); ok {
+				return c.Tool + " cluster " + c.Name
+			}
+			return ""
+		})
+
+	case "start", "stop":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: ksw local %s <name>\n", sub)
+			os.Exit(1)
+		}
+		c, ok := findLocalCluster(clusters, os.Args[3])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s Local cluster '%s' not found. Use 'ksw local ls' to list.\n", warnStyle.Render("✗"), os.Args[3])
+			os.Exit(exitNotFound)
+		}
+		if sub == "start" {
+			if err := c.start(); err != nil {
+				fatal(err)
+			}
+			if !known[c.Context] {
+				if err := c.importContext(); err != nil {
+					fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+				}
+			}
+			fmt.Printf("%s Started %s\n", successStyle.Render("✔"), c.Context)
+			return
+		}
+		if err := c.stop(); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s Stopped %s\n", successStyle.Render("✔"), c.Context)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown local subcommand '%s'.\nUsage: ksw local <ls|start|stop|import>\n", sub)
+		os.Exit(1)
+	}
+}
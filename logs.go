@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// ── ksw logs ───────────────────────────────────────────
+
+// handleLogs tails logs on a context without switching to it:
+// ksw logs <context> <selector> [-n <namespace>] [-- <extra args>]. It runs
+// stern when it's installed, otherwise kubectl logs -f. The namespace is -n,
+// then the repo's .ksw.yaml when it names this context, then the context's own.
+func handleLogs(cfg config) {
+	var args, extra []string
+	namespace := ""
+	for i := 2; i < len(os.Args); i++ {
+		switch {
+		case os.Args[i] == "--":
+			extra = os.Args[i+1:]
+			i = len(os.Args)
+		case (os.Args[i] == "-n" || os.Args[i] == "--namespace") && i+1 < len(os.Args):
+			namespace = os.Args[i+1]
+			i++
+		default:
+			args = append(args, os.Args[i])
+		}
+	}
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw logs <context> <selector> [-n <namespace>] [-- <stern or kubectl logs args>]")
+		fmt.Fprintln(os.Stderr, "       <selector> is a label selector (app=api), a pod or type/name (deploy/api)")
+		os.Exit(1)
+	}
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	targets, err := resolveTargets(cfg, args[0], contexts)
+	if err != nil {
+		fatal(err)
+	}
+	if len(targets) > 1 {
+		fatal(withExitCode(exitAmbiguous, fmt.Errorf("'%s' is %d contexts; ksw logs tails one:\n  %s", args[0], len(targets), strings.Join(targets, "\n  "))))
+	}
+	ctx := targets[0]
+	if namespace == "" {
+		if pc, ok, _ := currentProject(); ok && pc.Namespace != "" {
+			if target, err := resolveContext(pc.Context, contexts); err == nil && target == ctx {
+				namespace = pc.Namespace
+			}
+		}
+	}
+
+	tool := "kubectl"
+	if _, err := exec.LookPath("stern"); err == nil {
+		tool = "stern"
+	}
+	argv := logsCommand(tool, ctx, namespace, args[1], extra)
+	fmt.Fprintf(os.Stderr, "%s %s\n", dimStyle.Render("·"), dimStyle.Render(strings.Join(argv, " ")))
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), awsProfileEnv(cfg, ctx)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.ExitCode())
+		}
+		fatal(err)
+	}
+}
+
+// logsCommand builds the tail command. A selector with "=" (or "!=", "in")
+// is a label selector; anything else names a pod or type/name.
+func logsCommand(tool, ctx, namespace, selector string, extra []string) []string {
+	argv := []string{tool, "--context", ctx}
+	if tool == "kubectl" {
+		argv = append(argv, "logs", "-f")
+	}
+	if namespace != "" {
+		argv = append(argv, "-n", namespace)
+	}
+	if strings.ContainsAny(selector, "=!") || strings.Contains(selector, " in ") {
+		argv = append(argv, "-l", selector)
+		if tool == "kubectl" {
+			// Every pod and container behind the selector, told apart
+			argv = append(argv, "--all-containers", "--prefix")
+		}
+	} else {
+		argv = append(argv, selector)
+	}
+	return append(argv, slices.Clone(extra)...)
+}
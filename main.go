@@ -1,17 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
-	"unicode/utf8"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 const version = "1.5.0"
@@ -48,8 +54,8 @@ var (
 
 	// List items
 	selectedItemStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#00d4ff"))
+				Bold(true).
+				Foreground(lipgloss.Color("#00d4ff"))
 
 	normalItemStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#999"))
@@ -58,6 +64,10 @@ var (
 			Bold(true).
 			Foreground(lipgloss.Color("#50fa7b"))
 
+	// Protected (prod) contexts
+	dangerItemStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#ff5555"))
+
 	// Decorations
 	aliasStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#bd93f9"))
 	activeTag    = lipgloss.NewStyle().Foreground(lipgloss.Color("#50fa7b")).Render("●")
@@ -79,45 +89,116 @@ var (
 
 // ── Config (aliases + history + pins + groups) ────────
 type config struct {
-	Aliases    map[string]string   `json:"aliases"`
-	History    []string            `json:"history,omitempty"`
-	Previous   string              `json:"previous,omitempty"`
-	Pins       []string            `json:"pins,omitempty"`
-	ShortNames bool                `json:"short_names,omitempty"`
-	Groups     map[string][]string `json:"groups,omitempty"`
-	AI         aiConfig            `json:"ai,omitempty"`
-	AIMemory   []aiMemoryEntry     `json:"ai_memory,omitempty"`
+	Aliases        map[string]string       `json:"aliases"`
+	MultiAliases   map[string][]string     `json:"multi_aliases,omitempty"`
+	History        []string                `json:"history,omitempty"`
+	HistoryLog     []historyEntry          `json:"history_log,omitempty"`
+	Previous       string                  `json:"previous,omitempty"`
+	Pins           []string                `json:"pins,omitempty"`
+	ShortNames     bool                    `json:"short_names,omitempty"`
+	ShowRecent     bool                    `json:"show_recent,omitempty"`
+	ShowNamespaces bool                    `json:"show_namespaces,omitempty"`
+	ShowSidebar    bool                    `json:"show_sidebar,omitempty"`
+	Sort           string                  `json:"sort,omitempty"` // TUI order: "pins" (default), "alpha", "recent", "frecency" or "group"
+	AutoSelect     bool                    `json:"auto_select_single,omitempty"`
+	ProdConfirm    string                  `json:"prod_confirm,omitempty"` // Enter on a protected context in the TUI: "" asks for a second Enter, "off" doesn't
+	CertWarnDays   int                     `json:"cert_warn_days,omitempty"`
+	Timeout        int                     `json:"timeout_seconds,omitempty"`
+	KubectxSync    bool                    `json:"kubectx_sync,omitempty"`
+	RecordQueries  bool                    `json:"record_queries,omitempty"`
+	Features       map[string]bool         `json:"features,omitempty"` // experimental subsystems turned on (ksw features)
+	SuggestHintAt  int64                   `json:"suggest_hint_at,omitempty"`
+	NoSuggest      []string                `json:"no_suggest,omitempty"`
+	StartCursor    string                  `json:"start_cursor,omitempty"` // where the TUI cursor starts: "current" (default), "recent" or "top"
+	Groups         map[string][]string     `json:"groups,omitempty"`
+	GroupDefault   map[string]string       `json:"group_default,omitempty"` // group → its primary context
+	GroupColors    map[string]string       `json:"group_colors,omitempty"`  // group → color of its rows' marker, #rrggbb
+	Home           string                  `json:"home,omitempty"`          // safe default context for ksw home
+	Provenance     map[string]provenance   `json:"provenance,omitempty"`    // how each context entered the kubeconfig
+	Trash          []trashEntry            `json:"trash,omitempty"`         // removed aliases, pins and groups (ksw trash)
+	Expiry         map[string]string       `json:"expiry,omitempty"`        // context → last valid day, YYYY-MM-DD
+	AWSProfiles    map[string]string       `json:"aws_profiles,omitempty"`  // context or AWS account ID → AWS profile it needs
+	Notes          map[string]string       `json:"notes,omitempty"`         // context → reminder printed after switching to it
+	NotesMuted     []string                `json:"notes_muted,omitempty"`   // contexts whose note isn't printed
+	Archived       []string                `json:"archived,omitempty"`
+	Icons          []iconRule              `json:"icons,omitempty"`
+	Truncate       string                  `json:"truncate,omitempty"` // long names in the TUI: "middle" (default), "start" or "end"
+	Forwards       map[string][]forwardDef `json:"forwards,omitempty"`
+	Tunnels        []tunnelConfig          `json:"tunnels,omitempty"`
+	Protected      []string                `json:"protected,omitempty"`  // globs guarded against AI rename/delete and red in the TUI; default *prod*, *pdn*
+	AlreadyOn      string                  `json:"already_on,omitempty"` // "" prints a note, "silent" prints nothing, "exit" exits with exitAlreadyOn
+	AI             aiConfig                `json:"ai,omitempty"`
+	AIMemory       []aiMemoryEntry         `json:"ai_memory,omitempty"`
+	AILearned      map[string]string       `json:"ai_learned,omitempty"` // normalized query → context
+	Integrations   integrationsConfig      `json:"integrations,omitempty"`
+	IdleReminder   idleReminderConfig      `json:"idle_reminder,omitempty"`
+	Freeze         freezeConfig            `json:"freeze,omitempty"`
+	PolicyURL      string                  `json:"policy_url,omitempty"`
+
+	ActiveProfile string                 `json:"active_profile,omitempty"`
+	Profiles      map[string]profileData `json:"profiles,omitempty"`
+	profile       string                 // active non-default profile, "" = default
+	base          profileData            // default-profile settings while another profile is active
+	fileAI        aiConfig               // AI settings as stored, before KSW_AI_* overrides
 }
 
 const maxHistory = 10
 
+// historyEntry is a timestamped switch, kept for reporting (ksw history export)
+type historyEntry struct {
+	Context string `json:"context"`
+	From    string `json:"from,omitempty"`
+	Time    int64  `json:"time"`
+}
+
+const maxHistoryLog = 1000
+
 func configPath() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".ksw.json")
 }
 
 func loadConfig() config {
-	c := config{Aliases: make(map[string]string), Groups: make(map[string][]string)}
-	data, err := os.ReadFile(configPath())
-	if err != nil {
-		return c
+	c := config{Aliases: make(map[string]string), MultiAliases: make(map[string][]string), Groups: make(map[string][]string)}
+	if data, err := os.ReadFile(configPath()); err == nil {
+		_ = json.Unmarshal(data, &c)
 	}
-	_ = json.Unmarshal(data, &c)
+	c.useProfile(activeProfileName(c))
+	c.fileAI = c.AI
+	c.AI = c.AI.withEnv()
 	if c.Aliases == nil {
 		c.Aliases = make(map[string]string)
 	}
+	if c.MultiAliases == nil {
+		c.MultiAliases = make(map[string][]string)
+	}
 	if c.Groups == nil {
 		c.Groups = make(map[string][]string)
 	}
 	return c
 }
 
+// saveConfig writes the config through a temporary file, so an interrupted
+// write can't leave it half-written, and refreshes its backup
 func saveConfig(c config) error {
+	if err := configDamage(); err != nil {
+		return fmt.Errorf("%s is damaged (%v); repair it with ksw config doctor --fix", configPath(), err)
+	}
+	c.AI = c.AI.withoutEnv(c.fileAI)
+	c.storeProfile()
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(configPath(), data, 0644)
+	tmp := configPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, configPath()); err != nil {
+		return err
+	}
+	_ = os.WriteFile(backupPath(), data, 0644)
+	return nil
 }
 
 // recordHistory saves current context to history before switching
@@ -137,6 +218,11 @@ func recordHistory(cfg *config, current, next string) {
 		newHistory = newHistory[:maxHistory]
 	}
 	cfg.History = newHistory
+
+	cfg.HistoryLog = append(cfg.HistoryLog, historyEntry{Context: next, From: current, Time: time.Now().Unix()})
+	if len(cfg.HistoryLog) > maxHistoryLog {
+		cfg.HistoryLog = cfg.HistoryLog[len(cfg.HistoryLog)-maxHistoryLog:]
+	}
 }
 
 // ── Fuzzy matching ─────────────────────────────────────
@@ -148,16 +234,16 @@ type scored struct {
 // fuzzyMatch returns a score > 0 if pattern fuzzy-matches str.
 // Higher score = better match. 0 = no match.
 func fuzzyMatch(str, pattern string) int {
-	str = strings.ToLower(str)
-	pattern = strings.ToLower(pattern)
+	return fuzzyScore([]rune(strings.ToLower(str)), []rune(strings.ToLower(pattern)))
+}
 
-	pLen := utf8.RuneCountInString(pattern)
+// fuzzyScore is fuzzyMatch on already-lowercased runes; it doesn't allocate,
+// so the TUI can score every context on each keystroke
+func fuzzyScore(sRunes, pRunes []rune) int {
+	pLen := len(pRunes)
 	if pLen == 0 {
 		return 1
 	}
-
-	sRunes := []rune(str)
-	pRunes := []rune(pattern)
 	sLen := len(sRunes)
 
 	// Check if all pattern chars exist in order
@@ -193,43 +279,59 @@ func fuzzyMatch(str, pattern string) int {
 	}
 
 	// Exact substring bonus
-	if strings.Contains(str, pattern) {
+	if containsRunes(sRunes, pRunes) {
 		score += 50
 	}
 
 	return score
 }
 
-// ── Kubeconfig helpers ─────────────────────────────────
-func getContexts() ([]string, error) {
-	cmd := exec.Command("kubectl", "config", "get-contexts", "-o", "name")
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get contexts: %w", err)
-	}
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	var contexts []string
-	for _, l := range lines {
-		l = strings.TrimSpace(l)
-		if l != "" {
-			contexts = append(contexts, l)
+func containsRunes(s, sub []rune) bool {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if s[i] == sub[0] && slices.Equal(s[i:i+len(sub)], sub) {
+			return true
 		}
 	}
-	return contexts, nil
+	return false
+}
+
+// ── Kubeconfig helpers ─────────────────────────────────
+func getContexts() ([]string, error) {
+	return kube.Contexts()
+}
+
+// getKubeconfigState returns the current context and every context name in
+// one read, so a name can be resolved before switching instead of
+// trial-switching and retrying
+func getKubeconfigState() (string, []string, error) {
+	return kube.State()
 }
 
 func getCurrentContext() string {
-	cmd := exec.Command("kubectl", "config", "current-context")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
+	return kube.Current()
+}
+
+// effectiveContext is the context commands act on: KSW_CONTEXT when set
+// (a per-terminal override from ksw shellenv, ksw exec or CI), otherwise the
+// kubeconfig's current context
+func effectiveContext() string {
+	if ctx := os.Getenv("KSW_CONTEXT"); ctx != "" {
+		return ctx
 	}
-	return strings.TrimSpace(string(out))
+	return getCurrentContext()
+}
+
+// getContextNamespaces returns the default namespace configured for each context
+func getContextNamespaces() map[string]string {
+	return kube.Namespaces()
+}
+
+func getContextFiles() map[string]string {
+	return kube.Files()
 }
 
 func switchContext(name string) error {
-	cmd := exec.Command("kubectl", "config", "use-context", name)
-	return cmd.Run()
+	return kube.Use(name)
 }
 
 // ── Model ──────────────────────────────────────────────
@@ -245,9 +347,37 @@ type model struct {
 	terminalHeight int
 	terminalWidth  int
 	quitting       bool
-	shortNames      bool
-	activeGroup     string // "" = all contexts
-	showPinnedOnly  bool   // Ctrl+F toggle
+	shortNames     bool
+	activeGroup    string // "" = all contexts
+	activeAlias    string // multi-target alias being picked from
+	showArchived   bool   // show only archived contexts (ksw --archived)
+	showRecent     bool   // Ctrl+E toggle: "Recent" section above the list
+	showNamespaces bool   // Ctrl+N toggle: default namespace per row
+	namespaces     map[string]string
+	files          map[string]string // context → kubeconfig file, nil unless $KUBECONFIG merges several
+	fileLabels     map[string]string // kubeconfig file → name shown after each row
+	localRunning   map[string]bool   // kind/minikube/k3d context → running
+	showLatency    bool              // Ctrl+L toggle: API server RTT per row and in the preview
+	servers        map[string]string // context → API server, loaded after the first paint
+	latency        map[string]latencyResult
+	aiQuery        string   // "?" query the AI results belong to
+	aiMatches      []string // contexts picked by the AI for aiQuery
+	aiPending      bool
+	aiErr          string
+	recentCount    int      // number of leading entries in filtered that belong to the Recent section
+	showPinnedOnly bool     // Ctrl+F toggle
+	searchIndex    [][]rune // lowercased name + aliases per context, built on the first search
+	status         string   // feedback for the last in-TUI action, cleared after statusTTL
+	confirmProd    string   // protected context waiting for a second Enter
+	showHelp       bool     // "?" overlay
+	showSidebar    bool     // Ctrl+B toggle: groups sidebar
+	sidebarFocus   bool     // ←/→ move focus between the sidebar and the list
+	sidebarCursor  int
+	recentOnly     bool // the sidebar's "Recent" entry
+	historySearch  bool // Ctrl+R: only contexts from the history, newest first
+	statusID       int
+	view           *viewCache
+	certExpiry     map[string]time.Time // client certificate expiry per context, loaded after the first paint
 }
 
 // shortName extracts the last segment after '/' from a context name
@@ -258,6 +388,57 @@ func shortName(ctx string) string {
 	return ctx
 }
 
+// ── Provider badges ────────────────────────────────────
+
+// iconRule maps a regex on the context name, or on its cluster's server
+// URL, to a badge (text or emoji)
+type iconRule struct {
+	Match  string `json:"match,omitempty"`
+	Server string `json:"server,omitempty"`
+	Icon   string `json:"icon"`
+}
+
+// defaultIconRules detect the provider from common context naming schemes
+// and from the API server hosts of managed clusters. Words like eks only
+// count on their own, between separators, so "geeks" isn't an EKS cluster.
+var defaultIconRules = []iconRule{
+	{Match: `^arn:aws[a-z-]*:eks:|(^|[-_.])eks([-_.]|$)`, Server: `\.eks\.amazonaws\.com(\.cn)?(:|/|$)`, Icon: "aws"},
+	{Match: `^gke_`, Server: `\.gke\.goog(:|/|$)`, Icon: "gcp"},
+	{Match: `(^|[-_.])aks([-_.]|$)`, Server: `\.azmk8s\.io(:|/|$)`, Icon: "azure"},
+	{Match: `^k3d-|(^|[-_.])k3s([-_.]|$)`, Icon: "k3s"},
+	{Match: `^minikube`, Icon: "minikube"},
+	{Match: `^kind-`, Icon: "kind"},
+}
+
+var iconRegexps = map[string]*regexp.Regexp{}
+
+// iconMatch reports whether the regex pattern matches s; an empty pattern
+// matches nothing
+func iconMatch(pattern, s string) bool {
+	if pattern == "" {
+		return false
+	}
+	re, ok := iconRegexps[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		iconRegexps[pattern] = re
+	}
+	return re != nil && re.MatchString(s)
+}
+
+// providerBadge returns the badge for ctx, whose API server is server ("" when
+// unknown); user rules take precedence over the defaults
+func providerBadge(ctx, server string, rules []iconRule) string {
+	for _, set := range [][]iconRule{rules, defaultIconRules} {
+		for _, r := range set {
+			if iconMatch(r.Match, ctx) || (server != "" && iconMatch(r.Server, server)) {
+				return r.Icon
+			}
+		}
+	}
+	return ""
+}
+
 func initialModel(contexts []string, current string, cfg config, activeGroup string, pinnedOnly bool) model {
 	m := model{
 		contexts:       contexts,
@@ -266,18 +447,98 @@ func initialModel(contexts []string, current string, cfg config, activeGroup str
 		terminalHeight: 24,
 		terminalWidth:  80,
 		shortNames:     cfg.ShortNames,
+		showRecent:     cfg.ShowRecent,
+		showNamespaces: cfg.ShowNamespaces,
+		showSidebar:    cfg.ShowSidebar,
 		activeGroup:    activeGroup,
 		showPinnedOnly: pinnedOnly,
 	}
+	// Separate kubectl reads, so run them side by side. Local clusters are
+	// detected and API servers read after the first paint (Init): docker and
+	// minikube are slow, and the servers only add badges until Ctrl+L.
+	var wg sync.WaitGroup
+	if m.showNamespaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.namespaces = getContextNamespaces()
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.files = getContextFiles()
+	}()
+	wg.Wait()
+	if recordFileOrigins(&m.cfg, m.files) {
+		_ = saveConfig(m.cfg)
+	}
+	m.fileLabels = kubeconfigLabels(m.files)
+	m.view = newViewCache()
+	m.sidebarCursor = m.sidebarIndex()
 	m.resetFilter()
+	m.focusStart()
+	return m
+}
+
+// focusStart puts the cursor where start_cursor says: on the current
+// context, on the last one used before it (where switching back usually
+// goes), or on the first row
+func (m *model) focusStart() {
+	switch m.cfg.StartCursor {
+	case "top":
+		m.cursor = 0
+		return
+	case "recent":
+		for _, ctx := range m.cfg.History {
+			if ctx != m.current && slices.ContainsFunc(m.filtered, func(i int) bool { return m.contexts[i] == ctx }) {
+				m.focus(ctx)
+				return
+			}
+		}
+	}
+	m.focus(m.current)
+}
+
+// prefill starts the TUI with query already typed, best match highlighted
+func (m *model) prefill(query string) {
+	m.search = query
+	m.applyFilter()
+	m.cursor = 0
+	m.scrollOffset = 0
+	m.autoSelect()
+}
+
+// autoSelect chooses the only match of a search when auto_select_single is
+// on, like fzf's --select-1. "?" queries wait for the AI, so they never count.
+func (m *model) autoSelect() bool {
+	if !m.cfg.AutoSelect || m.search == "" || strings.HasPrefix(m.search, "?") || len(m.filtered) != 1 {
+		return false
+	}
+	ctx := m.contexts[m.filtered[0]]
+	if m.needsConfirm(ctx) {
+		m.confirmProd = ctx
+		return false
+	}
+	m.chosen = ctx
+	return true
+}
+
+// needsConfirm reports whether choosing ctx takes a second Enter: it's
+// protected and prod_confirm isn't off
+func (m *model) needsConfirm(ctx string) bool {
+	return m.cfg.ProdConfirm != "off" && isProtected(m.cfg, ctx)
+}
+
+// focus moves the cursor to ctx if it is in the filtered list
+func (m *model) focus(ctx string) {
 	for i, idx := range m.filtered {
-		if contexts[idx] == current {
+		if m.contexts[idx] == ctx {
 			m.cursor = i
 			break
 		}
 	}
 	m.ensureVisible()
-	return m
 }
 
 // isPinned returns true if ctx is in the pins list
@@ -290,34 +551,63 @@ func (m *model) isPinned(ctx string) bool {
 	return false
 }
 
+// movePin moves the pin at index from to index to, shifting the rest
+func movePin(cfg *config, from, to int) {
+	p := cfg.Pins[from]
+	pins := append(cfg.Pins[:from:from], cfg.Pins[from+1:]...)
+	pins = append(pins[:to], append([]string{p}, pins[to:]...)...)
+	cfg.Pins = pins
+}
+
+// isArchived returns true if ctx has been hidden with ksw archive
+func (m *model) isArchived(ctx string) bool {
+	for _, a := range m.cfg.Archived {
+		if a == ctx {
+			return true
+		}
+	}
+	return false
+}
+
 // sortedByPins returns indices with pinned contexts first (preserving pin order), then the rest
 func (m *model) sortedByPins(indices []int) []int {
+	if m.activeAlias != "" {
+		return indices
+	}
 	pinSet := make(map[string]int, len(m.cfg.Pins))
 	for i, p := range m.cfg.Pins {
 		pinSet[p] = i
 	}
-	pinned := make([]int, 0, len(m.cfg.Pins))
-	rest := make([]int, 0, len(indices))
-	// collect pinned in pin order
-	for _, p := range m.cfg.Pins {
-		for _, idx := range indices {
-			if m.contexts[idx] == p {
-				pinned = append(pinned, idx)
-				break
-			}
-		}
+	// collect pinned in pin order, then the rest
+	byPin := make([]int, len(m.cfg.Pins))
+	for i := range byPin {
+		byPin[i] = -1
 	}
-	// collect rest
+	sorted := make([]int, 0, len(indices))
 	for _, idx := range indices {
-		if _, ok := pinSet[m.contexts[idx]]; !ok {
-			rest = append(rest, idx)
+		if pos, ok := pinSet[m.contexts[idx]]; ok {
+			if byPin[pos] < 0 {
+				byPin[pos] = idx
+			}
+		} else {
+			sorted = append(sorted, idx)
 		}
 	}
-	return append(pinned, rest...)
+	pinned := slices.DeleteFunc(byPin, func(idx int) bool { return idx < 0 })
+	return append(pinned, sorted...)
 }
 
-// groupSet returns the set of contexts in the active group (nil = all)
+// groupSet returns the set of contexts in the active group, or the history
+// when the sidebar's Recent entry is picked (nil = all)
 func (m *model) groupSet() map[string]bool {
+	if m.recentOnly {
+		set := make(map[string]bool, len(m.cfg.History)+1)
+		for _, h := range m.cfg.History {
+			set[h] = true
+		}
+		set[m.current] = true
+		return set
+	}
 	if m.activeGroup == "" {
 		return nil
 	}
@@ -330,6 +620,10 @@ func (m *model) groupSet() map[string]bool {
 }
 
 func (m *model) resetFilter() {
+	if m.historySearch {
+		m.filterHistory()
+		return
+	}
 	gs := m.groupSet()
 	var indices []int
 	for i, ctx := range m.contexts {
@@ -339,14 +633,47 @@ func (m *model) resetFilter() {
 		if m.showPinnedOnly && !m.isPinned(ctx) {
 			continue
 		}
+		if m.isArchived(ctx) != m.showArchived {
+			continue
+		}
 		indices = append(indices, i)
 	}
-	m.filtered = m.sortedByPins(indices)
+	m.filtered = m.sortIndices(indices)
+	recent := m.recentIndices()
+	m.recentCount = len(recent)
+	m.filtered = append(recent, m.filtered...)
 	m.scrollOffset = 0
 }
 
+// maxRecent is the number of history entries shown in the Recent section
+const maxRecent = 3
+
+// recentIndices returns the last few history entries (excluding the current
+// context) for the Recent section. Only shown on the unfiltered, full list.
+func (m *model) recentIndices() []int {
+	if !m.showRecent || m.activeGroup != "" || m.activeAlias != "" || m.showPinnedOnly || m.showArchived || m.recentOnly {
+		return nil
+	}
+	pos := make(map[string]int, len(m.contexts))
+	for i, ctx := range m.contexts {
+		pos[ctx] = i
+	}
+	var recent []int
+	for _, h := range m.cfg.History {
+		idx, ok := pos[h]
+		if !ok || h == m.current || m.isArchived(h) {
+			continue
+		}
+		recent = append(recent, idx)
+		if len(recent) == maxRecent {
+			break
+		}
+	}
+	return recent
+}
+
 func (m *model) applyFilter() {
-	if m.search == "" {
+	if m.search == "" || m.historySearch {
 		m.resetFilter()
 		return
 	}
@@ -354,10 +681,44 @@ func (m *model) applyFilter() {
 	query := m.search
 	gs := m.groupSet()
 
-	// Build searchable strings: context name + any aliases pointing to it
-	reverseAlias := make(map[string][]string)
-	for alias, ctx := range m.cfg.Aliases {
-		reverseAlias[ctx] = append(reverseAlias[ctx], alias)
+	// "?" starts a natural-language query: the list stays as-is until
+	// Enter sends it to the AI, then shows exactly what it picked
+	if strings.HasPrefix(query, "?") {
+		if m.aiMatches == nil || m.aiQuery != query {
+			m.resetFilter()
+			return
+		}
+		picked := make(map[string]bool, len(m.aiMatches))
+		for _, ctx := range m.aiMatches {
+			picked[ctx] = true
+		}
+		var indices []int
+		for i, ctx := range m.contexts {
+			if !picked[ctx] || (gs != nil && !gs[ctx]) || m.isArchived(ctx) != m.showArchived {
+				continue
+			}
+			indices = append(indices, i)
+		}
+		m.filtered = m.sortedByPins(indices)
+		m.recentCount = 0
+		if m.cursor >= len(m.filtered) {
+			m.cursor = max(0, len(m.filtered)-1)
+		}
+		return
+	}
+
+	if m.searchIndex == nil {
+		m.searchIndex = buildSearchIndex(m.contexts, m.cfg.Aliases)
+	}
+	query, file, byFile := splitFileFilter(query)
+	pattern := []rune(strings.ToLower(query))
+	pinned := make(map[string]bool, len(m.cfg.Pins))
+	for _, p := range m.cfg.Pins {
+		pinned[p] = true
+	}
+	archived := make(map[string]bool, len(m.cfg.Archived))
+	for _, a := range m.cfg.Archived {
+		archived[a] = true
 	}
 
 	var results []scored
@@ -365,42 +726,101 @@ func (m *model) applyFilter() {
 		if gs != nil && !gs[ctx] {
 			continue
 		}
-		if m.showPinnedOnly && !m.isPinned(ctx) {
+		if m.showPinnedOnly && !pinned[ctx] {
 			continue
 		}
-		// Match against context name
-		searchable := ctx
-		if aliases, ok := reverseAlias[ctx]; ok {
-			searchable += " " + strings.Join(aliases, " ")
+		if archived[ctx] != m.showArchived {
+			continue
 		}
-		score := fuzzyMatch(searchable, query)
-		if score > 0 {
+		if byFile && !strings.Contains(strings.ToLower(m.fileLabel(ctx)), file) {
+			continue
+		}
+		if score := fuzzyScore(m.searchIndex[i], pattern); score > 0 {
 			results = append(results, scored{index: i, score: score})
 		}
 	}
 
-	// Sort by score descending
-	sort.Slice(results, func(a, b int) bool {
-		return results[a].score > results[b].score
-	})
+	// Sort by score descending; ties keep kubeconfig order
+	slices.SortStableFunc(results, func(a, b scored) int { return b.score - a.score })
 
 	indices := make([]int, 0, len(results))
 	for _, r := range results {
 		indices = append(indices, r.index)
 	}
 	m.filtered = m.sortedByPins(indices)
+	m.recentCount = 0
+	if m.cursor >= len(m.filtered) {
+		m.cursor = max(0, len(m.filtered)-1)
+	}
+}
+
+// historyOrder lists the contexts used before, most recently used first,
+// from the switch log and then the shorter history older configs only have.
+// The current context is left out: there's no going back to it.
+func (m *model) historyOrder() []string {
+	seen := map[string]bool{m.current: true}
+	var order []string
+	add := func(ctx string) {
+		if ctx != "" && !seen[ctx] {
+			seen[ctx] = true
+			order = append(order, ctx)
+		}
+	}
+	for i := len(m.cfg.HistoryLog) - 1; i >= 0; i-- {
+		add(m.cfg.HistoryLog[i].Context)
+		add(m.cfg.HistoryLog[i].From)
+	}
+	for _, h := range m.cfg.History {
+		add(h)
+	}
+	return order
+}
+
+// filterHistory is the list in Ctrl+R mode: like a shell's reverse search,
+// the search narrows the history but never reorders it by match quality
+func (m *model) filterHistory() {
+	if m.searchIndex == nil {
+		m.searchIndex = buildSearchIndex(m.contexts, m.cfg.Aliases)
+	}
+	pos := make(map[string]int, len(m.contexts))
+	for i, ctx := range m.contexts {
+		pos[ctx] = i
+	}
+	pattern := []rune(strings.ToLower(m.search))
+	m.filtered = nil
+	for _, ctx := range m.historyOrder() {
+		i, ok := pos[ctx]
+		if !ok || m.isArchived(ctx) != m.showArchived {
+			continue
+		}
+		if len(pattern) > 0 && fuzzyScore(m.searchIndex[i], pattern) == 0 {
+			continue
+		}
+		m.filtered = append(m.filtered, i)
+	}
+	m.recentCount = 0
 	if m.cursor >= len(m.filtered) {
 		m.cursor = max(0, len(m.filtered)-1)
 	}
 }
 
-func (m *model) maxVisible() int {
-	headerLines := 8
-	v := m.terminalHeight - headerLines - 2
-	if v < 3 {
-		v = 3
+// buildSearchIndex lowercases each context name plus the aliases pointing
+// to it once, so typing doesn't redo it for every context on every keystroke
+func buildSearchIndex(contexts []string, aliases map[string]string) [][]rune {
+	reverseAlias := make(map[string][]string)
+	for alias, ctx := range aliases {
+		reverseAlias[ctx] = append(reverseAlias[ctx], alias)
+	}
+	index := make([][]rune, len(contexts))
+	for i, ctx := range contexts {
+		searchable := ctx
+		if names, ok := reverseAlias[ctx]; ok {
+			sort.Strings(names)
+			searchable += " " + strings.Join(names, " ")
+		}
+		index[i] = []rune(strings.ToLower(searchable))
 	}
-	return v
+	return index
 }
 
 func (m *model) ensureVisible() {
@@ -422,22 +842,133 @@ func (m *model) aliasFor(ctx string) string {
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	if hasLocalContexts(m.contexts) {
+		return tea.Batch(loadCertExpiryCmd, loadServersCmd, loadLocalClustersCmd)
+	}
+	return tea.Batch(loadCertExpiryCmd, loadServersCmd)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if !keepsRows(msg) {
+		m.view.invalidate()
+	}
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case clearStatusMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+
 	case tea.WindowSizeMsg:
 		m.terminalHeight = msg.Height
 		m.terminalWidth = msg.Width
 
+	case latencyMsg:
+		m.latency[msg.ctx] = msg.result
+
+	case certExpiryMsg:
+		m.certExpiry = msg
+
+	case localClustersMsg:
+		m.localRunning = msg
+
+	case serversMsg:
+		m.servers = msg
+
+	case aiFilterMsg:
+		if msg.query != m.search {
+			break // query changed while the AI was thinking
+		}
+		m.aiPending = false
+		if msg.err != nil {
+			m.aiErr = msg.err.Error() // Enter retries
+			break
+		}
+		m.aiQuery = msg.query
+		m.aiMatches = msg.matches
+		m.aiErr = ""
+		m.cursor = 0
+		m.applyFilter()
+
 	case tea.KeyMsg:
+		// Any key but Enter takes back a pending prod confirmation
+		armed := m.confirmProd
+		m.confirmProd = ""
+		if m.showHelp {
+			return m.updateHelp(msg)
+		}
+		if msg.Type == tea.KeyRunes && string(msg.Runes) == "?" && m.search == "" {
+			m.showHelp = true
+			return m, nil
+		}
+		if m.sidebarFocus {
+			switch msg.Type {
+			case tea.KeyUp:
+				m.selectSidebar(m.sidebarCursor - 1)
+				return m, nil
+			case tea.KeyDown:
+				m.selectSidebar(m.sidebarCursor + 1)
+				return m, nil
+			case tea.KeyHome:
+				m.selectSidebar(0)
+				return m, nil
+			case tea.KeyEnd:
+				m.selectSidebar(len(m.sidebarItems()) - 1)
+				return m, nil
+			case tea.KeyEnter, tea.KeyRight:
+				m.sidebarFocus = false
+				return m, nil
+			}
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			m.quitting = true
 			return m, tea.Quit
+		case tea.KeyCtrlS:
+			// Cycle the sort mode and persist; searching still ranks by match
+			m.cfg.Sort = m.nextSortMode()
+			cmd = m.saveStatus("sorted by " + m.cfg.Sort)
+			if m.search == "" {
+				var ctx string
+				if len(m.filtered) > 0 {
+					ctx = m.contexts[m.filtered[m.cursor]]
+				}
+				m.resetFilter()
+				m.cursor = 0
+				m.focus(ctx)
+			}
+		case tea.KeyCtrlB:
+			// Toggle the groups sidebar and persist
+			m.showSidebar = !m.showSidebar
+			m.sidebarFocus = false
+			m.sidebarCursor = m.sidebarIndex()
+			m.cfg.ShowSidebar = m.showSidebar
+			cmd = m.saveStatus("sidebar " + onOff(m.showSidebar))
+		case tea.KeyLeft:
+			if m.showSidebar {
+				m.sidebarFocus = true
+			}
+		case tea.KeyRight:
+			m.sidebarFocus = false
+		case tea.KeyCtrlR:
+			// Reverse search through the history; pressed again, on to the
+			// next older match, as in the shell
+			if !m.historySearch {
+				m.historySearch = true
+				m.cursor = 0
+				m.applyFilter()
+			} else if m.cursor < len(m.filtered)-1 {
+				m.cursor++
+			}
+			m.ensureVisible()
 		case tea.KeyEscape:
-			if m.search != "" {
+			if m.historySearch {
+				m.historySearch = false
+				m.search = ""
+				m.resetFilter()
+				m.cursor = 0
+				m.focus(m.current)
+			} else if m.search != "" {
 				m.search = ""
 				m.resetFilter()
 				m.cursor = 0
@@ -472,17 +1003,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.filtered) > 0 {
 				ctx := m.contexts[m.filtered[m.cursor]]
 				if m.isPinned(ctx) {
-					newPins := make([]string, 0, len(m.cfg.Pins))
-					for _, p := range m.cfg.Pins {
-						if p != ctx {
-							newPins = append(newPins, p)
-						}
-					}
-					m.cfg.Pins = newPins
+					trashPin(&m.cfg, ctx, "")
+					cmd = m.saveStatus("unpinned " + shortName(ctx) + dimStyle.Render(" · ksw trash restore to undo"))
 				} else {
 					m.cfg.Pins = append(m.cfg.Pins, ctx)
+					cmd = m.saveStatus(pinTag + " pinned " + shortName(ctx))
 				}
-				_ = saveConfig(m.cfg)
 				savedCtx := ctx
 				m.resetFilter()
 				for i, idx := range m.filtered {
@@ -493,6 +1019,31 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.ensureVisible()
 			}
+		case tea.KeyCtrlUp, tea.KeyCtrlDown:
+			// Reorder the highlighted pin
+			if len(m.filtered) > 0 {
+				ctx := m.contexts[m.filtered[m.cursor]]
+				for i, p := range m.cfg.Pins {
+					if p != ctx {
+						continue
+					}
+					j := i - 1
+					if msg.Type == tea.KeyCtrlDown {
+						j = i + 1
+					}
+					if j >= 0 && j < len(m.cfg.Pins) {
+						movePin(&m.cfg, i, j)
+						cmd = m.saveStatus(fmt.Sprintf("moved %s to pin #%d", shortName(ctx), j+1))
+						if m.search != "" {
+							m.applyFilter()
+						} else {
+							m.resetFilter()
+						}
+						m.focus(ctx)
+					}
+					break
+				}
+			}
 		case tea.KeyCtrlT:
 			// Jump to first pinned context
 			for i, idx := range m.filtered {
@@ -506,17 +1057,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Toggle short name view and persist
 			m.shortNames = !m.shortNames
 			m.cfg.ShortNames = m.shortNames
-			_ = saveConfig(m.cfg)
+			cmd = m.saveStatus("short names " + onOff(m.shortNames))
+			if m.sortMode() == "alpha" && m.search == "" && len(m.filtered) > 0 {
+				// Alphabetical order follows the names on screen
+				ctx := m.contexts[m.filtered[m.cursor]]
+				m.resetFilter()
+				m.focus(ctx)
+			}
+		case tea.KeyCtrlE:
+			// Toggle the Recent section and persist
+			m.showRecent = !m.showRecent
+			m.cfg.ShowRecent = m.showRecent
+			cmd = m.saveStatus("recent section " + onOff(m.showRecent))
+			if m.search == "" {
+				var ctx string
+				if len(m.filtered) > 0 {
+					ctx = m.contexts[m.filtered[m.cursor]]
+				}
+				m.resetFilter()
+				m.cursor = 0
+				m.focus(ctx)
+			}
+		case tea.KeyCtrlN:
+			// Toggle namespace display and persist
+			m.showNamespaces = !m.showNamespaces
+			m.cfg.ShowNamespaces = m.showNamespaces
+			cmd = m.saveStatus("namespaces " + onOff(m.showNamespaces))
+			if m.showNamespaces && m.namespaces == nil {
+				m.namespaces = getContextNamespaces()
+			}
+		case tea.KeyCtrlL:
+			// Toggle API server latency; measure every context in the background
+			m.showLatency = !m.showLatency
+			m.ensureVisible()
+			cmd = m.setStatus("latency " + onOff(m.showLatency))
+			if m.showLatency && m.latency == nil {
+				if m.servers == nil {
+					m.servers = getContextServers() // toggled before loadServersCmd answered
+				}
+				m.latency = make(map[string]latencyResult)
+				cmds := []tea.Cmd{cmd}
+				for _, ctx := range m.contexts {
+					if server, ok := m.servers[ctx]; ok {
+						cmds = append(cmds, measureLatencyCmd(ctx, server))
+					}
+				}
+				return m, tea.Batch(cmds...)
+			}
 		case tea.KeyCtrlF:
 			// Toggle pinned-only filter
 			m.showPinnedOnly = !m.showPinnedOnly
+			m.activeGroup, m.recentOnly = "", false
+			m.sidebarCursor = m.sidebarIndex()
+			cmd = m.setStatus("pinned only " + onOff(m.showPinnedOnly))
 			m.search = ""
 			m.resetFilter()
 			m.cursor = 0
 			m.scrollOffset = 0
 		case tea.KeyEnter:
+			if strings.HasPrefix(m.search, "?") && len(m.search) > 1 && m.aiQuery != m.search {
+				if m.aiPending {
+					break
+				}
+				m.aiPending = true
+				m.aiErr = ""
+				return m, aiFilterCmd(m.search, m.contexts, m.cfg)
+			}
 			if len(m.filtered) > 0 {
-				m.chosen = m.contexts[m.filtered[m.cursor]]
+				ctx := m.contexts[m.filtered[m.cursor]]
+				if m.needsConfirm(ctx) && armed != ctx {
+					m.confirmProd = ctx
+					break
+				}
+				m.chosen = ctx
 				return m, tea.Quit
 			}
 		case tea.KeyBackspace:
@@ -529,141 +1142,348 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.applyFilter()
 			m.cursor = 0
 			m.scrollOffset = 0
+			if m.autoSelect() {
+				return m, tea.Quit
+			}
 			// Note: KeyCtrlP and KeyCtrlT are handled above, not here
 		}
 	}
-	return m, nil
+	return m, cmd
 }
 
-func (m model) View() string {
-	if m.quitting || m.chosen != "" {
-		return ""
-	}
+// viewOverscan is how many rows above and below the visible window stay
+// rendered, so scrolling by a line or two reuses them
+const viewOverscan = 5
 
-	var b strings.Builder
+// viewCache keeps View's output buffer and rendered rows between frames.
+// The model is copied on every Update, so it is shared through a pointer.
+type viewCache struct {
+	buf  bytes.Buffer
+	rows map[int]renderedRow // context index → row, near the visible window
+}
 
-	// ── Current context ──
-	currentAlias := m.aliasFor(m.current)
-	currentName := m.current
-	if m.shortNames {
-		currentName = shortName(m.current)
+// renderedRow is a list row minus the pointer; the highlighted row's name is
+// styled at render time
+type renderedRow struct {
+	marker  string // the group color bar, or a blank
+	danger  bool   // a protected context
+	name    string // styled as a non-highlighted row
+	display string // plain name, full or short
+	extras  string
+}
+
+func newViewCache() *viewCache {
+	return &viewCache{rows: make(map[int]renderedRow)}
+}
+
+func (c *viewCache) invalidate() {
+	if c != nil {
+		clear(c.rows)
 	}
-	if currentAlias != "" {
-		currentName += " " + aliasStyle.Render("@"+currentAlias)
+}
+
+// keepsRows reports whether msg leaves every row's text as it was: moving the
+// cursor and editing the search only change which rows are shown
+func keepsRows(msg tea.Msg) bool {
+	if _, ok := msg.(clearStatusMsg); ok {
+		return true
 	}
-	var currentDisplay string
-	if m.shortNames {
-		currentDisplay = dimStyle.Render("[short] ") + currentValueStyle.Render(currentName)
-	} else {
-		currentDisplay = currentValueStyle.Render(currentName)
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return false
 	}
-	filterLabel := ""
-	if m.activeGroup != "" {
-		filterLabel = "  " + pinItemStyle.Render("["+m.activeGroup+"]")
-	} else if m.showPinnedOnly {
-		filterLabel = "  " + pinItemStyle.Render("[★ pinned]")
+	switch key.Type {
+	case tea.KeyUp, tea.KeyDown, tea.KeyHome, tea.KeyEnd, tea.KeyPgUp, tea.KeyPgDown, tea.KeyRunes, tea.KeyBackspace:
+		return true
 	}
-	b.WriteString("  " + currentLabelStyle.Render("  current ") + currentDisplay + filterLabel + "\n")
-	b.WriteString("\n")
+	return false
+}
 
-	// ── Search bar ──
-	if m.search != "" {
-		b.WriteString("  " + searchActiveStyle.Render("  ❯ "+m.search+"█") + "\n")
-	} else {
-		b.WriteString("  " + searchPlaceholderStyle.Render("  ❯ type to search...") + "\n")
+// row returns the rendered row for m.contexts[idx], from the cache if it has it
+func (m model) row(idx int) renderedRow {
+	if m.view != nil {
+		if r, ok := m.view.rows[idx]; ok {
+			return r
+		}
 	}
-
-	// ── Separator ──
-	b.WriteString("  " + dimStyle.Render("  ─────────────────────────────────────────") + "\n")
-
-	if len(m.filtered) == 0 {
-		b.WriteString("\n  " + dimStyle.Render("  No matching contexts") + "\n")
-		return b.String()
+	r := m.renderRow(m.contexts[idx])
+	if m.view != nil {
+		m.view.rows[idx] = r
 	}
+	return r
+}
 
-	maxVisible := m.maxVisible()
-
-	start := m.scrollOffset
-	end := start + maxVisible
-	if end > len(m.filtered) {
-		end = len(m.filtered)
-	}
+func (m model) renderRow(ctx string) renderedRow {
+	isActive := ctx == m.current
+	isPinned := m.isPinned(ctx)
+	expired := isExpired(m.cfg, ctx)
 
-	// ── Scroll indicator top ──
-	if start > 0 {
-		b.WriteString("  " + dimStyle.Render(fmt.Sprintf("    ▲ %d more", start)) + "\n")
+	var extras strings.Builder
+	if badge := providerBadge(ctx, m.servers[ctx], m.cfg.Icons); badge != "" {
+		extras.WriteString(" " + dimStyle.Render(badge))
 	}
-
-	// ── List ──
-	for i := start; i < end; i++ {
-		ctx := m.contexts[m.filtered[i]]
-		isActive := ctx == m.current
-		alias := m.aliasFor(ctx)
-
-		pointer := "   "
-		var name string
-
-		isPinned := m.isPinned(ctx)
-
-		displayCtx := ctx
-		if m.shortNames {
-			displayCtx = shortName(ctx)
-		}
-
-		if i == m.cursor {
-			pointer = " ❯ "
-			name = selectedItemStyle.Render(displayCtx)
-		} else if isActive {
-			name = activeItemStyle.Render(displayCtx)
-		} else if isPinned {
-			name = pinItemStyle.Render(displayCtx)
+	if running, ok := m.localRunning[ctx]; ok {
+		if running {
+			extras.WriteString(" " + successStyle.Render("running"))
 		} else {
-			name = normalItemStyle.Render(displayCtx)
+			extras.WriteString(" " + dimStyle.Render("stopped"))
 		}
+	}
+	if label := m.fileLabels[m.files[ctx]]; label != "" {
+		extras.WriteString(" " + dimStyle.Render("· "+label))
+	}
+	if m.showNamespaces {
+		if ns := m.namespaces[ctx]; ns != "" {
+			extras.WriteString("  " + dimStyle.Render("(ns: "+ns+")"))
+		}
+	}
+	if m.showLatency {
+		if lr, ok := m.latency[ctx]; ok {
+			extras.WriteString(" " + formatLatency(lr))
+		}
+	}
+	if alias := m.aliasFor(ctx); alias != "" {
+		extras.WriteString(" " + aliasStyle.Render("@"+alias))
+	}
+	if expired {
+		extras.WriteString(" " + warnStyle.Render("expired"))
+	}
+	if notAfter, ok := m.certExpiry[ctx]; ok {
+		if left := certLeft(notAfter, time.Now(), certWarnDays(m.cfg)); left != "" {
+			extras.WriteString(" " + warnStyle.Render("cert "+left))
+		}
+	}
+	if isPinned {
+		extras.WriteString(" " + pinTag)
+	}
+	if isActive {
+		extras.WriteString(" " + activeTag)
+	}
 
-		extras := ""
-		if alias != "" {
-			extras += " " + aliasStyle.Render("@"+alias)
+	r := renderedRow{marker: m.groupMarker(ctx), danger: isProtected(m.cfg, ctx), display: ctx, extras: extras.String()}
+	if m.shortNames {
+		r.display = shortName(ctx)
+	}
+	r.display = truncateName(r.display, m.nameWidth(r.extras), m.cfg.Truncate)
+	if isActive {
+		r.name = activeItemStyle.Render(r.display)
+	} else if expired {
+		r.name = dimStyle.Render(r.display)
+	} else if r.danger {
+		r.name = dangerItemStyle.Render(r.display)
+	} else if isPinned {
+		r.name = pinItemStyle.Render(r.display)
+	} else {
+		r.name = normalItemStyle.Render(r.display)
+	}
+	return r
+}
+
+func (m model) View() string {
+	if m.quitting || m.chosen != "" {
+		return ""
+	}
+	if m.showHelp {
+		return m.helpView()
+	}
+
+	// Reuse last frame's buffer: after the first frame, writing a new one
+	// doesn't grow it
+	b := &bytes.Buffer{}
+	if m.view != nil {
+		b = &m.view.buf
+		b.Reset()
+	}
+
+	b.WriteString(m.header() + "\n")
+	list := &strings.Builder{}
+
+	if len(m.filtered) == 0 {
+		if !m.showSidebar {
+			b.WriteString("\n  " + dimStyle.Render("  No matching contexts") + "\n")
+			return b.String()
 		}
-		if isPinned {
-			extras += " " + pinTag
+		list.WriteString("\n  " + dimStyle.Render("  No matching contexts") + "\n")
+	} else {
+		m.writeList(list)
+	}
+
+	body := list.String()
+	if m.showSidebar {
+		height := max(strings.Count(body, "\n"), min(len(m.sidebarItems()), m.maxVisible()+2))
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(height), strings.TrimSuffix(body, "\n")) + "\n"
+	}
+	b.WriteString(body)
+
+	b.WriteString(m.footer() + "\n")
+
+	return b.String()
+}
+
+// writeList renders the visible rows, scroll indicators and preview
+func (m model) writeList(list *strings.Builder) {
+	maxVisible := m.maxVisible()
+
+	start := m.scrollOffset
+	end := start + maxVisible
+	if end > len(m.filtered) {
+		end = len(m.filtered)
+	}
+
+	// Render the rows just outside the window too, and drop the cache once
+	// it holds far more than the window so long scrolls don't grow it
+	if m.view != nil {
+		if len(m.view.rows) > 4*(maxVisible+2*viewOverscan) {
+			m.view.invalidate()
 		}
-		if isActive {
-			extras += " " + activeTag
+		for i := max(0, start-viewOverscan); i < min(len(m.filtered), end+viewOverscan); i++ {
+			m.row(m.filtered[i])
 		}
+	}
+
+	// ── Scroll indicator top ──
+	if start > 0 {
+		list.WriteString("  " + dimStyle.Render(fmt.Sprintf("    ▲ %d more", start)) + "\n")
+	}
 
-		b.WriteString("  " + pointer + name + extras + "\n")
+	// ── List ──
+	for i := start; i < end; i++ {
+		if m.recentCount > 0 && i == 0 {
+			list.WriteString("  " + dimStyle.Render("    Recent") + "\n")
+		} else if m.recentCount > 0 && i == m.recentCount {
+			list.WriteString("  " + dimStyle.Render("    All") + "\n")
+		}
+		r := m.row(m.filtered[i])
+		pointer, name := "   ", r.name
+		if i == m.cursor {
+			pointer, name = " ❯ ", selectedItemStyle.Render(r.display)
+			if r.danger {
+				pointer = warnStyle.Render(pointer)
+			}
+		}
+		list.WriteString(" " + r.marker + pointer + name + r.extras + "\n")
 	}
 
 	// ── Scroll indicator bottom ──
 	if end < len(m.filtered) {
-		b.WriteString("  " + dimStyle.Render(fmt.Sprintf("    ▼ %d more", len(m.filtered)-end)) + "\n")
+		list.WriteString("  " + dimStyle.Render(fmt.Sprintf("    ▼ %d more", len(m.filtered)-end)) + "\n")
 	}
 
-	// ── Footer ──
-	b.WriteString("\n")
-	counter := counterStyle.Render(fmt.Sprintf("  %d/%d", len(m.filtered), len(m.contexts)))
-	var help string
-	if m.terminalWidth >= 120 {
-		help = "  ↑↓ navigate · enter select · ctrl+p pin/unpin · ctrl+t jump-pin · ctrl+f pinned · ctrl+h short · esc · ctrl+c quit"
-	} else if m.terminalWidth >= 80 {
-		help = "  ↑↓ · enter · ^p pin · ^t pins · ^f pinned · ^h short · esc · ^c quit"
-	} else {
-		help = "  ↑↓ enter · ^p pin · ^f pinned · ^h short · esc ^c"
+	// ── Preview: API server, history and origin of the highlighted context ──
+	ctx := m.contexts[m.filtered[m.cursor]]
+	if m.showLatency {
+		preview := dimStyle.Render("no server configured")
+		if server, ok := m.servers[ctx]; ok {
+			rtt := dimStyle.Render("measuring…")
+			if r, ok := m.latency[ctx]; ok {
+				rtt = formatLatency(r)
+			}
+			preview = dimStyle.Render(server) + "  " + rtt
+		}
+		list.WriteString("  " + dimStyle.Render("    ⇄ ") + preview + "\n")
+	}
+	usage := "    ↺ " + m.usagePreview(ctx)
+	if p, ok := m.cfg.Provenance[ctx]; ok {
+		if label := m.fileLabels[p.Detail]; p.Source == "file" && label != "" {
+			// As the rows name the file
+			p.Detail = label
+		}
+		usage += "  · " + p.String()
 	}
-	b.WriteString("  " + counter + helpStyle.Render(help) + "\n")
+	list.WriteString("  " + dimStyle.Render(usage) + "\n")
+}
 
-	return b.String()
+// previewHistory is how many recent switches the preview lists
+const previewHistory = 3
+
+// usagePreview sums up how often ctx was switched to, newest switches first
+func (m model) usagePreview(ctx string) string {
+	var times []string
+	total := 0
+	for i := len(m.cfg.HistoryLog) - 1; i >= 0; i-- {
+		e := m.cfg.HistoryLog[i]
+		if e.Context != ctx {
+			continue
+		}
+		total++
+		if len(times) < previewHistory {
+			times = append(times, time.Unix(e.Time, 0).Format("2006-01-02 15:04"))
+		}
+	}
+	switch total {
+	case 0:
+		return "never switched to"
+	case 1:
+		return "1 switch · " + times[0]
+	}
+	return fmt.Sprintf("%d switches · last %s", total, strings.Join(times, ", "))
 }
 
 // ── Main ───────────────────────────────────────────────
 func main() {
+	defer recoverCrash()
+	showArchived := false
+	query := "" // ksw --tui <query> / ksw /<query>: open the TUI already filtered
+	// Global: ksw --profile <name> [args...]
+	if len(os.Args) > 2 && os.Args[1] == "--profile" {
+		profileOverride = os.Args[2]
+		os.Args = append(os.Args[:1], os.Args[3:]...)
+	}
+	// Global: --already-on <ok|silent|exit>, anywhere before a "--" or after
+	// ksw k, like the flags below
+	alreadyOnFlag := ""
+	for i := 1; i < len(os.Args)-1 && os.Args[i] != "--" && (i == 1 || os.Args[1] != "k"); i++ {
+		if os.Args[i] == "--already-on" {
+			alreadyOnFlag = os.Args[i+1]
+			os.Args = append(os.Args[:i], os.Args[i+2:]...)
+			break
+		}
+	}
+	// Global: --verify / --rollback-on-fail / --exact / --prefix / --debug / --override, anywhere
+	// before a "--" (what follows belongs to ksw exec's or ksw each's command) or after
+	// ksw k (kubectl has its own --prefix)
+	for i := 1; i < len(os.Args) && os.Args[i] != "--" && (i == 1 || os.Args[1] != "k"); i++ {
+		switch os.Args[i] {
+		case "--verify":
+			verifyFlag = true
+		case "--rollback-on-fail":
+			rollbackOnFailFlag = true
+		case "--exact":
+			matchMode = "exact"
+		case "--prefix":
+			matchMode = "prefix"
+		case "--debug":
+			debugFlag = true
+		case "--override":
+			overrideFlag = true
+		default:
+			continue
+		}
+		os.Args = append(os.Args[:i], os.Args[i+1:]...)
+		i--
+	}
+	if debugFlag {
+		startTrace()
+	}
+	// Opening the TUI: read the kubeconfig while the config loads
+	var state <-chan startupState
+	if opensTUI(os.Args[1:]) {
+		state = prefetchState()
+	}
 	cfg := loadConfig()
+	setCommandTimeout(cfg)
+	if err := configDamage(); err != nil && (len(os.Args) < 2 || os.Args[1] != "config") {
+		fmt.Fprintf(os.Stderr, "%s %s is damaged (%v): using defaults and leaving it untouched. Run ksw config doctor\n",
+			warnStyle.Render("⚠"), configPath(), err)
+	}
+	if alreadyOnFlag != "" {
+		cfg.AlreadyOn = alreadyOnFlag
+	}
 
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
-		case "-v", "--version":
-			fmt.Printf("ksw v%s\n", version)
+		case "-v", "--version", "version":
+			handleVersion(cfg)
 			return
 
 		case "-h", "--help":
@@ -672,46 +1492,147 @@ func main() {
 Usage:
   ksw                        Launch interactive selector (fuzzy search)
   ksw <name>                 Switch directly to context <name> (short name ok)
+  ksw --tui <query>          Launch the selector with the search already typed (or: ksw /<query>)
+  ksw --print-only [query]   Pick with the selector and print the name instead of switching
+  ksw --choose-into <file> [query]  Same, writing the name to <file> (exit 130 if cancelled)
   ksw -                      Switch to previous context
+  ksw home                   Switch to your home (safe default) context
+  ksw home set [ctx]         Set the home context (default: current)
+  ksw info [ctx] [--json]    Everything ksw knows about a context, with a health check
+  ksw trash [ls]             List removed aliases, pins and groups
+  ksw trash restore <n|name> Bring one back
+  ksw reconcile [--auto]     Fix aliases, pins, groups and history pointing at deleted contexts
+  ksw config doctor [--fix [--from-backup]]  Check ~/.ksw.json; repair it, or restore ksw's last save
+  ksw import kubectx|kubie [--dry-run]  Bring over the previous context, namespaces and shell aliases
+  ksw export kubectx [off]   Keep kubectx's previous context in sync on every switch
+  ksw features [on|off <name>]  List or toggle experimental features
   ksw @<alias>               Switch using an alias
   ksw history                Show recent context history
   ksw history <n>            Switch to history entry by number
+  ksw history export [--format csv|json] [--since 30d]  Export timestamped switches
+  ksw stats [--since 30d] [--context <glob>] [--json]  Top contexts and a weekday/hour heatmap
+  ksw stats --queries [on|off]  What you typed in the TUI before each pick (opt-in, stays local)
   ksw group add <name> [ctx] Create a group (use quotes for glob: "eks-sufi*")
   ksw group rm <name>        Remove a group
   ksw group ls               List all groups
   ksw group use <name>       Open TUI filtered to a group
+  ksw group use <name> --default  Switch straight to the group's default context
+  ksw group default <g> <ctx>  Set the group's default (primary) context
+  ksw group color <g> <color|off>  Mark the group's rows in the TUI with a color
   ksw group add-ctx <g> <ctx> Add a context to an existing group
   ksw group rmi <g> <ctx>  Remove a context from a group
+  ksw group auto [--yes]     Propose groups from context names (account, region, env)
+  ksw group export <g> [-o file]  Write a kubeconfig with only the group's contexts
   ksw pin <name>             Pin a context to the top of the list
+  ksw pin "<glob>" | @<group>  Pin every matching context at once
   ksw pin rm <name>          Unpin a context
+  ksw pin mv <name> <pos>    Move a pin to another position (1 = top)
   ksw pin ls                 List pinned contexts
   ksw pin use                Open TUI filtered to pinned contexts only
   ksw rename <old> <new>     Rename a context in kubeconfig
+  ksw archive <name>         Hide a context from the TUI and completion
+  ksw archive ls             List archived contexts
+  ksw unarchive <name>       Restore an archived context
+  ksw --archived             Open TUI showing only archived contexts
+  ksw which <name|@alias>    Show how a name would resolve, without switching
   ksw alias <name> <context> Create alias for a context
+  ksw alias <name> <c1> <c2> Create a multi-target alias (ksw @name opens a picker)
   ksw alias rm <name>        Remove an alias
   ksw alias ls               List all aliases
+  ksw alias check            Find aliases whose target no longer exists
   ksw completion install     Auto-install completion in ~/.zshrc or ~/.bashrc
   ksw completion zsh         Print zsh setup line
   ksw completion bash        Print bash setup line
+  ksw completion nu|elvish   Print nushell / elvish setup
+  ksw completion cache [contexts|aliases|groups|clear]  Names for completion, cached until kubeconfig changes
   ksw ai "<query>"           Switch context using natural language (AI)
   ksw ai chat                Interactive conversational mode (multi-turn)
   ksw ai config              Configure AI provider (openai, claude, gemini)
+  ksw ai config --provider <p> [--model m] [--api-key k] [--region r] [--profile p]  Non-interactive setup
+  ksw ai config set <timeout|retries|backoff> <n>  Tune AI request timeout (s), retries and backoff (ms)
+  ksw ai config set language <lang>  Always reply in this language ("" = follow the query)
+  ksw ai config set ca_bundle <pem> | insecure true  TLS settings for corporate proxies (HTTPS_PROXY is honored)
+  ksw ai models [use <id>]   List the provider's models or pick one not in the built-in list
+  ksw ai learned [ls|rm <q>|clear]  Manage queries answered offline from past AI switches
+  ksw ai summarize           AI overview of your contexts by env, account and usage
+  ksw ai tidy [--yes]        AI cleanup plan (renames, groups, pins, deletions) to apply selectively
+  ksw integrations slack enable --token <t>  Set Slack status while on prod contexts
+  ksw integrations slack disable  Stop updating Slack status
+  ksw watch [--idle 30m] [--default <ctx>]  Remind me to leave an idle prod context
+  ksw watch set [--idle 30m] [--default <ctx>]  Save the watch reminder settings
+  ksw forward <ctx> <svc:port>  Start a background port-forward for a context
+  ksw forward save <ctx> <svc:port>  Save a forward; ksw forward <ctx> starts all saved
+  ksw forward ls | stop <id|all>  List or stop running port-forwards
+  eval "$(ksw shellenv <ctx>)"  Pin a context to this terminal only (--alias, --reset)
+  ksw batch [--dry-run] < ops.txt  Apply switch/alias/pin/group lines (all or nothing)
+  ksw ide-server             JSON-RPC on stdin/stdout for editor extensions (list, current, resolve, switch)
+  ksw check [--latency] [pattern]  Check API server reachability (and round-trip time)
+  ksw check -o table|wide|json [--fail-fast]  Same as a wide table or JSON for CI, stopping at the first failure
+  ksw wait <ctx> [--timeout 60s]  Block until <ctx> exists and its API server accepts the credentials
+  ksw add <name> --server <url> --token <t>|-- <cmd>  Create a context, cluster and user, checked before saving
+  ksw tunnel set <pattern> --cmd "<cmd>" [--health <url>] [--auto]  VPN/SSH tunnel a context needs
+  ksw tunnel ls | rm <pattern> | up [ctx] | status [ctx]  Manage and start tunnels
+  ksw local ls               List kind/minikube/k3d clusters and their state
+  ksw local start|stop <name>  Start or stop a local cluster
+  ksw local import           Add missing local cluster contexts to kubeconfig
   ksw eks kubeconfig           Sync EKS clusters to kubeconfig
   ksw eks kubeconfig --profile <name>  Sync only one AWS profile
+  ksw profile ls             List config profiles
+  ksw profile use <name>     Switch config profile (aliases/pins/groups/AI)
+  ksw profile rm <name>      Delete a config profile
+  ksw --profile <name> ...   Run any command with a profile for this call only
+  ksw <name> --already-on <ok|silent|exit>  When already on <name>: note, no output, or exit 6
+  ksw <name> --verify        After switching, check the API server answers (exit 7 if not)
+  ksw <name> --rollback-on-fail  Like --verify, and switch back when the check fails
+  ksw <name> --override      Switch during a change freeze (logged to ~/.ksw/audit.log)
+  ksw freeze                 List current and upcoming change freezes
+  ksw policy [refresh]       Show the guardrails the team policy (policy_url) adds
+  ksw <name> --exact         Only a full or short name that matches exactly (no fuzzy/substring)
+  ksw <name> --prefix        Only names starting with <name>
+  ksw ... --debug            Log every kubectl/aws call with its time and exit status to ~/.ksw/debug.log
+  ksw bench [<name>] [-n <runs>]  Time each phase of a switch to <name> (default: current), switching back after
+  ksw suggest                Offer aliases for long context names you switch to often
+  ksw exec <name> -- <cmd>   Run a command against <name> without switching
+  ksw k [--on <target>] <kubectl args>  Run kubectl on a context, @alias, group or glob without switching
+  ksw each <@group> [-j N] -- <cmd>  Run a command on every context of a group at once, output prefixed by context
+  ksw each <@group> -o wide|json [--fail-fast] -- <cmd>  Same, reported as a wide table or JSON; --fail-fast kills the rest on a failure
+  ksw logs <ctx> <selector> [-n <ns>]  Tail logs with stern (or kubectl logs -f) on <ctx> without switching
+  ksw current [--short]      Print the context in effect (KSW_CONTEXT overrides kubeconfig)
+  ksw prompt                 Print a shell prompt segment for the context in effect
+  ksw project [use]          Show or switch to the context declared in the repo's .ksw.yaml
+  ksw project hook <zsh|bash>  Shell hook warning when the context doesn't match the repo
+  ksw expire <ctx> <date|30d>  Time-box a context: greyed out and warned about after the date
+  ksw expire ls|rm <ctx>|clean  List, clear, or delete expired contexts
+  ksw aws-profile <ctx|account> <profile>  AWS profile a context (or a whole account) needs; ls, rm
+  ksw note <ctx> "<text>"    Print a reminder after switching to a context; ls, rm, mute, unmute
   ksw -l                     List contexts (non-interactive)
   ksw -h                     Show this help
   ksw -v                     Show version
+  ksw version --verbose      Commit, build date, Go, platform, config/kubeconfig paths and features, for bug reports
 
 Navigation:
   Type                Filter contexts with fuzzy search
+  file:<name>         Only contexts from a matching kubeconfig file
   ↑ / ↓               Move up / down
   Home / End          Go to top / bottom
   PgUp / PgDn         Jump 10 items
   Backspace           Delete last character from filter
   Enter               Switch to highlighted context
+  Ctrl+↑ / Ctrl+↓     Move the highlighted pin up / down
+  Ctrl+B              Toggle the groups sidebar; ← / → move between it and the list
+  Ctrl+S              Cycle the sort: pins first, alphabetical, recent, frecency, group
+  Ctrl+E              Toggle the Recent section (last 3 contexts)
+  Ctrl+N              Toggle each context's default namespace
+  ?                   Show all keys and the current filters
   Esc                 Clear filter / Quit
   Ctrl+C              Quit
 
+Exit codes:
+  2  not found     3  ambiguous     4  kubeconfig error
+  5  AI error      6  already on the target (--already-on exit)
+  7  --verify failed
+  130  cancelled   1  anything else
+
 Config stored in ~/.ksw.json
 `, version)
 			return
@@ -719,19 +1640,22 @@ Config stored in ~/.ksw.json
 		case "-l", "--list":
 			contexts, err := getContexts()
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+				fatal(err)
 			}
 			current := getCurrentContext()
 			reverseAlias := make(map[string]string)
 			for alias, ctx := range cfg.Aliases {
 				reverseAlias[ctx] = alias
 			}
+			servers := getContextServers()
 			for _, ctx := range contexts {
 				alias := ""
 				if a, ok := reverseAlias[ctx]; ok {
 					alias = aliasStyle.Render(" @" + a)
 				}
+				if badge := providerBadge(ctx, servers[ctx], cfg.Icons); badge != "" {
+					alias = " " + dimStyle.Render(badge) + alias
+				}
 				if ctx == current {
 					fmt.Printf("%s%s %s\n", currentValueStyle.Render("▸ "+ctx), alias, activeTag)
 				} else {
@@ -748,19 +1672,27 @@ Config stored in ~/.ksw.json
 			}
 			current := getCurrentContext()
 			prev := cfg.Previous
+			if err := checkFreeze(cfg, current, prev); err != nil {
+				fatal(err)
+			}
 			recordHistory(&cfg, current, prev)
 			if err := switchContext(prev); err != nil {
 				fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), prev)
-				os.Exit(1)
+				os.Exit(exitNotFound)
 			}
 			if err := saveConfig(cfg); err != nil {
 				fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
 				os.Exit(1)
 			}
 			fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), prev)
+			runSwitchHooks(cfg, current, prev)
 			return
 
 		case "history":
+			if len(os.Args) >= 3 && os.Args[2] == "export" {
+				handleHistoryExport(cfg)
+				return
+			}
 			if len(cfg.History) == 0 {
 				fmt.Println(dimStyle.Render("No history yet."))
 				return
@@ -785,31 +1717,21 @@ Config stored in ~/.ksw.json
 					fmt.Fprintf(os.Stderr, "%s Number must be between 1 and %d\n", warnStyle.Render("✗"), len(cfg.History))
 					os.Exit(1)
 				}
-				target := cfg.History[n-1]
+				contexts, err := getContexts()
+				if err != nil {
+					fatal(err)
+				}
+				target, err := resolveContext(cfg.History[n-1], contexts)
+				if err != nil {
+					fatal(err)
+				}
+				if err := checkFreeze(cfg, current, target); err != nil {
+					fatal(err)
+				}
 				recordHistory(&cfg, current, target)
 				if err := switchContext(target); err != nil {
-					// Try suffix/substring match
-					contexts, cerr := getContexts()
-					if cerr != nil {
-						fmt.Fprintln(os.Stderr, cerr)
-						os.Exit(1)
-					}
-					var matches []string
-					for _, ctx := range contexts {
-						if strings.HasSuffix(ctx, "/"+target) || strings.Contains(ctx, target) {
-							matches = append(matches, ctx)
-						}
-					}
-					if len(matches) == 1 {
-						target = matches[0]
-						if err := switchContext(target); err != nil {
-							fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), target)
-							os.Exit(1)
-						}
-					} else {
-						fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), target)
-						os.Exit(1)
-					}
+					fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), target, err)
+					os.Exit(exitKubeconfig)
 				}
 				_ = saveConfig(cfg)
 				alias := ""
@@ -817,6 +1739,7 @@ Config stored in ~/.ksw.json
 					alias = " " + aliasStyle.Render("@"+a)
 				}
 				fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), target, alias)
+				runSwitchHooks(cfg, current, target)
 				return
 			}
 
@@ -844,7 +1767,15 @@ Config stored in ~/.ksw.json
 			return
 
 		case "completion":
-			handleCompletion()
+			handleCompletion(cfg)
+			return
+
+		case "__complete":
+			handleComplete(cfg)
+			return
+
+		case "which":
+			handleWhich(cfg)
 			return
 
 		case "pin":
@@ -855,7 +1786,6 @@ Config stored in ~/.ksw.json
 			handleGroup(cfg)
 			return
 
-
 		case "alias":
 			handleAlias(cfg)
 			return
@@ -868,91 +1798,290 @@ Config stored in ~/.ksw.json
 			handleEks()
 			return
 
+		case "local":
+			handleLocal()
+			return
+
+		case "forward", "pf":
+			handleForward(cfg)
+			return
+
+		case "shellenv":
+			handleShellenv(cfg)
+			return
+
+		case "batch":
+			handleBatch(cfg)
+			return
+
+		case "k":
+			handleK(cfg)
+			return
+
+		case "each":
+			handleEach(cfg)
+			return
+
+		case "wait":
+			handleWait(cfg)
+			return
+
+		case "add":
+			handleAdd(cfg)
+			return
+
+		case "logs":
+			handleLogs(cfg)
+			return
+
+		case "aws-profile":
+			handleAWSProfile(cfg)
+			return
+
+		case "ide-server":
+			handleIDEServer(cfg)
+			return
+
+		case "check":
+			handleCheck(cfg)
+			return
+
+		case "tunnel":
+			handleTunnel(cfg)
+			return
+
+		case "integrations":
+			handleIntegrations(cfg)
+			return
+
+		case "watch":
+			handleWatch(cfg)
+			return
+
+		case "profile":
+			handleProfile(cfg)
+			return
+
+		case "archive", "unarchive":
+			handleArchive(cfg)
+			return
+
+		case "stats":
+			handleStats(cfg)
+			return
+
+		case "home":
+			handleHome(cfg)
+			return
+
+		case "info":
+			handleInfo(cfg)
+			return
+
+		case "exec":
+			handleExec(cfg)
+			return
+
+		case "current":
+			handleCurrent(cfg)
+			return
+
+		case "project":
+			handleProject(cfg)
+			return
+
+		case "expire":
+			handleExpire(cfg)
+			return
+
+		case "note":
+			handleNote(cfg)
+			return
+
+		case "freeze":
+			handleFreeze(cfg)
+			return
+
+		case "policy":
+			handlePolicy(cfg)
+			return
+
+		case "prompt":
+			handlePrompt(cfg)
+			return
+
+		case "trash":
+			handleTrash(cfg)
+			return
+
+		case "config":
+			handleConfig(cfg)
+			return
+
+		case "import":
+			handleImport(cfg)
+			return
+
+		case "export":
+			handleExport(cfg)
+			return
+
+		case "features":
+			handleFeatures(cfg)
+			return
+
+		case "bench":
+			handleBench(cfg)
+			return
+
+		case "suggest":
+			handleSuggest(cfg)
+			return
+
+		case "reconcile":
+			handleReconcile(cfg)
+			return
+
+		case "--archived":
+			// Fall through to the TUI showing only archived contexts
+			showArchived = true
+
+		case "--tui":
+			// Fall through to the TUI with the search already typed
+			query = strings.Join(os.Args[2:], " ")
+
+		case "--print-only":
+			// Fall through to the TUI as a picker: print the choice, don't switch
+			printOnlyFlag = true
+			query = strings.Join(os.Args[2:], " ")
+
+		case "--choose-into":
+			// Same, writing the choice to a file for editors embedding the TUI
+			if len(os.Args) < 3 {
+				fmt.Fprintln(os.Stderr, "Usage: ksw --choose-into <file> [query]")
+				os.Exit(1)
+			}
+			chooseIntoFile = os.Args[2]
+			query = strings.Join(os.Args[3:], " ")
+
 		default:
 			arg := os.Args[1]
 
+			// "/query" opens the TUI filtered instead of switching
+			if len(arg) > 1 && arg[0] == '/' {
+				query = strings.Join(append([]string{arg[1:]}, os.Args[2:]...), " ")
+				break
+			}
+
 			// Handle @alias
 			if strings.HasPrefix(arg, "@") {
 				aliasName := arg[1:]
-				target, ok := cfg.Aliases[aliasName]
-				if !ok {
-					fmt.Fprintf(os.Stderr, "%s Alias '%s' not found. Use 'ksw alias ls' to list.\n", warnStyle.Render("✗"), aliasName)
-					os.Exit(1)
-				}
-				// Try exact match first, then suffix/substring match
-				if err := switchContext(target); err != nil {
-					contexts, cerr := getContexts()
-					if cerr != nil {
-						fmt.Fprintln(os.Stderr, cerr)
-						os.Exit(1)
+				// Multi-target alias: open a mini-picker of just its targets
+				if targets, ok := cfg.MultiAliases[aliasName]; ok {
+					contexts, err := getContexts()
+					if err != nil {
+						fatal(err)
 					}
-					var matches []string
-					for _, ctx := range contexts {
-						if strings.HasSuffix(ctx, "/"+target) || strings.HasSuffix(ctx, target) || strings.Contains(ctx, target) {
-							matches = append(matches, ctx)
+					var resolved []string
+					for _, t := range targets {
+						ctx, err := resolveContext(t, contexts)
+						if err != nil {
+							fmt.Fprintf(os.Stderr, "%s @%s: %v\n", warnStyle.Render("✗"), aliasName, err)
+							continue
 						}
+						resolved = append(resolved, ctx)
 					}
-					if len(matches) == 1 {
-						target = matches[0]
-						if err := switchContext(target); err != nil {
-							fmt.Fprintf(os.Stderr, "%s Context '%s' (alias @%s) not found in kubeconfig.\n", warnStyle.Render("✗"), target, aliasName)
-							os.Exit(1)
-						}
-					} else if len(matches) > 1 {
-						fmt.Fprintf(os.Stderr, "%s Ambiguous alias @%s, matches:\n", warnStyle.Render("✗"), aliasName)
-						for _, m := range matches {
-							fmt.Fprintf(os.Stderr, "  %s\n", m)
-						}
-						os.Exit(1)
-					} else {
-						fmt.Fprintf(os.Stderr, "%s Context '%s' (alias @%s) not found in kubeconfig.\n", warnStyle.Render("✗"), target, aliasName)
+					if len(resolved) == 0 {
+						fmt.Fprintf(os.Stderr, "%s No targets of alias @%s found in kubeconfig.\n", warnStyle.Render("✗"), aliasName)
 						os.Exit(1)
 					}
+					current := getCurrentContext()
+					m := initialModel(resolved, current, cfg, "", false)
+					// Keep the alias's own ordering instead of pins-first
+					m.activeAlias = aliasName
+					m.resetFilter()
+					m.cursor = 0
+					m.focus(current)
+					runTUI(m, current)
+					return
+				}
+				target, ok := cfg.Aliases[aliasName]
+				if !ok {
+					fmt.Fprintf(os.Stderr, "%s Alias '%s' not found. Use 'ksw alias ls' to list.\n", warnStyle.Render("✗"), aliasName)
+					os.Exit(exitNotFound)
+				}
+				// Resolve exact, then suffix/substring, before switching once
+				current, contexts, err := getKubeconfigState()
+				if err != nil {
+					fatal(err)
+				}
+				matches, err := resolveContexts(target, contexts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s Context '%s' (alias @%s) not found in kubeconfig.\n", warnStyle.Render("✗"), target, aliasName)
+					os.Exit(exitNotFound)
+				}
+				if len(matches) > 1 {
+					fmt.Fprintf(os.Stderr, "%s Ambiguous alias @%s, matches:\n", warnStyle.Render("✗"), aliasName)
+					for _, m := range matches {
+						fmt.Fprintf(os.Stderr, "  %s\n", m)
+					}
+					os.Exit(exitAmbiguous)
+				}
+				target = matches[0]
+				if target == current {
+					reportAlreadyOn(cfg, current)
+					return
+				}
+				if err := checkFreeze(cfg, current, target); err != nil {
+					fatal(err)
+				}
+				if err := switchContext(target); err != nil {
+					fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), target, err)
+					os.Exit(exitKubeconfig)
 				}
-				current := getCurrentContext()
 				recordHistory(&cfg, current, target)
 				_ = saveConfig(cfg)
 				fmt.Printf("%s Switched to %s %s\n", successStyle.Render("✔"), target, aliasStyle.Render("@"+aliasName))
+				runSwitchHooks(cfg, current, target)
 				return
 			}
 
 			if arg[0] != '-' {
-				// Try exact match first, then suffix/substring match
-				current := getCurrentContext()
-				target := arg
-				if err := switchContext(target); err != nil {
-					// Exact match failed, try to find by suffix or substring
-					contexts, cerr := getContexts()
-					if cerr != nil {
-						fmt.Fprintln(os.Stderr, cerr)
-						os.Exit(1)
-					}
-					var matches []string
-					for _, ctx := range contexts {
-						if strings.HasSuffix(ctx, "/"+arg) || strings.HasSuffix(ctx, arg) || strings.Contains(ctx, arg) {
-							matches = append(matches, ctx)
-						}
-					}
-					if len(matches) == 1 {
-						target = matches[0]
-						if err := switchContext(target); err != nil {
-							fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), target)
-							os.Exit(1)
-						}
-					} else if len(matches) > 1 {
-						fmt.Fprintf(os.Stderr, "%s Ambiguous context '%s', matches:\n", warnStyle.Render("✗"), arg)
-						for _, m := range matches {
-							fmt.Fprintf(os.Stderr, "  %s\n", m)
-						}
-						os.Exit(1)
-					} else {
-						fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), arg)
-						os.Exit(1)
+				// Resolve exact, then suffix/substring, before switching once
+				current, contexts, err := getKubeconfigState()
+				if err != nil {
+					fatal(err)
+				}
+				if len(contexts) == 0 {
+					noContexts()
+				}
+				matches, err := resolveContexts(arg, contexts)
+				if err != nil {
+					matches = []string{pickSuggestion(arg, contexts)}
+				}
+				if len(matches) > 1 {
+					fmt.Fprintf(os.Stderr, "%s Ambiguous context '%s', matches:\n", warnStyle.Render("✗"), arg)
+					for _, m := range matches {
+						fmt.Fprintf(os.Stderr, "  %s\n", m)
 					}
+					os.Exit(exitAmbiguous)
+				}
+				target := matches[0]
+				if target == current {
+					reportAlreadyOn(cfg, current)
+					return
+				}
+				if err := checkFreeze(cfg, current, target); err != nil {
+					fatal(err)
+				}
+				if err := switchContext(target); err != nil {
+					fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), target, err)
+					os.Exit(exitKubeconfig)
 				}
 				recordHistory(&cfg, current, target)
 				_ = saveConfig(cfg)
 				fmt.Printf("%s Switched to %s\n", successStyle.Render("✔"), target)
+				runSwitchHooks(cfg, current, target)
 				return
 			}
 			fmt.Fprintf(os.Stderr, "Unknown flag: %s. Use -h for help.\n", arg)
@@ -961,28 +2090,105 @@ Config stored in ~/.ksw.json
 	}
 
 	// Interactive mode
-	contexts, err := getContexts()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if state == nil {
+		state = prefetchState()
+	}
+	s := <-state
+	current, contexts := s.current, s.contexts
+	if s.err != nil {
+		fatal(s.err)
 	}
 	if len(contexts) == 0 {
-		fmt.Fprintln(os.Stderr, "No contexts found in kubeconfig.")
-		os.Exit(1)
+		noContexts()
 	}
 
-	current := getCurrentContext()
 	m := initialModel(contexts, current, cfg, "", false)
+	if showArchived {
+		m.showArchived = true
+		m.resetFilter()
+		m.cursor = 0
+		m.focus(current)
+	}
+	if query != "" {
+		m.prefill(query)
+	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	result, err := p.Run()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	runTUI(m, current)
+}
+
+// startupState is what the TUI needs from the kubeconfig to start
+type startupState struct {
+	current  string
+	contexts []string
+	err      error
+}
+
+// prefetchState reads the kubeconfig in the background, in one kubectl call
+func prefetchState() <-chan startupState {
+	ch := make(chan startupState, 1)
+	go func() {
+		current, contexts, err := getKubeconfigState()
+		ch <- startupState{current, contexts, err}
+	}()
+	return ch
+}
+
+// opensTUI reports whether args go straight to the interactive selector
+func opensTUI(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	switch args[0] {
+	case "--tui", "--print-only", "--choose-into", "--archived":
+		return true
+	}
+	return strings.HasPrefix(args[0], "/")
+}
+
+// Set by ksw --print-only and ksw --choose-into <file>: the TUI reports its
+// choice instead of switching
+var (
+	printOnlyFlag  bool
+	chooseIntoFile string
+)
+
+// runTUI runs the interactive selector and switches to the chosen context
+func runTUI(m model, current string) {
+	final := m
+	// A prefilled search may already have picked one (auto_select_single)
+	if m.chosen == "" {
+		opts := []tea.ProgramOption{tea.WithAltScreen()}
+		if printOnlyFlag {
+			// stdout carries the result, usually into $(...), so draw on stderr
+			opts = append(opts, tea.WithOutput(os.Stderr))
+			lipgloss.SetColorProfile(termenv.NewOutput(os.Stderr).EnvColorProfile())
+		}
+		p := newProgram(m, opts...)
+		stop := killCommandsOnQuit()
+		result, err := p.Run()
+		stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		final = result.(model)
+	}
+	if final.chosen != "" {
+		recordQuery(final.cfg, final.search, final.chosen, final.cursor)
 	}
 
-	final := result.(model)
+	if printOnlyFlag || chooseIntoFile != "" {
+		reportChoice(final.chosen)
+		return
+	}
 	if final.chosen != "" && final.chosen != current {
+		if err := checkFreeze(final.cfg, current, final.chosen); err != nil {
+			fatal(err)
+		}
+		// Not detected yet when chosen before the first checks came back
+		if running, ok := final.localRunning[final.chosen]; !ok || !running {
+			ensureLocalRunning(final.chosen)
+		}
 		recordHistory(&final.cfg, current, final.chosen)
 		if err := switchContext(final.chosen); err != nil {
 			fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", final.chosen, err)
@@ -993,13 +2199,197 @@ Config stored in ~/.ksw.json
 		extra := ""
 		if alias != "" {
 			extra = " " + aliasStyle.Render("@"+alias)
+		} else if final.activeAlias != "" {
+			extra = " " + aliasStyle.Render("@"+final.activeAlias)
 		}
 		fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), final.chosen, extra)
+		runSwitchHooks(final.cfg, current, final.chosen)
 	} else if final.chosen == current {
 		fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
 	}
 }
 
+// reportChoice hands the picked context to whoever ran --print-only or
+// --choose-into. Nothing picked exits with exitCancelled and leaves the file
+// alone, so a missing file also means cancelled.
+func reportChoice(chosen string) {
+	if chosen == "" {
+		os.Exit(exitCancelled)
+	}
+	if chooseIntoFile == "" {
+		fmt.Println(chosen)
+		return
+	}
+	// Write then rename, so a plugin watching the file never reads half of it
+	tmp := chooseIntoFile + ".tmp"
+	err := os.WriteFile(tmp, []byte(chosen+"\n"), 0644)
+	if err == nil {
+		err = os.Rename(tmp, chooseIntoFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Could not write %s: %v\n", warnStyle.Render("✗"), chooseIntoFile, err)
+		os.Exit(1)
+	}
+}
+
+// reportAlreadyOn handles a switch to the context that is already current,
+// per the already_on setting: a note (default), nothing ("silent"), or the
+// exitAlreadyOn exit code ("exit") so scripts can tell nothing changed
+func reportAlreadyOn(cfg config, current string) {
+	switch cfg.AlreadyOn {
+	case "silent":
+	case "exit":
+		fmt.Fprintf(os.Stderr, "%s Already on %s\n", dimStyle.Render("·"), current)
+		os.Exit(exitAlreadyOn)
+	default:
+		fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
+	}
+}
+
+// didYouMean returns up to 3 contexts whose names look like name, best first
+func didYouMean(name string, contexts []string) []string {
+	type scored struct {
+		ctx   string
+		score float64
+	}
+	var candidates []scored
+	for _, ctx := range contexts {
+		score := max(similarity(name, ctx), similarity(name, shortName(ctx)))
+		// Letters in order, e.g. "pymnts" for payments, count as a fair match
+		if fuzzyMatch(shortName(ctx), name) > 0 {
+			score = max(score, 0.5)
+		}
+		if score >= 0.4 {
+			candidates = append(candidates, scored{ctx, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	var out []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		out = append(out, candidates[i].ctx)
+	}
+	return out
+}
+
+// pickSuggestion reports that name matched nothing and lists similar
+// contexts. On a terminal a single key picks one and it is returned;
+// otherwise, or when nothing is picked, it exits with exitNotFound.
+func pickSuggestion(name string, contexts []string) string {
+	fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), name)
+	suggestions := didYouMean(name, contexts)
+	if len(suggestions) == 0 || matchMode != "" {
+		os.Exit(exitNotFound)
+	}
+	fmt.Fprintln(os.Stderr, dimStyle.Render("  Did you mean:"))
+	for i, s := range suggestions {
+		fmt.Fprintf(os.Stderr, "    %s %s\n", counterStyle.Render(fmt.Sprintf("%d", i+1)), s)
+	}
+
+	keys := "1"
+	if len(suggestions) > 1 {
+		keys = fmt.Sprintf("1-%d", len(suggestions))
+	}
+	key, ok := readKey("Press " + keys + " to switch, any other key to cancel:")
+	if !ok || key < '1' || int(key-'0') > len(suggestions) {
+		os.Exit(exitNotFound)
+	}
+	return suggestions[key-'1']
+}
+
+// switchTo resolves name and switches to it the way ksw <name> does,
+// printing note after the context name on success
+func switchTo(cfg config, name, note string) {
+	current, contexts, err := getKubeconfigState()
+	if err != nil {
+		fatal(err)
+	}
+	if len(contexts) == 0 {
+		noContexts()
+	}
+	target, err := resolveContext(name, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	if target == current {
+		reportAlreadyOn(cfg, current)
+		return
+	}
+	if err := checkFreeze(cfg, current, target); err != nil {
+		fatal(err)
+	}
+	if err := switchContext(target); err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to switch to '%s': %v\n", warnStyle.Render("✗"), target, err)
+		os.Exit(exitKubeconfig)
+	}
+	recordHistory(&cfg, current, target)
+	_ = saveConfig(cfg)
+	if note != "" {
+		note = " " + note
+	}
+	fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), target, note)
+	runSwitchHooks(cfg, current, target)
+}
+
+// ── handleHome ─────────────────────────────────────────
+
+// handleHome switches to the designated safe default context:
+// ksw home | ksw home set [ctx] | ksw home rm
+func handleHome(cfg config) {
+	sub := ""
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+	switch sub {
+	case "":
+		if cfg.Home == "" {
+			fmt.Fprintf(os.Stderr, "%s No home context set. Use: ksw home set <ctx>\n", warnStyle.Render("✗"))
+			os.Exit(exitNotFound)
+		}
+		switchTo(cfg, cfg.Home, dimStyle.Render("(home)"))
+
+	case "set":
+		current, contexts, err := getKubeconfigState()
+		if err != nil {
+			fatal(err)
+		}
+		ctx := current
+		if len(os.Args) >= 4 {
+			if ctx, err = resolveContext(os.Args[3], contexts); err != nil {
+				fatal(err)
+			}
+		}
+		if ctx == "" {
+			fmt.Fprintln(os.Stderr, "Usage: ksw home set <ctx>")
+			os.Exit(1)
+		}
+		cfg.Home = ctx
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Home context: %s\n", successStyle.Render("✔"), ctx)
+
+	case "rm":
+		cfg.Home = ""
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Home context cleared\n", successStyle.Render("✔"))
+
+	case "show":
+		if cfg.Home == "" {
+			fmt.Println(dimStyle.Render("No home context set."))
+			return
+		}
+		fmt.Println(cfg.Home)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown home subcommand '%s'.\nUsage: ksw home [set <ctx>|rm|show]\n", sub)
+		os.Exit(1)
+	}
+}
+
 // ── handleRename ───────────────────────────────────────
 func handleRename(cfg config) {
 	if len(os.Args) < 4 {
@@ -1012,80 +2402,134 @@ func handleRename(cfg config) {
 	// Get all contexts to find the full name if short name given
 	contexts, err := getContexts()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fatal(err)
 	}
 
-	// Resolve old name (exact or suffix/substring)
-	resolvedOld := oldName
-	if err := switchContext(oldName); err != nil {
-		// Not exact, try substring
-		var matches []string
-		for _, ctx := range contexts {
-			if strings.HasSuffix(ctx, "/"+oldName) || strings.Contains(ctx, oldName) {
-				matches = append(matches, ctx)
-			}
-		}
-		if len(matches) == 1 {
-			resolvedOld = matches[0]
-		} else if len(matches) > 1 {
-			fmt.Fprintf(os.Stderr, "%s Ambiguous name '%s', matches:\n", warnStyle.Render("✗"), oldName)
-			for _, m := range matches {
-				fmt.Fprintf(os.Stderr, "  %s\n", m)
-			}
-			os.Exit(1)
-		} else {
-			fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), oldName)
-			os.Exit(1)
-		}
+	// Resolve old name (exact or suffix/substring) without touching the kubeconfig
+	matches, err := resolveContexts(oldName, contexts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), oldName)
+		os.Exit(exitNotFound)
 	}
-	// Switch back to current after the test switch above
-	if cur := getCurrentContext(); cur != resolvedOld {
-		_ = switchContext(cur)
+	if len(matches) > 1 {
+		fmt.Fprintf(os.Stderr, "%s Ambiguous name '%s', matches:\n", warnStyle.Render("✗"), oldName)
+		for _, m := range matches {
+			fmt.Fprintf(os.Stderr, "  %s\n", m)
+		}
+		os.Exit(exitAmbiguous)
 	}
+	resolvedOld := matches[0]
 
-	cmd := exec.Command("kubectl", "config", "rename-context", resolvedOld, newName)
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := kubeconfigEdit(resolvedOld, "rename-context", resolvedOld, newName); err != nil {
 		fmt.Fprintf(os.Stderr, "%s Failed to rename: %s\n", warnStyle.Render("✗"), strings.TrimSpace(string(out)))
+		os.Exit(exitKubeconfig)
+	}
+
+	updated := renameContextRefs(&cfg, resolvedOld, newName)
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Update aliases that pointed to old name
+	fmt.Printf("%s Renamed %s → %s\n", successStyle.Render("✔"),
+		dimStyle.Render(resolvedOld), currentValueStyle.Render(newName))
+	if updated > 0 {
+		fmt.Printf("  %s Updated %d alias(es)\n", dimStyle.Render("·"), updated)
+	}
+}
+
+// renameContextRefs points aliases, pins, groups and history at a renamed
+// context. Returns the number of aliases updated.
+func renameContextRefs(cfg *config, oldName, newName string) int {
 	updated := 0
 	for alias, target := range cfg.Aliases {
-		if target == resolvedOld {
+		if target == oldName {
 			cfg.Aliases[alias] = newName
 			updated++
 		}
 	}
+	for alias, targets := range cfg.MultiAliases {
+		for i, t := range targets {
+			if t == oldName {
+				cfg.MultiAliases[alias][i] = newName
+				updated++
+			}
+		}
+	}
+	for i, p := range cfg.Pins {
+		if p == oldName {
+			cfg.Pins[i] = newName
+		}
+	}
+	for _, members := range cfg.Groups {
+		for i, m := range members {
+			if m == oldName {
+				members[i] = newName
+			}
+		}
+	}
 	// Update history
 	for i, h := range cfg.History {
-		if h == resolvedOld {
+		if h == oldName {
 			cfg.History[i] = newName
 		}
 	}
-	if cfg.Previous == resolvedOld {
+	for i, e := range cfg.HistoryLog {
+		if e.Context == oldName {
+			cfg.HistoryLog[i].Context = newName
+		}
+		if e.From == oldName {
+			cfg.HistoryLog[i].From = newName
+		}
+	}
+	if cfg.Previous == oldName {
 		cfg.Previous = newName
 	}
-	if err := saveConfig(cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-		os.Exit(1)
+	for g, ctx := range cfg.GroupDefault {
+		if ctx == oldName {
+			cfg.GroupDefault[g] = newName
+		}
 	}
-
-	fmt.Printf("%s Renamed %s → %s\n", successStyle.Render("✔"),
-		dimStyle.Render(resolvedOld), currentValueStyle.Render(newName))
-	if updated > 0 {
-		fmt.Printf("  %s Updated %d alias(es)\n", dimStyle.Render("·"), updated)
+	if cfg.Home == oldName {
+		cfg.Home = newName
+	}
+	if p, ok := cfg.Provenance[oldName]; ok {
+		delete(cfg.Provenance, oldName)
+		cfg.Provenance[newName] = p
+	}
+	if d, ok := cfg.Expiry[oldName]; ok {
+		delete(cfg.Expiry, oldName)
+		cfg.Expiry[newName] = d
+	}
+	if p, ok := cfg.AWSProfiles[oldName]; ok {
+		delete(cfg.AWSProfiles, oldName)
+		cfg.AWSProfiles[newName] = p
+	}
+	if n, ok := cfg.Notes[oldName]; ok {
+		delete(cfg.Notes, oldName)
+		cfg.Notes[newName] = n
+	}
+	for i, c := range cfg.NotesMuted {
+		if c == oldName {
+			cfg.NotesMuted[i] = newName
+		}
 	}
+	return updated
 }
 
 // ── handleCompletion ───────────────────────────────────
-func handleCompletion() {
+func handleCompletion(cfg config) {
 	shell := "zsh"
 	if len(os.Args) >= 3 {
 		shell = os.Args[2]
 	}
 
+	// "cache" prints names for the scripts without calling kubectl on every TAB
+	if shell == "cache" {
+		handleCompletionCache(cfg)
+		return
+	}
+
 	// If --script flag passed, print the actual completion script (used by source <(...))
 	if len(os.Args) >= 4 && os.Args[3] == "--script" {
 		printCompletionScript(shell)
@@ -1106,8 +2550,16 @@ func handleCompletion() {
 	case "bash":
 		fmt.Println("# Add this line to your ~/.bashrc:")
 		fmt.Println("source <(ksw completion bash --script)")
+	case "nu", "nushell":
+		fmt.Println("# Generate the script once (re-run after upgrading ksw):")
+		fmt.Println("ksw completion nu --script | save -f ($nu.default-config-dir | path join ksw.nu)")
+		fmt.Println("# Then add this line to your config.nu:")
+		fmt.Println("source ksw.nu")
+	case "elvish":
+		fmt.Println("# Add this line to your ~/.config/elvish/rc.elv:")
+		fmt.Println("eval (ksw completion elvish --script | slurp)")
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown shell '%s'. Supported: zsh, bash, install\n", shell)
+		fmt.Fprintf(os.Stderr, "Unknown shell '%s'. Supported: zsh, bash, nu, elvish, install\n", shell)
 		os.Exit(1)
 	}
 }
@@ -1167,133 +2619,6 @@ func installCompletion() {
 	fmt.Printf("  Run: %s\n", searchActiveStyle.Render("source "+rcFile))
 }
 
-func printCompletionScript(shell string) {
-	switch shell {
-	case "zsh":
-		fmt.Print(`_ksw_contexts() {
-  local contexts
-  contexts=($(kubectl config get-contexts -o name 2>/dev/null))
-  _describe 'contexts' contexts
-}
-
-_ksw_aliases() {
-  local aliases
-  aliases=($(ksw alias ls 2>/dev/null | awk '{print $1}' | tr -d '@'))
-  _describe 'aliases' aliases
-}
-
-_ksw_groups() {
-  local groups
-  groups=($(ksw group ls 2>/dev/null | awk '{print $1}'))
-  _describe 'groups' groups
-}
-
-_ksw() {
-  local state
-  _arguments \
-    '1: :->cmd' \
-    '*: :->args' && return
-
-  case $state in
-    cmd)
-      local cmds
-      cmds=(
-        'history:Show recent context history'
-        'group:Manage context groups'
-        'pin:Pin contexts to the top of the list'
-        'alias:Manage aliases'
-        'rename:Rename a context'
-        'completion:Print shell completion setup'
-        '-:Switch to previous context'
-        '-l:List contexts'
-        '-v:Show version'
-        '-h:Show help'
-      )
-      _describe 'commands' cmds
-      _ksw_contexts
-      ;;
-    args)
-      case $words[2] in
-        alias)
-          if [[ ${#words[@]} -eq 3 ]]; then
-            local sub=(ls rm)
-            _describe 'subcommands' sub
-            _ksw_aliases
-          elif [[ ${#words[@]} -eq 4 && $words[3] == rm ]]; then
-            _ksw_aliases
-          fi
-          ;;
-        group)
-          if [[ ${#words[@]} -eq 3 ]]; then
-            local sub=(add rm ls use add-ctx rmi)
-            _describe 'subcommands' sub
-          elif [[ ${#words[@]} -ge 4 ]]; then
-            case $words[3] in
-              use|rm|add-ctx|rmi) _ksw_groups ;;
-            esac
-          fi
-          ;;
-        pin)
-          if [[ ${#words[@]} -eq 3 ]]; then
-            local sub=(ls rm use)
-            _describe 'subcommands' sub
-            _ksw_contexts
-          fi
-          ;;
-        rename)
-          _ksw_contexts ;;
-      esac
-      ;;
-  esac
-}
-
-compdef _ksw ksw
-`)
-	case "bash":
-		fmt.Print(`_ksw_complete() {
-  local cur prev pprev
-  COMPREPLY=()
-  cur="${COMP_WORDS[COMP_CWORD]}"
-  prev="${COMP_WORDS[COMP_CWORD-1]}"
-  pprev="${COMP_WORDS[COMP_CWORD-2]}"
-
-  local contexts
-  contexts=$(kubectl config get-contexts -o name 2>/dev/null | tr '\n' ' ')
-
-  local aliases
-  aliases=$(ksw alias ls 2>/dev/null | awk '{print $1}' | tr -d '@' | tr '\n' ' ')
-
-  local groups
-  groups=$(ksw group ls 2>/dev/null | awk '{print $1}' | tr '\n' ' ')
-
-  if [[ $COMP_CWORD -eq 1 ]]; then
-    local cmds="history group pin alias rename completion - -l -v -h"
-    COMPREPLY=( $(compgen -W "$cmds $contexts" -- "$cur") )
-    return
-  fi
-
-  case "$prev" in
-    group)  COMPREPLY=( $(compgen -W "add rm ls use add-ctx rmi" -- "$cur") ) ;;
-    pin)    COMPREPLY=( $(compgen -W "ls rm use $contexts" -- "$cur") ) ;;
-    alias)  COMPREPLY=( $(compgen -W "ls rm $aliases" -- "$cur") ) ;;
-    use)    [[ "$pprev" == "group" ]] && COMPREPLY=( $(compgen -W "$groups" -- "$cur") ) ;;
-    rm)
-      case "$pprev" in
-        alias) COMPREPLY=( $(compgen -W "$aliases" -- "$cur") ) ;;
-        group) COMPREPLY=( $(compgen -W "$groups" -- "$cur") ) ;;
-        pin)   COMPREPLY=( $(compgen -W "$contexts" -- "$cur") ) ;;
-      esac
-      ;;
-    rename|add-ctx|rmi) COMPREPLY=( $(compgen -W "$contexts" -- "$cur") ) ;;
-    *)      COMPREPLY=( $(compgen -W "$contexts" -- "$cur") ) ;;
-  esac
-}
-
-complete -F _ksw_complete ksw
-`)
-	}
-}
-
 // ── handlePin ──────────────────────────────────────────
 func handlePin(cfg config) {
 	if len(os.Args) < 3 {
@@ -1328,33 +2653,47 @@ func handlePin(cfg config) {
 		}
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			fatal(err)
 		}
 		current := getCurrentContext()
 		m := initialModel(contexts, current, cfg, "", true)
-		p := tea.NewProgram(m, tea.WithAltScreen())
-		result, err := p.Run()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
+		runTUI(m, current)
+
+	case "mv", "move":
+		// ksw pin mv <name> <position>
+		if len(os.Args) < 5 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw pin mv <name> <position>")
 			os.Exit(1)
 		}
-		final := result.(model)
-		if final.chosen != "" && final.chosen != current {
-			recordHistory(&final.cfg, current, final.chosen)
-			if err := switchContext(final.chosen); err != nil {
-				fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", final.chosen, err)
-				os.Exit(1)
-			}
-			_ = saveConfig(final.cfg)
-			alias := final.aliasFor(final.chosen)
-			extra := ""
-			if alias != "" {
-				extra = " " + aliasStyle.Render("@"+alias)
+		matches, _ := resolveContexts(os.Args[3], cfg.Pins)
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "%s '%s' is not pinned.\n", warnStyle.Render("✗"), os.Args[3])
+			os.Exit(1)
+		}
+		if len(matches) > 1 {
+			fmt.Fprintf(os.Stderr, "%s Ambiguous '%s', matches:\n  %s\n", warnStyle.Render("✗"), os.Args[3], strings.Join(matches, "\n  "))
+			os.Exit(exitAmbiguous)
+		}
+		pos, err := strconv.Atoi(os.Args[4])
+		if err != nil || pos < 1 || pos > len(cfg.Pins) {
+			fmt.Fprintf(os.Stderr, "%s Position must be between 1 and %d\n", warnStyle.Render("✗"), len(cfg.Pins))
+			os.Exit(1)
+		}
+		from := 0
+		for i, p := range cfg.Pins {
+			if p == matches[0] {
+				from = i
+				break
 			}
-			fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), final.chosen, extra)
-		} else if final.chosen == current {
-			fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
+		}
+		movePin(&cfg, from, pos-1)
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Moved %s to position %d\n", successStyle.Render("✔"), pinItemStyle.Render(matches[0]), pos)
+		for i, p := range cfg.Pins {
+			fmt.Printf("  %d  %s %s\n", i+1, pinTag, pinItemStyle.Render(p))
 		}
 
 	case "rm", "remove", "unpin":
@@ -1371,20 +2710,11 @@ func handlePin(cfg config) {
 				break
 			}
 		}
-		found := false
-		newPins := cfg.Pins[:0]
-		for _, p := range cfg.Pins {
-			if p == resolved {
-				found = true
-			} else {
-				newPins = append(newPins, p)
-			}
-		}
-		if !found {
+		if !slices.Contains(cfg.Pins, resolved) {
 			fmt.Fprintf(os.Stderr, "%s '%s' is not pinned.\n", warnStyle.Render("✗"), name)
 			os.Exit(1)
 		}
-		cfg.Pins = newPins
+		trashPin(&cfg, resolved, "")
 		if err := saveConfig(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
 			os.Exit(1)
@@ -1397,8 +2727,12 @@ func handlePin(cfg config) {
 		// Resolve full context name (exact or suffix/substring)
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			fatal(err)
+		}
+		// Glob patterns and @group pin every match at once
+		if strings.HasPrefix(name, "@") || strings.ContainsAny(name, "*?") {
+			pinMany(cfg, name, contexts)
+			return
 		}
 		resolved := name
 		// Check exact match first
@@ -1423,10 +2757,10 @@ func handlePin(cfg config) {
 				for _, m := range matches {
 					fmt.Fprintf(os.Stderr, "  %s\n", m)
 				}
-				os.Exit(1)
+				os.Exit(exitAmbiguous)
 			} else {
 				fmt.Fprintf(os.Stderr, "%s Context '%s' not found.\n", warnStyle.Render("✗"), name)
-				os.Exit(1)
+				os.Exit(exitNotFound)
 			}
 		}
 		// Check already pinned
@@ -1445,6 +2779,56 @@ func handlePin(cfg config) {
 	}
 }
 
+// pinMany pins every context matching a glob pattern or @group reference
+func pinMany(cfg config, target string, contexts []string) {
+	var matches []string
+	if strings.HasPrefix(target, "@") {
+		groupName := target[1:]
+		members, ok := cfg.Groups[groupName]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
+			os.Exit(exitNotFound)
+		}
+		matches = members
+	} else {
+		var err error
+		matches, err = resolveContexts(target, contexts)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	pinned := make(map[string]bool, len(cfg.Pins))
+	for _, p := range cfg.Pins {
+		pinned[p] = true
+	}
+	var added []string
+	already := 0
+	for _, ctx := range matches {
+		if pinned[ctx] {
+			already++
+			continue
+		}
+		pinned[ctx] = true
+		cfg.Pins = append(cfg.Pins, ctx)
+		added = append(added, ctx)
+	}
+	if len(added) > 0 {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("%s Pinned %d context(s) from %s\n", successStyle.Render("✔"), len(added), aliasStyle.Render(target))
+	for _, ctx := range added {
+		fmt.Printf("  %s %s\n", pinTag, pinItemStyle.Render(ctx))
+	}
+	if already > 0 {
+		fmt.Printf("  %s %d already pinned\n", dimStyle.Render("·"), already)
+	}
+}
+
 // ── handleGroup ────────────────────────────────────────
 
 // globMatch returns true if str matches a simple glob pattern (* and ?)
@@ -1493,6 +2877,12 @@ func matchGlob(pattern, str string) bool {
 // If the pattern contains * or ?, it returns all matching contexts.
 // Otherwise it returns exactly one context (or error).
 func resolveContexts(name string, contexts []string) ([]string, error) {
+	switch matchMode {
+	case "exact":
+		return matchStrict(name, contexts, func(s string) bool { return s == name })
+	case "prefix":
+		return matchStrict(name, contexts, func(s string) bool { return strings.HasPrefix(s, name) })
+	}
 	// Glob pattern
 	if strings.ContainsAny(name, "*?") {
 		var matches []string
@@ -1511,7 +2901,7 @@ func resolveContexts(name string, contexts []string) ([]string, error) {
 			}
 		}
 		if len(matches) == 0 {
-			return nil, fmt.Errorf("no contexts match pattern '%s'", name)
+			return nil, withExitCode(exitNotFound, fmt.Errorf("no contexts match pattern '%s'", name))
 		}
 		return matches, nil
 	}
@@ -1531,7 +2921,28 @@ func resolveContexts(name string, contexts []string) ([]string, error) {
 	if len(matches) >= 1 {
 		return matches, nil
 	}
-	return nil, fmt.Errorf("context '%s' not found", name)
+	return nil, withExitCode(exitNotFound, fmt.Errorf("context '%s' not found", name))
+}
+
+// matchMode is set by the global --exact / --prefix flags so scripts can opt
+// out of glob, suffix and substring matching: "" (default), "exact", "prefix"
+var matchMode string
+
+// matchStrict returns the contexts whose full or short name satisfies ok
+func matchStrict(name string, contexts []string, ok func(string) bool) ([]string, error) {
+	var matches []string
+	for _, ctx := range contexts {
+		if ctx == name {
+			return []string{ctx}, nil
+		}
+		if ok(ctx) || ok(shortName(ctx)) {
+			matches = append(matches, ctx)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, withExitCode(exitNotFound, fmt.Errorf("context '%s' not found (--%s)", name, matchMode))
+	}
+	return matches, nil
 }
 
 func resolveContext(name string, contexts []string) (string, error) {
@@ -1540,7 +2951,7 @@ func resolveContext(name string, contexts []string) (string, error) {
 		return "", err
 	}
 	if len(results) > 1 {
-		return "", fmt.Errorf("ambiguous '%s', matches:\n  %s", name, strings.Join(results, "\n  "))
+		return "", withExitCode(exitAmbiguous, fmt.Errorf("ambiguous '%s', matches:\n  %s", name, strings.Join(results, "\n  ")))
 	}
 	return results[0], nil
 }
@@ -1579,7 +2990,11 @@ func handleGroup(cfg config) {
 		for _, n := range names {
 			fmt.Printf("  %s %s\n", aliasStyle.Render(n), dimStyle.Render(fmt.Sprintf("(%d contexts)", len(cfg.Groups[n]))))
 			for _, ctx := range cfg.Groups[n] {
-				fmt.Printf("      %s %s\n", dimStyle.Render("·"), normalItemStyle.Render(ctx))
+				mark := ""
+				if cfg.GroupDefault[n] == ctx {
+					mark = " " + dimStyle.Render("(default)")
+				}
+				fmt.Printf("      %s %s%s\n", dimStyle.Render("·"), normalItemStyle.Render(ctx), mark)
 			}
 		}
 
@@ -1592,16 +3007,14 @@ func handleGroup(cfg config) {
 		groupName := os.Args[3]
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			fatal(err)
 		}
 		// Resolve any provided contexts (supports glob patterns like eks-sufi*)
 		var resolved []string
 		for _, arg := range os.Args[4:] {
 			ctxs, err := resolveContexts(arg, contexts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
-				os.Exit(1)
+				fatal(err)
 			}
 			for _, ctx := range ctxs {
 				// Avoid duplicates
@@ -1660,7 +3073,7 @@ func handleGroup(cfg config) {
 				fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
 				continue
 			}
-			delete(cfg.Groups, groupName)
+			trashGroup(&cfg, groupName, "")
 			fmt.Printf("%s Removed group %s\n", successStyle.Render("✔"), aliasStyle.Render(groupName))
 		}
 		if err := saveConfig(cfg); err != nil {
@@ -1668,6 +3081,9 @@ func handleGroup(cfg config) {
 			os.Exit(1)
 		}
 
+	case "auto":
+		handleGroupAuto(cfg)
+
 	case "add-ctx":
 		// ksw group add-ctx <group> <ctx>
 		if len(os.Args) < 5 {
@@ -1677,17 +3093,15 @@ func handleGroup(cfg config) {
 		groupName := os.Args[3]
 		if _, ok := cfg.Groups[groupName]; !ok {
 			fmt.Fprintf(os.Stderr, "%s Group '%s' not found. Create it first with: ksw group add %s\n", warnStyle.Render("✗"), groupName, groupName)
-			os.Exit(1)
+			os.Exit(exitNotFound)
 		}
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			fatal(err)
 		}
 		ctx, err := resolveContext(os.Args[4], contexts)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
-			os.Exit(1)
+			fatal(err)
 		}
 		for _, c := range cfg.Groups[groupName] {
 			if c == ctx {
@@ -1711,7 +3125,7 @@ func handleGroup(cfg config) {
 		groupName := os.Args[3]
 		if _, ok := cfg.Groups[groupName]; !ok {
 			fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
-			os.Exit(1)
+			os.Exit(exitNotFound)
 		}
 		// Build set of members to remove (supports substring and glob)
 		toRemove := make(map[string]bool)
@@ -1749,7 +3163,7 @@ func handleGroup(cfg config) {
 			}
 		}
 		if len(toRemove) == 0 {
-			os.Exit(1)
+			os.Exit(exitNotFound)
 		}
 		var newMembers []string
 		for _, c := range cfg.Groups[groupName] {
@@ -1767,61 +3181,267 @@ func handleGroup(cfg config) {
 		}
 
 	case "use":
-		// ksw group use <name> — open TUI filtered to group
+		// ksw group use <name> [--default] — open TUI filtered to group, or switch to its default
 		if len(os.Args) < 4 {
-			fmt.Fprintln(os.Stderr, "Usage: ksw group use <name>")
+			fmt.Fprintln(os.Stderr, "Usage: ksw group use <name> [--default]")
 			os.Exit(1)
 		}
 		groupName := os.Args[3]
 		members, ok := cfg.Groups[groupName]
 		if !ok {
 			fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
-			os.Exit(1)
+			os.Exit(exitNotFound)
 		}
 		if len(members) == 0 {
 			fmt.Fprintf(os.Stderr, "%s Group '%s' is empty.\n", warnStyle.Render("✗"), groupName)
 			os.Exit(1)
 		}
+		if len(os.Args) >= 5 && os.Args[4] == "--default" {
+			target, ok := cfg.GroupDefault[groupName]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "%s Group '%s' has no default. Set one with: ksw group default %s <ctx>\n", warnStyle.Render("✗"), groupName, groupName)
+				os.Exit(exitNotFound)
+			}
+			switchTo(cfg, target, aliasStyle.Render("@"+groupName))
+			return
+		}
 		contexts, err := getContexts()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			fatal(err)
 		}
 		current := getCurrentContext()
 		m := initialModel(contexts, current, cfg, groupName, false)
-		p := tea.NewProgram(m, tea.WithAltScreen())
-		result, err := p.Run()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
+		runTUI(m, current)
+
+	case "color":
+		handleGroupColor(cfg)
+
+	case "default":
+		// ksw group default <name> [ctx] — show or set the group's primary context
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw group default <name> [ctx]")
 			os.Exit(1)
 		}
-		final := result.(model)
-		if final.chosen != "" && final.chosen != current {
-			recordHistory(&final.cfg, current, final.chosen)
-			if err := switchContext(final.chosen); err != nil {
-				fmt.Fprintf(os.Stderr, "Error switching to %s: %v\n", final.chosen, err)
-				os.Exit(1)
+		groupName := os.Args[3]
+		members, ok := cfg.Groups[groupName]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
+			os.Exit(exitNotFound)
+		}
+		if len(os.Args) < 5 {
+			if d, ok := cfg.GroupDefault[groupName]; ok {
+				fmt.Println(d)
+			} else {
+				fmt.Println(dimStyle.Render("No default for group " + groupName))
 			}
-			_ = saveConfig(final.cfg)
-			alias := final.aliasFor(final.chosen)
-			extra := ""
-			if alias != "" {
-				extra = " " + aliasStyle.Render("@"+alias)
+			return
+		}
+		ctx, err := resolveContext(os.Args[4], members)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s '%s' is not a member of group %s.\n", warnStyle.Render("✗"), os.Args[4], groupName)
+			os.Exit(exitCode(err))
+		}
+		if cfg.GroupDefault == nil {
+			cfg.GroupDefault = make(map[string]string)
+		}
+		cfg.GroupDefault[groupName] = ctx
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Default for group %s: %s\n", successStyle.Render("✔"), aliasStyle.Render(groupName), ctx)
+
+	case "export":
+		// ksw group export <name> [-o file] — kubeconfig with only the group's contexts
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw group export <name> [-o file]")
+			os.Exit(1)
+		}
+		groupName := os.Args[3]
+		outFile := ""
+		for i := 4; i < len(os.Args); i++ {
+			if (os.Args[i] == "-o" || os.Args[i] == "--output") && i+1 < len(os.Args) {
+				outFile = os.Args[i+1]
+				i++
 			}
-			fmt.Printf("%s Switched to %s%s\n", successStyle.Render("✔"), final.chosen, extra)
-		} else if final.chosen == current {
-			fmt.Printf("%s Already on %s\n", dimStyle.Render("·"), current)
 		}
+		members, ok := cfg.Groups[groupName]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s Group '%s' not found.\n", warnStyle.Render("✗"), groupName)
+			os.Exit(exitNotFound)
+		}
+		if len(members) == 0 {
+			fmt.Fprintf(os.Stderr, "%s Group '%s' is empty.\n", warnStyle.Render("✗"), groupName)
+			os.Exit(1)
+		}
+		data, err := subsetKubeconfig(members)
+		if err != nil {
+			fatal(err)
+		}
+		if outFile == "" || outFile == "-" {
+			os.Stdout.Write(data)
+			return
+		}
+		// The file embeds credentials — keep it private
+		if err := os.WriteFile(outFile, data, 0600); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s Exported group %s (%d contexts) to %s\n", successStyle.Render("✔"), aliasStyle.Render(groupName), len(members), outFile)
 
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown group subcommand '%s'.\nUsage: ksw group <add|rm|ls|use|add-ctx|rmi>\n", sub)
+		fmt.Fprintf(os.Stderr, "Unknown group subcommand '%s'.\nUsage: ksw group <add|rm|ls|use|default|color|add-ctx|rmi|auto|export>\n", sub)
 		os.Exit(1)
 	}
 }
 
+// ── group auto ─────────────────────────────────────────
+
+// envSuffixes are recognised environment markers at the end of a cluster name
+var envSuffixes = map[string]bool{
+	"dev": true, "qa": true, "test": true, "uat": true, "stg": true, "staging": true,
+	"pdn": true, "prod": true, "prd": true, "sandbox": true, "sbx": true,
+}
+
+// proposeGroups derives candidate groups from context naming conventions:
+// provider prefix, AWS account / GCP project, region and environment suffix.
+func proposeGroups(contexts []string) map[string][]string {
+	proposals := make(map[string][]string)
+	add := func(group, ctx string) {
+		proposals[group] = append(proposals[group], ctx)
+	}
+	for _, ctx := range contexts {
+		switch {
+		case strings.HasPrefix(ctx, "arn:aws:eks:"):
+			// arn:aws:eks:<region>:<account>:cluster/<name>
+			parts := strings.Split(ctx, ":")
+			add("eks", ctx)
+			if len(parts) >= 5 {
+				add(parts[3], ctx)
+				add("aws-"+parts[4], ctx)
+			}
+		case strings.HasPrefix(ctx, "gke_"):
+			// gke_<project>_<zone>_<name>
+			parts := strings.Split(ctx, "_")
+			add("gke", ctx)
+			if len(parts) >= 4 {
+				add("gcp-"+parts[1], ctx)
+				add(parts[2], ctx)
+			}
+		case strings.HasPrefix(ctx, "kind-"), strings.HasPrefix(ctx, "k3d-"), ctx == "minikube", ctx == "docker-desktop":
+			add("local", ctx)
+		}
+		short := shortName(ctx)
+		if idx := strings.LastIndexAny(short, "-_"); idx >= 0 {
+			if env := strings.ToLower(short[idx+1:]); envSuffixes[env] {
+				add(env, ctx)
+			}
+		}
+	}
+	// A group of one isn't worth proposing
+	for name, members := range proposals {
+		if len(members) < 2 {
+			delete(proposals, name)
+		}
+	}
+	return proposals
+}
+
+// handleGroupAuto shows proposed groups and creates the accepted ones.
+// Pass --yes to accept all proposals without prompting.
+func handleGroupAuto(cfg config) {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	proposals := proposeGroups(contexts)
+	if len(proposals) == 0 {
+		fmt.Println(dimStyle.Render("No groups could be derived from context names."))
+		return
+	}
+	names := make([]string, 0, len(proposals))
+	for n := range proposals {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	fmt.Println(dimStyle.Render("  Proposed groups:"))
+	for i, n := range names {
+		note := ""
+		if _, ok := cfg.Groups[n]; ok {
+			note = " " + dimStyle.Render("(exists, will merge)")
+		}
+		fmt.Printf("  %2d  %s %s%s\n", i+1, aliasStyle.Render(n), dimStyle.Render(fmt.Sprintf("(%d contexts)", len(proposals[n]))), note)
+	}
+
+	accept := make(map[string]bool)
+	yes := len(os.Args) >= 4 && (os.Args[3] == "--yes" || os.Args[3] == "-y")
+	for _, i := range promptSelection("Create", len(names), yes) {
+		accept[names[i]] = true
+	}
+	if len(accept) == 0 {
+		fmt.Println(dimStyle.Render("  No groups created."))
+		return
+	}
+
+	for _, n := range names {
+		if !accept[n] {
+			continue
+		}
+		existing := cfg.Groups[n]
+		existingSet := make(map[string]bool, len(existing))
+		for _, c := range existing {
+			existingSet[c] = true
+		}
+		added := 0
+		for _, ctx := range proposals[n] {
+			if !existingSet[ctx] {
+				existing = append(existing, ctx)
+				added++
+			}
+		}
+		cfg.Groups[n] = existing
+		fmt.Printf("%s Group %s — added %d context(s)\n", successStyle.Render("✔"), aliasStyle.Render(n), added)
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// promptSelection asks which of n numbered items to act on and returns their
+// zero-based indices. With all set it selects everything without asking.
+func promptSelection(verb string, n int, all bool) []int {
+	var picked []int
+	if all {
+		for i := 0; i < n; i++ {
+			picked = append(picked, i)
+		}
+		return picked
+	}
+	fmt.Printf("\n  %s [a]ll, [n]one, or numbers (e.g. 1,3): ", verb)
+	var pick string
+	fmt.Scanln(&pick)
+	pick = strings.ToLower(strings.TrimSpace(pick))
+	switch pick {
+	case "a", "all":
+		return promptSelection(verb, n, true)
+	case "", "n", "none":
+		return nil
+	}
+	for _, field := range strings.Split(pick, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || i < 1 || i > n {
+			fmt.Fprintf(os.Stderr, "%s Invalid selection '%s'\n", warnStyle.Render("✗"), field)
+			os.Exit(1)
+		}
+		picked = append(picked, i-1)
+	}
+	return picked
+}
+
 func handleAlias(cfg config) {
 	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "Usage: ksw alias <ls|rm|name> [context]")
+		fmt.Fprintln(os.Stderr, "Usage: ksw alias <ls|rm|name> [context...]")
 		os.Exit(1)
 	}
 
@@ -1829,51 +3449,85 @@ func handleAlias(cfg config) {
 
 	switch sub {
 	case "ls", "list":
-		if len(cfg.Aliases) == 0 {
+		if len(cfg.Aliases) == 0 && len(cfg.MultiAliases) == 0 {
 			fmt.Println(dimStyle.Render("No aliases configured. Use: ksw alias <name> <context>"))
 			return
 		}
 		// Sort aliases for consistent output
-		names := make([]string, 0, len(cfg.Aliases))
+		names := make([]string, 0, len(cfg.Aliases)+len(cfg.MultiAliases))
 		for name := range cfg.Aliases {
 			names = append(names, name)
 		}
+		for name := range cfg.MultiAliases {
+			names = append(names, name)
+		}
 		sort.Strings(names)
 		for _, name := range names {
+			if targets, ok := cfg.MultiAliases[name]; ok {
+				fmt.Printf("  %s → %s\n", aliasStyle.Render("@"+name), strings.Join(targets, ", "))
+				continue
+			}
 			fmt.Printf("  %s → %s\n", aliasStyle.Render("@"+name), cfg.Aliases[name])
 		}
 
+	case "check":
+		handleAliasCheck(cfg)
+
 	case "rm", "remove", "delete":
 		if len(os.Args) < 4 {
 			fmt.Fprintln(os.Stderr, "Usage: ksw alias rm <name>")
 			os.Exit(1)
 		}
 		name := os.Args[3]
-		if _, ok := cfg.Aliases[name]; !ok {
+		_, single := cfg.Aliases[name]
+		_, multi := cfg.MultiAliases[name]
+		if !single && !multi {
 			fmt.Fprintf(os.Stderr, "%s Alias '%s' not found.\n", warnStyle.Render("✗"), name)
-			os.Exit(1)
+			os.Exit(exitNotFound)
 		}
-		delete(cfg.Aliases, name)
+		trashAlias(&cfg, name, "")
 		if err := saveConfig(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("%s Removed alias %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+name))
+		fmt.Printf("%s Removed alias %s %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+name), dimStyle.Render("(ksw trash restore "+name+" to undo)"))
 
 	default:
-		// ksw alias <name> <context>
+		// ksw alias <name> <context> [context2 ...]
 		name := sub
 		if len(os.Args) < 4 {
 			// Show what this alias points to
 			if target, ok := cfg.Aliases[name]; ok {
 				fmt.Printf("  %s → %s\n", aliasStyle.Render("@"+name), target)
+			} else if targets, ok := cfg.MultiAliases[name]; ok {
+				fmt.Printf("  %s → %s\n", aliasStyle.Render("@"+name), strings.Join(targets, ", "))
 			} else {
-				fmt.Fprintf(os.Stderr, "Usage: ksw alias <name> <context>\n")
+				fmt.Fprintf(os.Stderr, "Usage: ksw alias <name> <context> [context2 ...]\n")
 				os.Exit(1)
 			}
 			return
 		}
+		if len(os.Args) > 4 {
+			// Multiple targets: ksw @name opens a picker of just these
+			targets := os.Args[3:]
+			for _, t := range targets {
+				warnMissingTarget(t)
+			}
+			delete(cfg.Aliases, name)
+			cfg.MultiAliases[name] = targets
+			if err := saveConfig(cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s Alias %s → %d contexts\n", successStyle.Render("✔"), aliasStyle.Render("@"+name), len(targets))
+			for _, t := range targets {
+				fmt.Printf("  %s %s\n", dimStyle.Render("·"), t)
+			}
+			return
+		}
 		context := os.Args[3]
+		warnMissingTarget(context)
+		delete(cfg.MultiAliases, name)
 		cfg.Aliases[name] = context
 		if err := saveConfig(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
@@ -1882,3 +3536,399 @@ func handleAlias(cfg config) {
 		fmt.Printf("%s Alias %s → %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+name), context)
 	}
 }
+
+// warnMissingTarget prints a warning if an alias target doesn't resolve to any context
+func warnMissingTarget(target string) {
+	contexts, err := getContexts()
+	if err != nil {
+		return
+	}
+	if _, err := resolveContexts(target, contexts); err != nil {
+		fmt.Fprintf(os.Stderr, "%s Context '%s' not found in kubeconfig (alias saved anyway).\n", warnStyle.Render("!"), target)
+	}
+}
+
+// ── handleAliasCheck ───────────────────────────────────
+
+// handleAliasCheck verifies every alias target still resolves to a context
+// and offers to retarget or delete the ones that don't.
+func handleAliasCheck(cfg config) {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+
+	names := make([]string, 0, len(cfg.Aliases)+len(cfg.MultiAliases))
+	for name := range cfg.Aliases {
+		names = append(names, name)
+	}
+	for name := range cfg.MultiAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		fmt.Println(dimStyle.Render("No aliases configured. Use: ksw alias <name> <context>"))
+		return
+	}
+
+	orphans := 0
+	changed := false
+	for _, name := range names {
+		targets := cfg.MultiAliases[name]
+		if t, ok := cfg.Aliases[name]; ok {
+			targets = []string{t}
+		}
+		var kept []string
+		for _, target := range targets {
+			matches, _ := resolveContexts(target, contexts)
+			switch {
+			case len(matches) == 1:
+				fmt.Printf("  %s %s → %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+name), matches[0])
+				kept = append(kept, target)
+				continue
+			case len(matches) > 1:
+				fmt.Printf("  %s %s → %s %s\n", warnStyle.Render("?"), aliasStyle.Render("@"+name), target,
+					dimStyle.Render(fmt.Sprintf("(ambiguous, %d matches)", len(matches))))
+				kept = append(kept, target)
+				continue
+			}
+
+			orphans++
+			fmt.Printf("  %s %s → %s %s\n", warnStyle.Render("✗"), aliasStyle.Render("@"+name), target, dimStyle.Render("(orphan)"))
+			fmt.Printf("    [r]etarget · [d]elete · [s]kip: ")
+			var pick string
+			fmt.Scanln(&pick)
+			switch strings.ToLower(strings.TrimSpace(pick)) {
+			case "r", "retarget":
+				fmt.Printf("    New context: ")
+				var newTarget string
+				fmt.Scanln(&newTarget)
+				resolved, err := resolveContext(strings.TrimSpace(newTarget), contexts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "    %s %v\n", warnStyle.Render("✗"), err)
+					kept = append(kept, target)
+					continue
+				}
+				fmt.Printf("    %s %s → %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+name), resolved)
+				kept = append(kept, resolved)
+				changed = true
+			case "d", "delete":
+				fmt.Printf("    %s Removed %s\n", successStyle.Render("✔"), target)
+				changed = true
+			default:
+				kept = append(kept, target)
+			}
+		}
+
+		if len(kept) == 0 {
+			trashAlias(&cfg, name, "")
+		} else if _, ok := cfg.Aliases[name]; ok {
+			cfg.Aliases[name] = kept[0]
+		} else {
+			cfg.MultiAliases[name] = kept
+		}
+	}
+
+	if changed {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println()
+	if orphans == 0 {
+		fmt.Printf("%s All %d alias(es) resolve\n", successStyle.Render("✔"), len(names))
+	} else {
+		fmt.Printf("%s %d orphaned target(s) found\n", warnStyle.Render("✗"), orphans)
+	}
+}
+
+// ── handleWhich ────────────────────────────────────────
+
+// handleWhich shows how a query would resolve (and via which rule) without switching
+func handleWhich(cfg config) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw which <name|@alias>")
+		os.Exit(1)
+	}
+	query := os.Args[2]
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+
+	row := func(label, value string) {
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render(fmt.Sprintf("%-8s", label)), value)
+	}
+	row("query", query)
+
+	target := query
+	if strings.HasPrefix(query, "@") {
+		aliasName := query[1:]
+		if targets, ok := cfg.MultiAliases[aliasName]; ok {
+			row("rule", aliasStyle.Render("alias")+dimStyle.Render(" (multi-target, opens picker)"))
+			for _, t := range targets {
+				ctx, err := resolveContext(t, contexts)
+				if err != nil {
+					row("target", t+" "+warnStyle.Render("✗ not found"))
+					continue
+				}
+				row("target", ctx)
+			}
+			return
+		}
+		t, ok := cfg.Aliases[aliasName]
+		if !ok {
+			row("rule", warnStyle.Render("✗ alias not found"))
+			os.Exit(1)
+		}
+		row("alias", aliasStyle.Render(query)+" → "+t)
+		target = t
+	}
+
+	rule, matches := whichRule(target, contexts)
+	switch {
+	case len(matches) == 1:
+		row("rule", rule)
+		row("context", currentValueStyle.Render(matches[0]))
+	case len(matches) > 1:
+		row("rule", rule+" "+warnStyle.Render(fmt.Sprintf("(ambiguous, %d matches)", len(matches))))
+		for _, m := range matches {
+			row("", m)
+		}
+		os.Exit(1)
+	default:
+		row("rule", warnStyle.Render("✗ no match"))
+		// Direct switching stops here; show what the TUI's fuzzy search would rank first
+		var results []scored
+		for i, ctx := range contexts {
+			if score := fuzzyMatch(ctx, target); score > 0 {
+				results = append(results, scored{index: i, score: score})
+			}
+		}
+		sort.Slice(results, func(a, b int) bool {
+			return results[a].score > results[b].score
+		})
+		for i, r := range results {
+			if i == 3 {
+				break
+			}
+			row("fuzzy", contexts[r.index]+dimStyle.Render(fmt.Sprintf(" (score %d, TUI only)", r.score)))
+		}
+		os.Exit(1)
+	}
+}
+
+// whichRule resolves name with resolveContexts, as a direct switch does, and
+// names the rule that matched
+func whichRule(name string, contexts []string) (string, []string) {
+	matches, err := resolveContexts(name, contexts)
+	if err != nil {
+		return "", nil
+	}
+	switch {
+	case matchMode != "":
+		return matchMode + " (--" + matchMode + ")", matches
+	case strings.ContainsAny(name, "*?"):
+		if !slices.ContainsFunc(matches, func(ctx string) bool { return globMatch(name, ctx) }) {
+			return "glob *" + name, matches
+		}
+		return "glob", matches
+	case len(matches) == 1 && matches[0] == name:
+		return "exact", matches
+	}
+	suffix := 0
+	for _, ctx := range matches {
+		if strings.HasSuffix(ctx, name) {
+			suffix++
+		}
+	}
+	switch suffix {
+	case len(matches):
+		return "suffix", matches
+	case 0:
+		return "substring", matches
+	}
+	return "suffix/substring", matches
+}
+
+// ── handleHistoryExport ────────────────────────────────
+
+// handleHistoryExport writes the timestamped switch log as CSV or JSON to stdout
+func handleHistoryExport(cfg config) {
+	format := "csv"
+	var since time.Duration
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--format", "-f":
+			if i+1 < len(os.Args) {
+				format = os.Args[i+1]
+				i++
+			}
+		case "--since":
+			if i+1 < len(os.Args) {
+				d, err := parseSince(os.Args[i+1])
+				if err != nil {
+					fatal(err)
+				}
+				since = d
+				i++
+			}
+		}
+	}
+	if format != "csv" && format != "json" {
+		fmt.Fprintf(os.Stderr, "%s Unknown format '%s'. Supported: csv, json\n", warnStyle.Render("✗"), format)
+		os.Exit(1)
+	}
+
+	type exportRow struct {
+		Context    string `json:"context"`
+		From       string `json:"from,omitempty"`
+		SwitchedAt string `json:"switched_at"`
+		Duration   int64  `json:"duration_seconds"`
+	}
+	now := time.Now()
+	var rows []exportRow
+	for i, e := range cfg.HistoryLog {
+		// Duration runs until the next switch, or until now for the latest one
+		end := now.Unix()
+		if i+1 < len(cfg.HistoryLog) {
+			end = cfg.HistoryLog[i+1].Time
+		}
+		at := time.Unix(e.Time, 0)
+		if since > 0 && at.Before(now.Add(-since)) {
+			continue
+		}
+		rows = append(rows, exportRow{
+			Context:    e.Context,
+			From:       e.From,
+			SwitchedAt: at.Format(time.RFC3339),
+			Duration:   end - e.Time,
+		})
+	}
+
+	if format == "json" {
+		if rows == nil {
+			rows = []exportRow{}
+		}
+		data, _ := json.MarshalIndent(rows, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"context", "from", "switched_at", "duration_seconds"})
+	for _, r := range rows {
+		_ = w.Write([]string{r.Context, r.From, r.SwitchedAt, strconv.FormatInt(r.Duration, 10)})
+	}
+	w.Flush()
+}
+
+// parseSince parses durations like "30d", "12h" or "90m"
+func parseSince(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration '%s' (use e.g. 30d, 12h, 90m)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s' (use e.g. 30d, 12h, 90m)", s)
+	}
+	return d, nil
+}
+
+// ── handleArchive ──────────────────────────────────────
+
+// handleArchive hides or restores contexts without touching kubeconfig
+func handleArchive(cfg config) {
+	unarchive := os.Args[1] == "unarchive"
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: ksw %s <name>\n", os.Args[1])
+		os.Exit(1)
+	}
+
+	if !unarchive && (os.Args[2] == "ls" || os.Args[2] == "list") {
+		// --names prints bare names (used by shell completion)
+		names := len(os.Args) >= 4 && os.Args[3] == "--names"
+		if len(cfg.Archived) == 0 && !names {
+			fmt.Println(dimStyle.Render("No archived contexts. Use: ksw archive <name>"))
+			return
+		}
+		for _, a := range cfg.Archived {
+			if names {
+				fmt.Println(a)
+			} else {
+				fmt.Printf("  %s %s\n", dimStyle.Render("▪"), normalItemStyle.Render(a))
+			}
+		}
+		return
+	}
+
+	if unarchive {
+		name := os.Args[2]
+		matches, _ := resolveContexts(name, cfg.Archived)
+		if len(matches) > 1 {
+			fmt.Fprintf(os.Stderr, "%s Ambiguous '%s', matches:\n  %s\n", warnStyle.Render("✗"), name, strings.Join(matches, "\n  "))
+			os.Exit(exitAmbiguous)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "%s '%s' is not archived.\n", warnStyle.Render("✗"), name)
+			os.Exit(1)
+		}
+		var kept []string
+		for _, a := range cfg.Archived {
+			if a != matches[0] {
+				kept = append(kept, a)
+			}
+		}
+		cfg.Archived = kept
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Unarchived %s\n", successStyle.Render("✔"), matches[0])
+		return
+	}
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	archived := 0
+	for _, arg := range os.Args[2:] {
+		ctxs, err := resolveContexts(arg, contexts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+			continue
+		}
+		if len(ctxs) > 1 && !strings.ContainsAny(arg, "*?") {
+			fmt.Fprintf(os.Stderr, "%s Ambiguous '%s', matches:\n  %s\n", warnStyle.Render("✗"), arg, strings.Join(ctxs, "\n  "))
+			continue
+		}
+		for _, ctx := range ctxs {
+			already := false
+			for _, a := range cfg.Archived {
+				if a == ctx {
+					already = true
+					break
+				}
+			}
+			if already {
+				fmt.Printf("%s Already archived: %s\n", dimStyle.Render("·"), ctx)
+				continue
+			}
+			cfg.Archived = append(cfg.Archived, ctx)
+			archived++
+			fmt.Printf("%s Archived %s\n", successStyle.Render("✔"), ctx)
+		}
+	}
+	if archived == 0 {
+		return
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
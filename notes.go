@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── Context notes ──────────────────────────────────────
+
+// showNote is run after a switch: the context's note, unless it was muted
+func showNote(cfg config, ctx string) {
+	note := cfg.Notes[ctx]
+	if note == "" || slices.Contains(cfg.NotesMuted, ctx) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s\n", warnStyle.Render("⚠"), note)
+}
+
+// handleNote manages the reminders printed after switching to a context:
+// ksw note <ctx> "<text>" | ksw note <ctx> | ksw note ls | ksw note rm|mute|unmute <ctx>
+func handleNote(cfg config) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw note <ctx> \"<text>\" | <ctx> | ls | rm <ctx> | mute <ctx> | unmute <ctx>")
+		os.Exit(1)
+	}
+	save := func() {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// resolve finds the context a note belongs to; names already in the
+	// notes don't need the kubeconfig, so notes of deleted contexts can go
+	resolve := func(name string) string {
+		if _, ok := cfg.Notes[name]; ok {
+			return name
+		}
+		contexts, err := getContexts()
+		if err != nil {
+			fatal(err)
+		}
+		ctx, err := resolveContext(name, contexts)
+		if err != nil {
+			fatal(err)
+		}
+		return ctx
+	}
+
+	switch sub := os.Args[2]; sub {
+	case "ls", "list":
+		if len(cfg.Notes) == 0 {
+			fmt.Println(dimStyle.Render("No notes. Use: ksw note <ctx> \"<text>\""))
+			return
+		}
+		names := make([]string, 0, len(cfg.Notes))
+		for ctx := range cfg.Notes {
+			names = append(names, ctx)
+		}
+		sort.Strings(names)
+		for _, ctx := range names {
+			muted := ""
+			if slices.Contains(cfg.NotesMuted, ctx) {
+				muted = " " + dimStyle.Render("(muted)")
+			}
+			fmt.Printf("  %s%s\n    %s\n", ctx, muted, cfg.Notes[ctx])
+		}
+
+	case "rm", "remove", "mute", "unmute":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: ksw note %s <ctx>\n", sub)
+			os.Exit(1)
+		}
+		ctx := resolve(os.Args[3])
+		if _, ok := cfg.Notes[ctx]; !ok {
+			fmt.Fprintf(os.Stderr, "%s %s has no note.\n", warnStyle.Render("✗"), ctx)
+			os.Exit(exitNotFound)
+		}
+		cfg.NotesMuted = slices.DeleteFunc(cfg.NotesMuted, func(c string) bool { return c == ctx })
+		switch sub {
+		case "mute":
+			cfg.NotesMuted = append(cfg.NotesMuted, ctx)
+			save()
+			fmt.Printf("%s %s's note won't show on switch %s\n", successStyle.Render("✔"), shortName(ctx), dimStyle.Render("(ksw note unmute to bring it back)"))
+		case "unmute":
+			save()
+			fmt.Printf("%s %s's note shows on switch again\n", successStyle.Render("✔"), shortName(ctx))
+		default:
+			delete(cfg.Notes, ctx)
+			save()
+			fmt.Printf("%s Removed the note on %s\n", successStyle.Render("✔"), ctx)
+		}
+
+	default:
+		ctx := resolve(sub)
+		if len(os.Args) < 4 {
+			// ksw note <ctx>
+			if note := cfg.Notes[ctx]; note != "" {
+				fmt.Println(note)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s %s has no note.\n", warnStyle.Render("✗"), ctx)
+			os.Exit(exitNotFound)
+		}
+		note := strings.TrimSpace(strings.Join(os.Args[3:], " "))
+		if note == "" {
+			fmt.Fprintln(os.Stderr, "Usage: ksw note <ctx> \"<text>\"")
+			os.Exit(1)
+		}
+		if cfg.Notes == nil {
+			cfg.Notes = make(map[string]string)
+		}
+		cfg.Notes[ctx] = note
+		save()
+		fmt.Printf("%s Note on %s: %s\n", successStyle.Render("✔"), ctx, note)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Team policy (policy_url) ───────────────────────────
+
+// policyCacheTTL is how long a fetched policy is used before fetching it again
+const policyCacheTTL = 15 * time.Minute
+
+// teamPolicy is the guardrails a platform team publishes at policy_url. They
+// add to the user's own settings, which can't loosen them.
+type teamPolicy struct {
+	Protected   []string       `json:"protected,omitempty"`    // globs protected on top of the user's own
+	Freeze      []freezeWindow `json:"freeze,omitempty"`       // windows on top of freeze.windows
+	AIForbidden []string       `json:"ai_forbidden,omitempty"` // AI actions refused: "switch", "rename", "delete", "group"...
+}
+
+var (
+	policyMu     sync.Mutex
+	policyLoaded = map[string]teamPolicy{} // policy_url → its policy, once per run
+)
+
+// loadedPolicy returns the policy at cfg's policy_url, fetched at most once
+// per run and from the cache while it's fresh. A policy that can't be read
+// is reported and treated as empty.
+func loadedPolicy(cfg config) teamPolicy {
+	return loadPolicy(cfg, false)
+}
+
+// loadPolicy is loadedPolicy; force fetches the policy even when the cached
+// copy is fresh, which stays the fallback if that fails
+func loadPolicy(cfg config, force bool) teamPolicy {
+	if cfg.PolicyURL == "" {
+		return teamPolicy{}
+	}
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	if p, ok := policyLoaded[cfg.PolicyURL]; ok && !force {
+		return p
+	}
+	var p teamPolicy
+	data, err := cachedFetch("policy", cfg.PolicyURL, policyCacheTTL, force)
+	if err == nil {
+		if err = json.Unmarshal(data, &p); err != nil {
+			err = fmt.Errorf("%s isn't a ksw policy: %v", cfg.PolicyURL, err)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", warnStyle.Render("⚠"), warnStyle.Render("policy: "+err.Error()))
+	}
+	policyLoaded[cfg.PolicyURL] = p
+	return p
+}
+
+// aiActionName is what ai_forbidden entries match: the action, or for
+// commands the command ("rename", "alias add", "history")
+func aiActionName(act aiResponse) string {
+	if act.Action != "command" {
+		return act.Action
+	}
+	if strings.HasPrefix(act.Command, "history ") {
+		return "history"
+	}
+	return act.Command
+}
+
+// aiAllowed fails when the team policy forbids the AI action name. A
+// forbidden word covers every command it starts: "group" also forbids
+// "group add" and "group rm".
+func aiAllowed(cfg config, name string) error {
+	for _, f := range loadedPolicy(cfg).AIForbidden {
+		if name == f || strings.HasPrefix(name, f+" ") {
+			return fmt.Errorf("the team policy doesn't allow the AI to %s", name)
+		}
+	}
+	return nil
+}
+
+// ── Cached fetches ─────────────────────────────────────
+
+// fetchCache is the last successful fetch of a URL, and the last failed one
+// since then
+type fetchCache struct {
+	URL     string `json:"url"`
+	Fetched int64  `json:"fetched"`
+	Body    string `json:"body"`
+	Failed  int64  `json:"failed,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// fetchRetryAfter is how long a failed fetch isn't tried again. While the
+// source is down every command would otherwise wait on it, up to the HTTP
+// timeout or a whole git clone.
+const fetchRetryAfter = 2 * time.Minute
+
+func fetchCachePath(name string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw", "cache", name+".json")
+}
+
+// cachedFetch returns what src holds, from ~/.ksw/cache/<name>.json while
+// it's younger than ttl. When fetching fails the last copy is used, however
+// old, with a note saying so, and src isn't fetched again for
+// fetchRetryAfter. force fetches src now regardless of both.
+func cachedFetch(name, src string, ttl time.Duration, force bool) ([]byte, error) {
+	path := fetchCachePath(name)
+	var cache fetchCache
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	if cache.URL != src {
+		cache = fetchCache{URL: src}
+	}
+	if !force && cache.Fetched != 0 && time.Since(time.Unix(cache.Fetched, 0)) < ttl {
+		return []byte(cache.Body), nil
+	}
+	var body []byte
+	var err error
+	if !force && cache.Failed != 0 && time.Since(time.Unix(cache.Failed, 0)) < fetchRetryAfter {
+		err = errors.New(cache.Error)
+	} else if body, err = fetchSource(src); err != nil {
+		cache.Failed, cache.Error = time.Now().Unix(), err.Error()
+		saveFetchCache(path, cache)
+	}
+	if err != nil {
+		if cache.Fetched == 0 {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "  %s %s\n", dimStyle.Render("·"), dimStyle.Render(fmt.Sprintf(
+			"%s: %v; using the copy from %s ago", name, err, formatSeconds(time.Now().Unix()-cache.Fetched))))
+		return []byte(cache.Body), nil
+	}
+	saveFetchCache(path, fetchCache{URL: src, Fetched: time.Now().Unix(), Body: string(body)})
+	return body, nil
+}
+
+func saveFetchCache(path string, cache fetchCache) {
+	if data, err := json.Marshal(cache); err == nil && os.MkdirAll(filepath.Dir(path), 0700) == nil {
+		_ = os.WriteFile(path, data, 0600)
+	}
+}
+
+// fetchSource reads an https:// URL (http:// only on this machine), or a
+// file in a Git repository given as git+<repo>#<path>
+func fetchSource(src string) ([]byte, error) {
+	if strings.HasPrefix(src, "git+") {
+		return fetchGitFile(src)
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+		return nil, fmt.Errorf("%s: use an https:// URL or git+<repo>#<path>", src)
+	}
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(src)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch %s: %w", src, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't fetch %s: %s", src, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch %s: %w", src, err)
+	}
+	return body, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// fetchGitFile reads a file from the default branch of a repository:
+// git+https://github.com/acme/platform.git#ksw/policy.json. Credentials come
+// from git's own setup; it never prompts.
+func fetchGitFile(src string) ([]byte, error) {
+	repo, file, ok := strings.Cut(strings.TrimPrefix(src, "git+"), "#")
+	if !ok || repo == "" || file == "" {
+		return nil, fmt.Errorf("%s: use git+<repo>#<path/to/file>", src)
+	}
+	dir, err := os.MkdirTemp("", "ksw-git-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	cmd, done := command("git", "clone", "--quiet", "--depth", "1", repo, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); done(err) != nil {
+		return nil, fmt.Errorf("git clone %s: %s", repo, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+}
+
+// ── ksw policy ─────────────────────────────────────────
+
+// handlePolicy: ksw policy [refresh]. Shows the guardrails policy_url adds;
+// refresh fetches it again now.
+func handlePolicy(cfg config) {
+	if cfg.PolicyURL == "" {
+		fmt.Println(dimStyle.Render(`No team policy. Set "policy_url" in ~/.ksw.json to an https:// URL or git+<repo>#<path>.`))
+		return
+	}
+	refresh := len(os.Args) >= 3
+	if refresh && os.Args[2] != "refresh" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw policy [refresh]")
+		os.Exit(1)
+	}
+	p := loadPolicy(cfg, refresh)
+	fmt.Printf("  %s %s\n", currentLabelStyle.Render("source   "), cfg.PolicyURL)
+	var cache fetchCache
+	if data, err := os.ReadFile(fetchCachePath("policy")); err == nil && json.Unmarshal(data, &cache) == nil && cache.URL == cfg.PolicyURL && cache.Fetched != 0 {
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render("fetched  "), dimStyle.Render(formatSeconds(time.Now().Unix()-cache.Fetched)+" ago"))
+	}
+	list := func(label string, items []string) {
+		value := dimStyle.Render("none")
+		if len(items) > 0 {
+			value = strings.Join(items, ", ")
+		}
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render(label), value)
+	}
+	list("protected", p.Protected)
+	var freezes []string
+	for _, w := range p.Freeze {
+		freezes = append(freezes, w.label()+" ("+w.Start+" → "+w.End+")")
+	}
+	list("freezes  ", freezes)
+	list("AI can't ", slices.Clone(p.AIForbidden))
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ── Config profiles ────────────────────────────────────
+
+// profileData holds the settings that are kept separately per profile.
+// History, previous context and UI preferences are shared across profiles.
+type profileData struct {
+	Aliases      map[string]string   `json:"aliases,omitempty"`
+	MultiAliases map[string][]string `json:"multi_aliases,omitempty"`
+	Pins         []string            `json:"pins,omitempty"`
+	Groups       map[string][]string `json:"groups,omitempty"`
+	GroupDefault map[string]string   `json:"group_default,omitempty"`
+	GroupColors  map[string]string   `json:"group_colors,omitempty"`
+	Trash        []trashEntry        `json:"trash,omitempty"`
+	AI           aiConfig            `json:"ai,omitempty"`
+	AIMemory     []aiMemoryEntry     `json:"ai_memory,omitempty"`
+	AILearned    map[string]string   `json:"ai_learned,omitempty"`
+}
+
+// profileOverride is set by the global --profile flag
+var profileOverride string
+
+// activeProfileName returns the profile to use: --profile flag, then the persisted choice
+func activeProfileName(c config) string {
+	name := c.ActiveProfile
+	if profileOverride != "" {
+		name = profileOverride
+	}
+	if name == "default" {
+		return ""
+	}
+	return name
+}
+
+func (c *config) profileFields() profileData {
+	return profileData{
+		Aliases:      c.Aliases,
+		MultiAliases: c.MultiAliases,
+		Pins:         c.Pins,
+		Groups:       c.Groups,
+		GroupDefault: c.GroupDefault,
+		GroupColors:  c.GroupColors,
+		Trash:        c.Trash,
+		AI:           c.AI,
+		AIMemory:     c.AIMemory,
+		AILearned:    c.AILearned,
+	}
+}
+
+func (c *config) setProfileFields(p profileData) {
+	c.Aliases = p.Aliases
+	c.MultiAliases = p.MultiAliases
+	c.Pins = p.Pins
+	c.Groups = p.Groups
+	c.GroupDefault = p.GroupDefault
+	c.GroupColors = p.GroupColors
+	c.Trash = p.Trash
+	c.AI = p.AI
+	c.AIMemory = p.AIMemory
+	c.AILearned = p.AILearned
+}
+
+// useProfile swaps the named profile's settings into the top-level fields
+func (c *config) useProfile(name string) {
+	if name == "" {
+		return
+	}
+	c.base = c.profileFields()
+	c.setProfileFields(c.Profiles[name])
+	c.profile = name
+}
+
+// storeProfile moves the active profile's settings back into Profiles
+// and restores the default profile at the top level, ready to be saved
+func (c *config) storeProfile() {
+	if c.profile == "" {
+		return
+	}
+	profiles := make(map[string]profileData, len(c.Profiles)+1)
+	for k, v := range c.Profiles {
+		profiles[k] = v
+	}
+	profiles[c.profile] = c.profileFields()
+	c.Profiles = profiles
+	c.setProfileFields(c.base)
+	c.profile = ""
+}
+
+// ── handleProfile ──────────────────────────────────────
+
+func handleProfile(cfg config) {
+	sub := "ls"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+
+	active := cfg.profile
+	if active == "" {
+		active = "default"
+	}
+
+	switch sub {
+	case "ls", "list":
+		names := []string{"default"}
+		for n := range cfg.Profiles {
+			if n != "default" {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names[1:])
+		for _, n := range names {
+			if n == active {
+				fmt.Printf("  %s %s\n", activeItemStyle.Render(n), activeTag)
+			} else {
+				fmt.Printf("  %s\n", normalItemStyle.Render(n))
+			}
+		}
+
+	case "use":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw profile use <name>")
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		// Persist the choice on the default-profile view of the config
+		cfg.storeProfile()
+		_, exists := cfg.Profiles[name]
+		if name == "default" {
+			cfg.ActiveProfile = ""
+			exists = true
+		} else {
+			cfg.ActiveProfile = name
+			if !exists {
+				if cfg.Profiles == nil {
+					cfg.Profiles = make(map[string]profileData)
+				}
+				cfg.Profiles[name] = profileData{}
+			}
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		if exists {
+			fmt.Printf("%s Using profile %s\n", successStyle.Render("✔"), aliasStyle.Render(name))
+		} else {
+			fmt.Printf("%s Created and using profile %s\n", successStyle.Render("✔"), aliasStyle.Render(name))
+		}
+
+	case "rm", "remove", "delete":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw profile rm <name>")
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		if name == "default" {
+			fmt.Fprintf(os.Stderr, "%s The default profile cannot be removed.\n", warnStyle.Render("✗"))
+			os.Exit(1)
+		}
+		cfg.storeProfile()
+		if _, ok := cfg.Profiles[name]; !ok {
+			fmt.Fprintf(os.Stderr, "%s Profile '%s' not found.\n", warnStyle.Render("✗"), name)
+			os.Exit(exitNotFound)
+		}
+		delete(cfg.Profiles, name)
+		if cfg.ActiveProfile == name {
+			cfg.ActiveProfile = ""
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Removed profile %s\n", successStyle.Render("✔"), aliasStyle.Render(name))
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown profile subcommand '%s'.\nUsage: ksw profile <ls|use|rm>\n", sub)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Progress ───────────────────────────────────────────
+
+// progress is a spinner line on stderr with an optional done/total counter.
+// Lines printed through it appear above the spinner. When stderr isn't a
+// terminal it degrades to plain output.
+type progress struct {
+	program *guardedProgram
+	mu      sync.Mutex
+	done    int
+}
+
+type progressStepMsg struct{ done int }
+type progressFinishedMsg struct{}
+type progressTickMsg struct{}
+
+type progressModel struct {
+	label    string
+	total    int
+	done     int
+	frame    int
+	finished bool
+}
+
+var (
+	spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	spinnerDots   = []string{"", ".", "..", "..."}
+)
+
+func progressTick() tea.Cmd {
+	return tea.Tick(80*time.Millisecond, func(time.Time) tea.Msg { return progressTickMsg{} })
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return progressTick()
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.finished = true
+			return m, tea.Interrupt
+		}
+	case progressTickMsg:
+		m.frame++
+		return m, progressTick()
+	case progressStepMsg:
+		m.done = msg.done
+	case progressFinishedMsg:
+		m.finished = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	if m.finished {
+		return "" // leave no spinner remnant behind
+	}
+	line := dimStyle.Render(spinnerFrames[m.frame%len(spinnerFrames)]) + " " + m.label
+	if m.total > 0 {
+		line += dimStyle.Render(fmt.Sprintf(" %d/%d", m.done, m.total))
+	} else {
+		line += dimStyle.Render(spinnerDots[(m.frame/3)%len(spinnerDots)])
+	}
+	return line
+}
+
+// Step marks one of the total units of work as done
+func (pr *progress) Step() {
+	if pr == nil {
+		return
+	}
+	pr.mu.Lock()
+	pr.done++
+	done := pr.done
+	pr.mu.Unlock()
+	if pr.program != nil {
+		pr.program.Send(progressStepMsg{done: done})
+	}
+}
+
+// Println prints a line above the spinner
+func (pr *progress) Println(line string) {
+	if pr.program != nil {
+		pr.program.Println(line)
+		return
+	}
+	fmt.Println(line)
+}
+
+// errInterrupted is returned by runWithProgress when the user pressed Ctrl+C
+var errInterrupted = errors.New("interrupted")
+
+// stderrIsTerminal reports whether the spinner can be drawn
+func stderrIsTerminal() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// runWithProgress runs fn while showing label with a spinner (and a done/total
+// counter when total > 0). The spinner line is removed when fn returns.
+// On Ctrl+C the context passed to fn is cancelled, the terminal is restored
+// and errInterrupted is returned without waiting for fn.
+func runWithProgress(ctx context.Context, label string, total int, fn func(ctx context.Context, pr *progress) error) error {
+	pr := &progress{}
+	if inChatMode {
+		// The chat view draws its own spinner and cancels ctx itself
+		return fn(ctx, pr)
+	}
+	if !stderrIsTerminal() {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		err := fn(ctx, pr)
+		if ctx.Err() != nil {
+			return errInterrupted
+		}
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr.program = newProgram(progressModel{label: label, total: total}, tea.WithOutput(os.Stderr))
+	result := make(chan error, 1)
+	go func() {
+		err := fn(ctx, pr)
+		result <- err
+		pr.program.Send(progressFinishedMsg{})
+	}()
+
+	if _, err := pr.program.Run(); err != nil {
+		if errors.Is(err, tea.ErrInterrupted) {
+			return errInterrupted
+		}
+		return <-result
+	}
+	return <-result
+}
+
+// exitIfInterrupted ends the process the way a shell expects after Ctrl+C
+func exitIfInterrupted(err error) {
+	if errors.Is(err, errInterrupted) {
+		fmt.Fprintln(os.Stderr, dimStyle.Render("Interrupted."))
+		os.Exit(exitCancelled)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ── Project config (.ksw.yaml) ─────────────────────────
+
+// projectFileName is looked up from the working directory upwards
+const projectFileName = ".ksw.yaml"
+
+// projectConfig is what a repo declares in .ksw.yaml:
+//
+//	context: payments-dev
+//	namespace: payments
+//	protected: true
+type projectConfig struct {
+	Context   string
+	Namespace string
+	Protected bool
+	path      string
+}
+
+// findProjectFile returns the nearest .ksw.yaml at or above dir, or ""
+func findProjectFile(dir string) string {
+	for {
+		p := filepath.Join(dir, projectFileName)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig parses the flat "key: value" subset of YAML the file
+// needs; there is no YAML dependency, so nesting and lists are not supported
+func loadProjectConfig(path string) (projectConfig, error) {
+	pc := projectConfig{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return pc, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return pc, fmt.Errorf("%s:%d: expected 'key: value'", path, lineNo)
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.TrimSpace(key) {
+		case "context":
+			pc.Context = value
+		case "namespace":
+			pc.Namespace = value
+		case "protected":
+			pc.Protected = value == "true" || value == "yes"
+		default:
+			return pc, fmt.Errorf("%s:%d: unknown key '%s' (context, namespace, protected)", path, lineNo, strings.TrimSpace(key))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pc, err
+	}
+	if pc.Context == "" {
+		return pc, fmt.Errorf("%s: 'context' is required", path)
+	}
+	return pc, nil
+}
+
+// currentProject loads the .ksw.yaml for the working directory; ok is false
+// when there is none
+func currentProject() (pc projectConfig, ok bool, err error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return pc, false, err
+	}
+	path := findProjectFile(wd)
+	if path == "" {
+		return pc, false, nil
+	}
+	pc, err = loadProjectConfig(path)
+	return pc, true, err
+}
+
+const zshProjectHook = `# ksw project hook
+_ksw_project_check() { ksw project check }
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _ksw_project_check
+_ksw_project_check
+`
+
+const bashProjectHook = `# ksw project hook
+_ksw_project_check() {
+  [ "$PWD" = "$_KSW_LAST_PWD" ] && return
+  _KSW_LAST_PWD="$PWD"
+  ksw project check
+}
+PROMPT_COMMAND="_ksw_project_check${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+`
+
+// handleProject works with the repo's .ksw.yaml:
+// ksw project [show] | ksw project use | ksw project check | ksw project hook <zsh|bash>
+func handleProject(cfg config) {
+	sub := "show"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+
+	if sub == "hook" {
+		shell := "zsh"
+		if len(os.Args) >= 4 {
+			shell = os.Args[3]
+		}
+		switch shell {
+		case "zsh":
+			fmt.Print(zshProjectHook)
+		case "bash":
+			fmt.Print(bashProjectHook)
+		default:
+			fmt.Fprintf(os.Stderr, "%s Unsupported shell '%s'. Supported: zsh, bash\n", warnStyle.Render("✗"), shell)
+			os.Exit(1)
+		}
+		return
+	}
+
+	pc, ok, err := currentProject()
+	if err != nil {
+		fatal(err)
+	}
+	if !ok {
+		if sub == "check" {
+			return // outside any project: nothing to say
+		}
+		fmt.Fprintf(os.Stderr, "%s No %s found in this directory or its parents.\n", warnStyle.Render("✗"), projectFileName)
+		os.Exit(exitNotFound)
+	}
+
+	switch sub {
+	case "show":
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render("file     "), dimStyle.Render(pc.path))
+		fmt.Printf("  %s %s\n", currentLabelStyle.Render("context  "), pc.Context)
+		if pc.Namespace != "" {
+			fmt.Printf("  %s %s\n", currentLabelStyle.Render("namespace"), pc.Namespace)
+		}
+		if pc.Protected {
+			fmt.Printf("  %s %s\n", currentLabelStyle.Render("protected"), warnStyle.Render("yes"))
+		}
+
+	case "check":
+		// Run by the shell hook on every directory change: quiet when all is well
+		current := effectiveContext()
+		contexts, err := getContexts()
+		if err != nil {
+			return
+		}
+		want, err := resolveContext(pc.Context, contexts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s expects context '%s', which isn't in your kubeconfig\n", warnStyle.Render("⚠"), projectFileName, pc.Context)
+			return
+		}
+		if current != want {
+			fmt.Fprintf(os.Stderr, "%s This repo expects %s but you're on %s · %s\n", warnStyle.Render("⚠"),
+				aliasStyle.Render(shortName(want)), shortName(current), dimStyle.Render("ksw project use"))
+		}
+
+	case "use":
+		current, contexts, err := getKubeconfigState()
+		if err != nil {
+			fatal(err)
+		}
+		target, err := resolveContext(pc.Context, contexts)
+		if err != nil {
+			fatal(err)
+		}
+		if pc.Protected && target != current {
+			fmt.Printf("%s %s is marked protected in %s. Switch? [y/N]: ", warnStyle.Render("⚠"), target, projectFileName)
+			var answer string
+			fmt.Scanln(&answer)
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println(dimStyle.Render("Cancelled."))
+				os.Exit(1)
+			}
+		}
+		switchTo(cfg, target, dimStyle.Render("(project)"))
+		if pc.Namespace != "" {
+			out, err := combinedOutput("kubectl", "config", "set-context", target, "--namespace", pc.Namespace)
+			if err != nil {
+				fatal(withExitCode(exitKubeconfig, fmt.Errorf("failed to set namespace: %s", strings.TrimSpace(string(out)))))
+			}
+			fmt.Printf("  %s namespace %s\n", dimStyle.Render("·"), pc.Namespace)
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown project subcommand '%s'.\nUsage: ksw project [show|use|check|hook <zsh|bash>]\n", sub)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// ── Provenance ─────────────────────────────────────────
+
+// provenance records how a context entered the kubeconfig
+type provenance struct {
+	Source string `json:"source"`           // "eks", "local", "manual" (ksw add) or "file" (a merged kubeconfig)
+	Detail string `json:"detail,omitempty"` // e.g. "profile prod, us-east-1", "kind cluster dev" or the file
+	Added  int64  `json:"added"`            // for "file", when ksw first saw the context there
+}
+
+func (p provenance) String() string {
+	s := p.Source
+	if p.Detail != "" {
+		s += " (" + p.Detail + ")"
+	}
+	return s
+}
+
+// recordNewContexts stores provenance for every context present now but not
+// in before, so commands that add contexts only need to snapshot the list
+// first. detail may be nil.
+func recordNewContexts(before []string, source string, detail func(ctx string) string) {
+	after, err := getContexts()
+	if err != nil {
+		return
+	}
+	cfg := loadConfig()
+	added := 0
+	for _, ctx := range after {
+		if slices.Contains(before, ctx) {
+			continue
+		}
+		d := ""
+		if detail != nil {
+			d = detail(ctx)
+		}
+		setProvenance(&cfg, ctx, source, d)
+		added++
+	}
+	if added > 0 {
+		_ = saveConfig(cfg)
+	}
+}
+
+// setProvenance records that ctx just came from source
+func setProvenance(cfg *config, ctx, source, detail string) {
+	if cfg.Provenance == nil {
+		cfg.Provenance = make(map[string]provenance)
+	}
+	cfg.Provenance[ctx] = provenance{Source: source, Detail: detail, Added: time.Now().Unix()}
+}
+
+// recordFileOrigins gives the contexts ksw knows nothing about the file they
+// come from, when $KUBECONFIG merges several; files is what kube.Files()
+// returns. It reports whether it recorded any.
+func recordFileOrigins(cfg *config, files map[string]string) bool {
+	added := false
+	for ctx, file := range files {
+		if _, ok := cfg.Provenance[ctx]; !ok && file != "" {
+			setProvenance(cfg, ctx, "file", file)
+			added = true
+		}
+	}
+	return added
+}
+
+// kubeconfigFileFor returns the kubeconfig file that defines ctx, when
+// $KUBECONFIG merges several
+func kubeconfigFileFor(ctx string) string {
+	paths := kubeconfigPaths()
+	if len(paths) == 1 {
+		return paths[0]
+	}
+	return getContextFiles()[ctx]
+}
+
+// kubeconfigEdit runs "kubectl config <args>", a command that edits ctx's
+// entry, and returns its combined output. With several kubeconfig files it
+// targets the one defining ctx, so a rename or delete lands there instead of
+// wherever kubectl writes merged changes.
+func kubeconfigEdit(ctx string, args ...string) ([]byte, error) {
+	args = append([]string{"config"}, args...)
+	if len(kubeconfigPaths()) > 1 {
+		if file := kubeconfigFileFor(ctx); file != "" {
+			args = append(args, "--kubeconfig", file)
+		}
+	}
+	return combinedOutput("kubectl", args...)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Query log (ksw stats --queries) ────────────────────
+
+// queryLogMax is how big the query log grows before its older half is
+// dropped
+const queryLogMax = 256 << 10
+
+// queryEntry is one TUI pick: what was typed and what it led to. Pos is
+// the pick's row in the filtered list, 0 when it was the first match.
+type queryEntry struct {
+	Time    int64  `json:"time"`
+	Query   string `json:"query"`
+	Context string `json:"context"`
+	Pos     int    `json:"pos,omitempty"`
+}
+
+// queryLogPath is where picks are recorded when record_queries is on. It is
+// never sent anywhere.
+func queryLogPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw", "queries.jsonl")
+}
+
+// recordQuery appends a pick to the query log. Picks made without typing
+// anything say nothing about names, so they're left out.
+func recordQuery(cfg config, query, ctx string, pos int) {
+	query = strings.TrimSpace(query)
+	if !cfg.RecordQueries || query == "" || ctx == "" {
+		return
+	}
+	path := queryLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > queryLogMax {
+		trimQueryLog(path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	data, _ := json.Marshal(queryEntry{Time: time.Now().Unix(), Query: query, Context: ctx, Pos: pos})
+	_, _ = f.Write(append(data, '\n'))
+}
+
+// trimQueryLog keeps the newer half of the log
+func trimQueryLog(path string) {
+	entries := loadQueryLog()
+	entries = entries[len(entries)/2:]
+	var b strings.Builder
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err == nil {
+		_ = os.Rename(tmp, path)
+	}
+}
+
+// loadQueryLog reads the query log, skipping lines it can't parse
+func loadQueryLog() []queryEntry {
+	f, err := os.Open(queryLogPath())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var entries []queryEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e queryEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.Context != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+type queryCount struct {
+	Query string `json:"query"`
+	Picks int    `json:"picks"`
+}
+
+// queryStats is what was typed to reach one context
+type queryStats struct {
+	Context  string       `json:"context"`
+	Picks    int          `json:"picks"`
+	NotFirst int          `json:"not_first"` // picks that took arrow keys after typing
+	Queries  []queryCount `json:"queries"`   // most used first
+	Alias    string       `json:"alias,omitempty"`
+}
+
+type queriesReport struct {
+	Since    string       `json:"since,omitempty"`
+	Context  string       `json:"context,omitempty"`
+	Picks    int          `json:"picks"`
+	Contexts []queryStats `json:"contexts"`
+}
+
+// buildQueryStats groups the log by context, most picked first; pattern (a
+// glob) limits it to matching contexts
+func buildQueryStats(cfg config, entries []queryEntry, since time.Duration, pattern string) queriesReport {
+	var r queriesReport
+	now := time.Now()
+	byCtx := make(map[string]*queryStats)
+	counts := make(map[string]map[string]int)
+	for _, e := range entries {
+		if since > 0 && time.Unix(e.Time, 0).Before(now.Add(-since)) {
+			continue
+		}
+		if pattern != "" && !globMatch(pattern, e.Context) {
+			continue
+		}
+		s, ok := byCtx[e.Context]
+		if !ok {
+			s = &queryStats{Context: e.Context, Alias: aliasTo(cfg, e.Context)}
+			byCtx[e.Context] = s
+			counts[e.Context] = make(map[string]int)
+		}
+		s.Picks++
+		if e.Pos > 0 {
+			s.NotFirst++
+		}
+		counts[e.Context][e.Query]++
+		r.Picks++
+	}
+	for ctx, s := range byCtx {
+		for q, n := range counts[ctx] {
+			s.Queries = append(s.Queries, queryCount{Query: q, Picks: n})
+		}
+		sort.Slice(s.Queries, func(i, j int) bool {
+			if s.Queries[i].Picks != s.Queries[j].Picks {
+				return s.Queries[i].Picks > s.Queries[j].Picks
+			}
+			return s.Queries[i].Query < s.Queries[j].Query
+		})
+		r.Contexts = append(r.Contexts, *s)
+	}
+	sort.Slice(r.Contexts, func(i, j int) bool {
+		if r.Contexts[i].Picks != r.Contexts[j].Picks {
+			return r.Contexts[i].Picks > r.Contexts[j].Picks
+		}
+		return r.Contexts[i].Context < r.Contexts[j].Context
+	})
+	if r.Contexts == nil {
+		r.Contexts = []queryStats{}
+	}
+	return r
+}
+
+// aliasTo returns the first alias, by name, that leads to ctx alone
+func aliasTo(cfg config, ctx string) string {
+	var names []string
+	for name, target := range cfg.Aliases {
+		if target == ctx {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// freeAlias is the shortest alias candidate for ctx nobody uses yet
+func freeAlias(cfg config, ctx string) string {
+	for _, name := range aliasCandidates(ctx) {
+		_, single := cfg.Aliases[name]
+		_, multi := cfg.MultiAliases[name]
+		if !single && !multi {
+			return name
+		}
+	}
+	return ""
+}
+
+// handleQueryStats: ksw stats --queries [on|off]. Shows what was typed in
+// the TUI before each pick, to find contexts that deserve an alias.
+func handleQueryStats(cfg config, toggle string, since time.Duration, sinceArg, pattern string, asJSON bool) {
+	switch toggle {
+	case "on", "off":
+		cfg.RecordQueries = toggle == "on"
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		if cfg.RecordQueries {
+			fmt.Printf("%s Recording TUI queries to %s %s\n", successStyle.Render("✔"), queryLogPath(), dimStyle.Render("(stays on this machine)"))
+		} else {
+			fmt.Printf("%s Stopped recording TUI queries %s\n", successStyle.Render("✔"), dimStyle.Render("(rm "+queryLogPath()+" to forget them)"))
+		}
+		return
+	}
+
+	r := buildQueryStats(cfg, loadQueryLog(), since, pattern)
+	r.Since, r.Context = sinceArg, pattern
+	if asJSON {
+		data, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if r.Picks == 0 {
+		if !cfg.RecordQueries {
+			fmt.Println(dimStyle.Render("Query recording is off. Turn it on with: ksw stats --queries on"))
+		} else {
+			fmt.Println(dimStyle.Render("No queries recorded yet."))
+		}
+		return
+	}
+
+	fmt.Printf("%s %d picks across %d contexts\n\n", successStyle.Render("✔"), r.Picks, len(r.Contexts))
+	var hints []string
+	for i, s := range r.Contexts {
+		if i == 10 {
+			break
+		}
+		var typed []string
+		for j, q := range s.Queries {
+			if j == 3 {
+				break
+			}
+			typed = append(typed, fmt.Sprintf("%q %d×", q.Query, q.Picks))
+		}
+		name := shortName(s.Context)
+		if s.Alias != "" {
+			name += " " + aliasStyle.Render("@"+s.Alias)
+		}
+		fmt.Printf("  %s %s\n", currentValueStyle.Render(fmt.Sprintf("%4d×", s.Picks)), name)
+		fmt.Printf("        %s\n", dimStyle.Render(strings.Join(typed, ", ")))
+		if s.NotFirst > 0 {
+			fmt.Printf("        %s\n", warnStyle.Render(fmt.Sprintf("%d of them not the first match", s.NotFirst)))
+		}
+		// Typing more than an alias would take, or moving past other
+		// matches, is what an alias saves
+		if s.Alias == "" && (s.NotFirst > 0 || len(s.Queries[0].Query) > 3) {
+			if a := freeAlias(cfg, s.Context); a != "" {
+				hints = append(hints, "ksw alias "+a+" "+s.Context)
+			}
+		}
+	}
+	if len(hints) > 0 {
+		fmt.Printf("\n  %s\n", dimStyle.Render("Aliases that would save typing:"))
+		for _, h := range hints {
+			fmt.Printf("    %s\n", h)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ── ksw reconcile ──────────────────────────────────────
+
+// staleRefs maps each config reference to a context that no longer exists
+// to descriptions of where it is used ("@alias", "pin", "group payments"...)
+func staleRefs(cfg config, contexts []string) map[string][]string {
+	refs := make(map[string][]string)
+	missing := func(ctx string) bool { return ctx != "" && !slices.Contains(contexts, ctx) }
+
+	// Alias targets may be short names, so resolve them like a switch does
+	for name, target := range cfg.Aliases {
+		if m, _ := resolveContexts(target, contexts); len(m) == 0 {
+			refs[target] = append(refs[target], "@"+name)
+		}
+	}
+	for name, targets := range cfg.MultiAliases {
+		for _, t := range targets {
+			if m, _ := resolveContexts(t, contexts); len(m) == 0 {
+				refs[t] = append(refs[t], "@"+name)
+			}
+		}
+	}
+	for _, p := range cfg.Pins {
+		if missing(p) {
+			refs[p] = append(refs[p], "pin")
+		}
+	}
+	for g, members := range cfg.Groups {
+		for _, m := range members {
+			if missing(m) {
+				refs[m] = append(refs[m], "group "+g)
+			}
+		}
+	}
+	for g, d := range cfg.GroupDefault {
+		if missing(d) {
+			refs[d] = append(refs[d], "default of "+g)
+		}
+	}
+	if missing(cfg.Home) {
+		refs[cfg.Home] = append(refs[cfg.Home], "home")
+	}
+	for _, h := range cfg.History {
+		if missing(h) {
+			refs[h] = append(refs[h], "history")
+		}
+	}
+	if missing(cfg.Previous) && !slices.Contains(refs[cfg.Previous], "history") {
+		refs[cfg.Previous] = append(refs[cfg.Previous], "history")
+	}
+	for _, uses := range refs {
+		sort.Strings(uses)
+	}
+	return refs
+}
+
+// dropContextRefs removes every reference to ctx; aliases and pins go to the
+// trash, marked as removed by by
+func dropContextRefs(cfg *config, ctx, by string) {
+	for name, target := range cfg.Aliases {
+		if target == ctx {
+			trashAlias(cfg, name, by)
+		}
+	}
+	for name, targets := range cfg.MultiAliases {
+		if !slices.Contains(targets, ctx) {
+			continue
+		}
+		if kept := slices.DeleteFunc(slices.Clone(targets), func(t string) bool { return t == ctx }); len(kept) > 0 {
+			cfg.MultiAliases[name] = kept
+		} else {
+			trashAlias(cfg, name, by)
+		}
+	}
+	trashPin(cfg, ctx, by)
+	for g, members := range cfg.Groups {
+		cfg.Groups[g] = slices.DeleteFunc(members, func(m string) bool { return m == ctx })
+	}
+	for g, d := range cfg.GroupDefault {
+		if d == ctx {
+			delete(cfg.GroupDefault, g)
+		}
+	}
+	if cfg.Home == ctx {
+		cfg.Home = ""
+	}
+	delete(cfg.Expiry, ctx)
+	delete(cfg.AWSProfiles, ctx)
+	delete(cfg.Notes, ctx)
+	cfg.NotesMuted = slices.DeleteFunc(cfg.NotesMuted, func(c string) bool { return c == ctx })
+	cfg.History = slices.DeleteFunc(cfg.History, func(h string) bool { return h == ctx })
+	if cfg.Previous == ctx {
+		cfg.Previous = ""
+	}
+}
+
+// similarity is 1 minus the edit distance over the longer length
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// renameCandidate returns the context stale was most likely renamed to, or ""
+// when no context is clearly closer than the rest. Contexts that are already
+// referenced by the config are skipped: they weren't just renamed.
+func renameCandidate(stale string, contexts []string, referenced map[string]bool) string {
+	const minSimilarity, minLead = 0.75, 0.1
+	best, bestScore, second := "", 0.0, 0.0
+	for _, ctx := range contexts {
+		if referenced[ctx] {
+			continue
+		}
+		score := max(similarity(stale, ctx), similarity(shortName(stale), shortName(ctx)))
+		if score > bestScore {
+			best, bestScore, second = ctx, score, bestScore
+		} else if score > second {
+			second = score
+		}
+	}
+	if bestScore < minSimilarity || bestScore-second < minLead {
+		return ""
+	}
+	return best
+}
+
+// referencedContexts lists the existing contexts the config already points at
+func referencedContexts(cfg config, contexts []string) map[string]bool {
+	refs := make(map[string]bool)
+	add := func(name string) {
+		if ctx, err := resolveContext(name, contexts); err == nil {
+			refs[ctx] = true
+		}
+	}
+	for _, t := range cfg.Aliases {
+		add(t)
+	}
+	for _, targets := range cfg.MultiAliases {
+		for _, t := range targets {
+			add(t)
+		}
+	}
+	for _, p := range cfg.Pins {
+		refs[p] = true
+	}
+	for _, members := range cfg.Groups {
+		for _, m := range members {
+			refs[m] = true
+		}
+	}
+	return refs
+}
+
+// handleReconcile walks config entries that point at deleted contexts:
+// ksw reconcile [--auto]
+// --auto only applies renames detected by similarity and leaves the rest.
+func handleReconcile(cfg config) {
+	auto := len(os.Args) >= 3 && os.Args[2] == "--auto"
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+
+	refs := staleRefs(cfg, contexts)
+	if len(refs) == 0 {
+		fmt.Printf("%s Config and kubeconfig agree\n", successStyle.Render("✔"))
+		return
+	}
+	stale := make([]string, 0, len(refs))
+	for s := range refs {
+		stale = append(stale, s)
+	}
+	sort.Strings(stale)
+	referenced := referencedContexts(cfg, contexts)
+
+	changed, left := 0, 0
+	for _, s := range stale {
+		suggestion := renameCandidate(s, contexts, referenced)
+		fmt.Printf("  %s %s %s\n", warnStyle.Render("✗"), s, dimStyle.Render("("+strings.Join(refs[s], ", ")+")"))
+
+		if auto {
+			if suggestion == "" {
+				fmt.Printf("    %s\n", dimStyle.Render("no obvious rename, left as is"))
+				left++
+				continue
+			}
+			renameContextRefs(&cfg, s, suggestion)
+			referenced[suggestion] = true
+			fmt.Printf("    %s → %s\n", successStyle.Render("✔"), suggestion)
+			changed++
+			continue
+		}
+
+		prompt := "[r]etarget · [d]elete · [s]kip: "
+		if suggestion != "" {
+			fmt.Printf("    %s %s\n", dimStyle.Render("renamed to?"), suggestion)
+			prompt = "[a]ccept · " + prompt
+		}
+		fmt.Printf("    %s", prompt)
+		var pick string
+		fmt.Scanln(&pick)
+		switch strings.ToLower(strings.TrimSpace(pick)) {
+		case "a", "accept":
+			if suggestion == "" {
+				left++
+				continue
+			}
+			renameContextRefs(&cfg, s, suggestion)
+			referenced[suggestion] = true
+			fmt.Printf("    %s → %s\n", successStyle.Render("✔"), suggestion)
+			changed++
+		case "r", "retarget":
+			fmt.Printf("    New context: ")
+			var newTarget string
+			fmt.Scanln(&newTarget)
+			resolved, err := resolveContext(strings.TrimSpace(newTarget), contexts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "    %s %v\n", warnStyle.Render("✗"), err)
+				left++
+				continue
+			}
+			renameContextRefs(&cfg, s, resolved)
+			referenced[resolved] = true
+			fmt.Printf("    %s → %s\n", successStyle.Render("✔"), resolved)
+			changed++
+		case "d", "delete":
+			dropContextRefs(&cfg, s, "")
+			fmt.Printf("    %s Removed %s %s\n", successStyle.Render("✔"), s, dimStyle.Render("(aliases and pins are in ksw trash)"))
+			changed++
+		default:
+			left++
+		}
+	}
+
+	if changed > 0 {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("\n%s reconciled, %s left\n",
+		successStyle.Render(fmt.Sprintf("%d", changed)), dimStyle.Render(fmt.Sprintf("%d", left)))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ── Fan-out reports ────────────────────────────────────
+
+// reportOpts are the flags of commands that report on many contexts at once
+// (ksw check, ksw each), so CI jobs can read and stop on them
+type reportOpts struct {
+	format   string // "table" (the default), "wide" or "json"
+	failFast bool   // stop at the first context that fails
+}
+
+// parse takes -o/--output <format> or --fail-fast at args[i] and returns how
+// many words it used; 0 when args[i] is none of them
+func (o *reportOpts) parse(args []string, i int) (int, error) {
+	a, used := args[i], 1
+	switch {
+	case a == "--fail-fast":
+		o.failFast = true
+		return 1, nil
+	case a == "-o" || a == "--output":
+		if i+1 == len(args) {
+			return 0, fmt.Errorf("%s needs a format: table, wide or json", a)
+		}
+		a, used = args[i+1], 2
+	case strings.HasPrefix(a, "-o="), strings.HasPrefix(a, "--output="):
+		_, a, _ = strings.Cut(a, "=")
+	default:
+		return 0, nil
+	}
+	switch a {
+	case "table", "wide", "json":
+		o.format = a
+		return used, nil
+	}
+	return 0, fmt.Errorf("unknown output format '%s' (use table, wide or json)", a)
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ── Shell env ──────────────────────────────────────────
+
+// shellenvDir holds the minimal kubeconfigs written by ksw shellenv
+func shellenvDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kube", "ksw")
+}
+
+// minifiedKubeconfig is a self-contained kubeconfig containing only ctx
+func minifiedKubeconfig(ctx string) ([]byte, error) {
+	out, err := output("kubectl", "config", "view", "--minify", "--flatten", "--context", ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export context '%s': %w", ctx, err)
+	}
+	return out, nil
+}
+
+// exportContextKubeconfig writes minifiedKubeconfig(ctx) under shellenvDir
+func exportContextKubeconfig(ctx string) (string, error) {
+	out, err := minifiedKubeconfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	return writeShellenvKubeconfig([]string{ctx}, out)
+}
+
+// shellenvPath is the file under shellenvDir for contexts. It is keyed by a
+// hash of the full names: clusters sharing a short name in two accounts or
+// regions mustn't overwrite each other's file, which another terminal may
+// be using.
+func shellenvPath(contexts []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(contexts, "\n")))
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(shortName(contexts[0]))
+	if len(contexts) > 1 {
+		name += fmt.Sprintf("+%d", len(contexts)-1)
+	}
+	return filepath.Join(shellenvDir(), fmt.Sprintf("%s-%x.yaml", name, sum[:4]))
+}
+
+// writeShellenvKubeconfig saves data as shellenvPath(contexts)
+func writeShellenvKubeconfig(contexts []string, data []byte) (string, error) {
+	if err := os.MkdirAll(shellenvDir(), 0700); err != nil {
+		return "", err
+	}
+	path := shellenvPath(contexts)
+	// The flattened file embeds credentials — keep it private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// subsetKubeconfig builds a standalone kubeconfig with only the given
+// contexts and the clusters and users they reference, credentials inlined.
+// current-context is set to the first one. Returns YAML.
+func subsetKubeconfig(contexts []string) ([]byte, error) {
+	out, err := output("kubectl", "config", "view", "--flatten", "-o", "json")
+	if err != nil {
+		return nil, withExitCode(exitKubeconfig, fmt.Errorf("failed to read kubeconfig: %w", err))
+	}
+	var full map[string]any
+	if err := json.Unmarshal(out, &full); err != nil {
+		return nil, withExitCode(exitKubeconfig, fmt.Errorf("unexpected kubeconfig: %w", err))
+	}
+
+	// entries returns a kubeconfig list (clusters, contexts, users) keyed by name
+	entries := func(key string) map[string]map[string]any {
+		byName := make(map[string]map[string]any)
+		list, _ := full[key].([]any)
+		for _, e := range list {
+			if m, ok := e.(map[string]any); ok {
+				if name, ok := m["name"].(string); ok {
+					byName[name] = m
+				}
+			}
+		}
+		return byName
+	}
+	allContexts, allClusters, allUsers := entries("contexts"), entries("clusters"), entries("users")
+
+	var ctxList, clusterList, userList []any
+	seen := make(map[string]bool)
+	for _, name := range contexts {
+		c, ok := allContexts[name]
+		if !ok {
+			return nil, withExitCode(exitNotFound, fmt.Errorf("context '%s' not found in kubeconfig", name))
+		}
+		ctxList = append(ctxList, c)
+		spec, _ := c["context"].(map[string]any)
+		if cl, _ := spec["cluster"].(string); cl != "" && !seen["cluster:"+cl] {
+			seen["cluster:"+cl] = true
+			if e, ok := allClusters[cl]; ok {
+				clusterList = append(clusterList, e)
+			}
+		}
+		if u, _ := spec["user"].(string); u != "" && !seen["user:"+u] {
+			seen["user:"+u] = true
+			if e, ok := allUsers[u]; ok {
+				userList = append(userList, e)
+			}
+		}
+	}
+
+	subset := map[string]any{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"preferences":     map[string]any{},
+		"clusters":        clusterList,
+		"contexts":        ctxList,
+		"users":           userList,
+		"current-context": contexts[0],
+	}
+	data, _ := json.Marshal(subset)
+
+	// Let kubectl render the JSON as regular kubeconfig YAML
+	tmp, err := os.CreateTemp("", "ksw-export-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	tmp.Close()
+	yaml, err := output("kubectl", "config", "view", "--flatten", "--kubeconfig", tmp.Name())
+	if err != nil {
+		return nil, withExitCode(exitKubeconfig, fmt.Errorf("failed to render kubeconfig: %w", err))
+	}
+	return yaml, nil
+}
+
+// saveKubeconfigLine keeps the KUBECONFIG this terminal had before its first
+// ksw shellenv, for --reset to bring back; restoreKubeconfigLine does that,
+// unsetting KUBECONFIG only when it wasn't set before
+const (
+	saveKubeconfigLine    = `[ -n "${KSW_PREV_KUBECONFIG+x}" ] || export KSW_PREV_KUBECONFIG="${KUBECONFIG-}"`
+	restoreKubeconfigLine = `if [ -n "${KSW_PREV_KUBECONFIG+x}" ]; then if [ -n "$KSW_PREV_KUBECONFIG" ]; then export KUBECONFIG="$KSW_PREV_KUBECONFIG"; else unset KUBECONFIG; fi; unset KSW_PREV_KUBECONFIG; fi`
+)
+
+// handleShellenv prints eval-able exports that pin a context to the current terminal:
+//
+//	eval "$(ksw shellenv payments-dev)"          # KUBECONFIG → minimal file
+//	eval "$(ksw shellenv payments-dev --alias)"  # kubectl --context alias
+//	eval "$(ksw shellenv --reset)"
+//
+// A target naming several contexts (@multi alias, group, glob) gets a file
+// with all of them, the first one current.
+func handleShellenv(cfg config) {
+	var name string
+	useAlias, reset := false, false
+	for _, a := range os.Args[2:] {
+		switch a {
+		case "--alias":
+			useAlias = true
+		case "--reset":
+			reset = true
+		default:
+			name = a
+		}
+	}
+
+	if reset {
+		fmt.Println(restoreKubeconfigLine)
+		fmt.Println("unset KSW_CONTEXT")
+		fmt.Println("unalias kubectl 2>/dev/null")
+		return
+	}
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "Usage: eval \"$(ksw shellenv <context> [--alias])\"")
+		fmt.Fprintln(os.Stderr, "       eval \"$(ksw shellenv --reset)\"")
+		os.Exit(1)
+	}
+
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	targets, err := resolveTargets(cfg, name, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	ctx := targets[0]
+	if useAlias && len(targets) > 1 {
+		fmt.Fprintf(os.Stderr, "%s '%s' names %d contexts; --alias pins only one\n", warnStyle.Render("✗"), name, len(targets))
+		os.Exit(exitAmbiguous)
+	}
+
+	// This terminal can get the AWS profile the context needs, not just a warning
+	for _, kv := range awsProfileEnv(cfg, ctx) {
+		k, v, _ := strings.Cut(kv, "=")
+		fmt.Printf("export %s='%s'\n", k, shellQuote(v))
+	}
+	if useAlias {
+		fmt.Printf("alias kubectl='kubectl --context %s'\n", shellQuote(ctx))
+		fmt.Printf("export KSW_CONTEXT='%s'\n", shellQuote(ctx))
+		return
+	}
+
+	var path string
+	if len(targets) == 1 {
+		path, err = exportContextKubeconfig(ctx)
+	} else {
+		var data []byte
+		if data, err = subsetKubeconfig(targets); err == nil {
+			path, err = writeShellenvKubeconfig(targets, data)
+		}
+	}
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Println(saveKubeconfigLine)
+	fmt.Printf("export KUBECONFIG='%s'\n", shellQuote(path))
+	fmt.Printf("export KSW_CONTEXT='%s'\n", shellQuote(ctx))
+}
+
+// handleExec runs a command against a context without touching the current
+// context, through the same minimal kubeconfig ksw shellenv uses:
+// ksw exec [name] -- <command> [args...]; without a name KSW_CONTEXT is used
+func handleExec(cfg config) {
+	sep := slices.Index(os.Args, "--")
+	name := os.Getenv("KSW_CONTEXT")
+	if sep > 2 {
+		name = os.Args[2]
+	}
+	if sep < 2 || sep == len(os.Args)-1 || name == "" {
+		fmt.Fprintln(os.Stderr, "Usage: ksw exec <context> -- <command> [args...]")
+		fmt.Fprintln(os.Stderr, "       KSW_CONTEXT=<context> ksw exec -- <command> [args...]")
+		os.Exit(1)
+	}
+	name, err := aliasTarget(cfg, name)
+	if err != nil {
+		fatal(err)
+	}
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	ctx, err := resolveContext(name, contexts)
+	if err != nil {
+		fatal(err)
+	}
+	path, err := exportContextKubeconfig(ctx)
+	if err != nil {
+		fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[sep+1], os.Args[sep+2:]...)
+	cmd.Env = append(append(os.Environ(), "KUBECONFIG="+path, "KSW_CONTEXT="+ctx), awsProfileEnv(cfg, ctx)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.ExitCode())
+		}
+		fatal(err)
+	}
+}
+
+// handleK runs kubectl with --context set to what ksw resolves, without
+// switching: ksw k [--on <target>] <kubectl args...>. Without --on it uses the
+// context in effect for this terminal; a target naming several contexts runs
+// kubectl once on each.
+func handleK(cfg config) {
+	args := os.Args[2:]
+	target := ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "--on=") {
+		target, args = strings.TrimPrefix(args[0], "--on="), args[1:]
+	} else if len(args) > 1 && args[0] == "--on" {
+		target, args = args[1], args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ksw k [--on <context|@alias|group|glob>] <kubectl args...>")
+		os.Exit(1)
+	}
+
+	var targets []string
+	if target == "" {
+		ctx := effectiveContext()
+		if ctx == "" {
+			fmt.Fprintf(os.Stderr, "%s No current context; use --on <context>.\n", warnStyle.Render("✗"))
+			os.Exit(exitKubeconfig)
+		}
+		targets = []string{ctx}
+	} else {
+		contexts, err := getContexts()
+		if err != nil {
+			fatal(err)
+		}
+		if targets, err = resolveTargets(cfg, target, contexts); err != nil {
+			fatal(err)
+		}
+	}
+
+	code := 0
+	for _, ctx := range targets {
+		if len(targets) > 1 {
+			fmt.Fprintf(os.Stderr, "%s %s\n", aliasStyle.Render("──"), currentValueStyle.Render(ctx))
+		}
+		cmd := exec.Command("kubectl", append([]string{"--context", ctx}, args...)...)
+		cmd.Env = append(os.Environ(), awsProfileEnv(cfg, ctx)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			var ee *exec.ExitError
+			if !errors.As(err, &ee) {
+				fatal(err)
+			}
+			code = ee.ExitCode()
+		}
+	}
+	os.Exit(code)
+}
+
+// aliasTarget returns the context an @alias points to, and any other name
+// as it is: a bare name is a context name even when an alias shares it
+func aliasTarget(cfg config, name string) (string, error) {
+	alias, ok := strings.CutPrefix(name, "@")
+	if !ok {
+		return name, nil
+	}
+	target, ok := cfg.Aliases[alias]
+	if !ok {
+		return "", withExitCode(exitNotFound, fmt.Errorf("alias '@%s' not found", alias))
+	}
+	return target, nil
+}
+
+// resolveTargets expands a --on target into contexts: @alias (multi-target
+// ones too), a group, a glob, or a single name resolved as ksw <name> would.
+// @<group> works too when no alias has the group's name.
+func resolveTargets(cfg config, target string, contexts []string) ([]string, error) {
+	if name, ok := strings.CutPrefix(target, "@"); ok {
+		_, isGroup := cfg.Groups[name]
+		_, isAlias := cfg.Aliases[name]
+		if _, ok := cfg.MultiAliases[name]; isGroup && !ok && !isAlias {
+			return resolveTargets(cfg, name, contexts)
+		}
+		if targets, ok := cfg.MultiAliases[name]; ok {
+			var out []string
+			for _, t := range targets {
+				ctx, err := resolveContext(t, contexts)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, ctx)
+			}
+			return out, nil
+		}
+		t, ok := cfg.Aliases[name]
+		if !ok {
+			return nil, withExitCode(exitNotFound, fmt.Errorf("alias '@%s' not found", name))
+		}
+		target = t
+	} else if members, ok := cfg.Groups[target]; ok {
+		var out []string
+		for _, m := range members {
+			if slices.Contains(contexts, m) {
+				out = append(out, m)
+			}
+		}
+		if len(out) == 0 {
+			return nil, withExitCode(exitNotFound, fmt.Errorf("no context of group '%s' is in the kubeconfig", target))
+		}
+		return out, nil
+	}
+	if strings.ContainsAny(target, "*?") {
+		return resolveContexts(target, contexts)
+	}
+	ctx, err := resolveContext(target, contexts)
+	if err != nil {
+		return nil, err
+	}
+	return []string{ctx}, nil
+}
+
+// handleCurrent prints the context in effect for this terminal:
+// ksw current [--short]
+func handleCurrent(cfg config) {
+	ctx := effectiveContext()
+	if ctx == "" {
+		fmt.Fprintf(os.Stderr, "%s No current context.\n", warnStyle.Render("✗"))
+		os.Exit(exitKubeconfig)
+	}
+	if len(os.Args) >= 3 && os.Args[2] == "--short" {
+		ctx = shortName(ctx)
+	}
+	fmt.Println(ctx)
+}
+
+// handlePrompt prints a short, uncolored segment for shell prompts, e.g.
+// "⎈ payments-dev"; a "*" marks a KSW_CONTEXT override. It prints nothing
+// when there is no context so prompts stay clean.
+func handlePrompt(cfg config) {
+	ctx := effectiveContext()
+	if ctx == "" {
+		return
+	}
+	name := shortName(ctx)
+	for alias, target := range cfg.Aliases {
+		if target == ctx || target == name {
+			name = "@" + alias
+			break
+		}
+	}
+	mark := ""
+	if os.Getenv("KSW_CONTEXT") != "" {
+		mark = "*"
+	}
+	fmt.Printf("⎈ %s%s\n", name, mark)
+}
+
+// shellQuote escapes s for use inside single quotes
+func shellQuote(s string) string {
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
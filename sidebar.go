@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Groups sidebar (Ctrl+B) ────────────────────────────
+
+// Fixed sidebar entries above the groups
+const (
+	sidebarAll    = "All"
+	sidebarPinned = "★ Pinned"
+	sidebarRecent = "Recent"
+)
+
+// sidebarItems lists the fixed entries, then every group by name
+func (m *model) sidebarItems() []string {
+	items := []string{sidebarAll, sidebarPinned, sidebarRecent}
+	groups := make([]string, 0, len(m.cfg.Groups))
+	for g := range m.cfg.Groups {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	return append(items, groups...)
+}
+
+// sidebarIndex is the sidebar entry matching the current filter
+func (m *model) sidebarIndex() int {
+	switch {
+	case m.activeGroup != "":
+		for i, item := range m.sidebarItems()[3:] {
+			if item == m.activeGroup {
+				return i + 3
+			}
+		}
+	case m.showPinnedOnly:
+		return 1
+	case m.recentOnly:
+		return 2
+	}
+	return 0
+}
+
+// selectSidebar filters the list to sidebar entry i
+func (m *model) selectSidebar(i int) {
+	items := m.sidebarItems()
+	if i < 0 || i >= len(items) {
+		return
+	}
+	m.sidebarCursor = i
+	m.activeGroup, m.showPinnedOnly, m.recentOnly = "", false, false
+	switch {
+	case i == 1:
+		m.showPinnedOnly = true
+	case i == 2:
+		m.recentOnly = true
+	case i >= 3:
+		m.activeGroup = items[i]
+	}
+	if m.search != "" {
+		m.applyFilter()
+	} else {
+		m.resetFilter()
+	}
+	m.cursor = 0
+	m.scrollOffset = 0
+}
+
+// sidebarLabel is entry i as shown: groups carry their size
+func (m *model) sidebarLabel(items []string, i int) string {
+	if i < 3 {
+		return items[i]
+	}
+	return items[i] + " " + fmt.Sprint(len(m.cfg.Groups[items[i]]))
+}
+
+// sidebarWidth is the sidebar's width including its border, 0 when hidden
+func (m *model) sidebarWidth() int {
+	if !m.showSidebar {
+		return 0
+	}
+	items := m.sidebarItems()
+	w := 0
+	for i := range items {
+		w = max(w, lipgloss.Width(m.sidebarLabel(items, i)))
+	}
+	return min(w+7, 28, max(12, m.terminalWidth/3)) // "  ❯ " + label + " │ "
+}
+
+// listWidth is what's left for the context list next to the sidebar
+func (m *model) listWidth() int {
+	return m.terminalWidth - m.sidebarWidth()
+}
+
+// sidebarView renders the sidebar as height lines
+func (m *model) sidebarView(height int) string {
+	width := m.sidebarWidth()
+	items := m.sidebarItems()
+	lines := make([]string, 0, height)
+	// Keep the highlighted entry in view when there are more groups than lines
+	start := max(0, min(m.sidebarCursor-height+1, len(items)-height))
+	for i := start; i < len(items) && len(lines) < height; i++ {
+		label := m.sidebarLabel(items, i)
+		if lipgloss.Width(label) > width-7 {
+			label = truncateEnd(label, width-7)
+		}
+		pointer := "  "
+		switch {
+		case i == m.sidebarCursor && m.sidebarFocus:
+			pointer, label = "❯ ", selectedItemStyle.Render(label)
+		case i == m.sidebarCursor:
+			label = activeItemStyle.Render(label)
+		case i < 3:
+			label = dimStyle.Render(label)
+		}
+		lines = append(lines, "  "+pointer+label)
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	border := dimStyle.Render("│")
+	for i, l := range lines {
+		lines[i] = l + strings.Repeat(" ", max(1, width-2-lipgloss.Width(l))) + border + " "
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// ── TUI sort modes (Ctrl+S) ────────────────────────────
+
+// sortModes are the orders Ctrl+S cycles through; "pins" is the default.
+// They apply to the unfiltered list: while searching, the best match comes first.
+var sortModes = []string{"pins", "alpha", "recent", "frecency", "group"}
+
+// sortMode is cfg.Sort, or "pins" when unset or unknown
+func (m *model) sortMode() string {
+	if slices.Contains(sortModes, m.cfg.Sort) {
+		return m.cfg.Sort
+	}
+	return "pins"
+}
+
+// nextSortMode returns the mode after the current one
+func (m *model) nextSortMode() string {
+	i := slices.Index(sortModes, m.sortMode())
+	return sortModes[(i+1)%len(sortModes)]
+}
+
+// sortIndices orders the unfiltered list for the active sort mode; ties keep
+// kubeconfig order
+func (m *model) sortIndices(indices []int) []int {
+	switch m.sortMode() {
+	case "alpha":
+		name := func(idx int) string {
+			if m.shortNames {
+				return shortName(m.contexts[idx])
+			}
+			return m.contexts[idx]
+		}
+		slices.SortStableFunc(indices, func(a, b int) int { return cmp.Compare(name(a), name(b)) })
+	case "recent":
+		usage := usageStats(m.cfg)
+		last := func(idx int) int64 {
+			if m.contexts[idx] == m.current {
+				return 1<<63 - 1
+			}
+			return usage[m.contexts[idx]].LastUsed
+		}
+		slices.SortStableFunc(indices, func(a, b int) int { return cmp.Compare(last(b), last(a)) })
+	case "frecency":
+		scores := frecency(m.cfg, time.Now())
+		slices.SortStableFunc(indices, func(a, b int) int {
+			return cmp.Compare(scores[m.contexts[b]], scores[m.contexts[a]])
+		})
+	case "group":
+		// By group name, each context under the first group it's in; ungrouped last
+		groups := m.sidebarItems()[3:]
+		rank := make(map[string]int)
+		for i, g := range groups {
+			for _, ctx := range m.cfg.Groups[g] {
+				if _, ok := rank[ctx]; !ok {
+					rank[ctx] = i
+				}
+			}
+		}
+		pos := func(idx int) int {
+			if r, ok := rank[m.contexts[idx]]; ok {
+				return r
+			}
+			return len(groups)
+		}
+		slices.SortStableFunc(indices, func(a, b int) int { return cmp.Compare(pos(a), pos(b)) })
+	default:
+		return m.sortedByPins(indices)
+	}
+	return indices
+}
+
+// frecency scores each context by its switches, recent ones weighing more:
+// 4 in the last day, 2 in the last week, 1 in the last month, ¼ before that
+func frecency(cfg config, now time.Time) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, e := range cfg.HistoryLog {
+		age := now.Sub(time.Unix(e.Time, 0))
+		switch {
+		case age < 24*time.Hour:
+			scores[e.Context] += 4
+		case age < 7*24*time.Hour:
+			scores[e.Context] += 2
+		case age < 30*24*time.Hour:
+			scores[e.Context]++
+		default:
+			scores[e.Context] += 0.25
+		}
+	}
+	return scores
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ── Stats ──────────────────────────────────────────────
+
+// heatShades maps a cell's share of the busiest cell to a block character
+var heatShades = []string{"·", "░", "▒", "▓", "█"}
+
+// weekdayOrder lists rows Monday first, the way most people read a week
+var weekdayOrder = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+type statsContext struct {
+	Context  string `json:"context"`
+	Switches int    `json:"switches"`
+	Seconds  int64  `json:"seconds"`
+}
+
+type statsReport struct {
+	Since    string         `json:"since,omitempty"`
+	Context  string         `json:"context,omitempty"`
+	Switches int            `json:"switches"`
+	Top      []statsContext `json:"top"`
+	// Heatmap[weekday][hour] counts switches in local time, weekday 0 = Sunday
+	Heatmap [7][24]int `json:"heatmap"`
+}
+
+// buildStats aggregates the switch log; pattern (a glob) limits it to matching contexts
+func buildStats(cfg config, since time.Duration, pattern string) statsReport {
+	var r statsReport
+	now := time.Now()
+	byCtx := make(map[string]*statsContext)
+	for i, e := range cfg.HistoryLog {
+		at := time.Unix(e.Time, 0)
+		if since > 0 && at.Before(now.Add(-since)) {
+			continue
+		}
+		if pattern != "" && !globMatch(pattern, e.Context) {
+			continue
+		}
+		end := now.Unix()
+		if i+1 < len(cfg.HistoryLog) {
+			end = cfg.HistoryLog[i+1].Time
+		}
+		s, ok := byCtx[e.Context]
+		if !ok {
+			s = &statsContext{Context: e.Context}
+			byCtx[e.Context] = s
+		}
+		s.Switches++
+		s.Seconds += end - e.Time
+		r.Switches++
+		r.Heatmap[at.Weekday()][at.Hour()]++
+	}
+	for _, s := range byCtx {
+		r.Top = append(r.Top, *s)
+	}
+	sort.Slice(r.Top, func(i, j int) bool {
+		if r.Top[i].Switches != r.Top[j].Switches {
+			return r.Top[i].Switches > r.Top[j].Switches
+		}
+		return r.Top[i].Context < r.Top[j].Context
+	})
+	if r.Top == nil {
+		r.Top = []statsContext{}
+	}
+	return r
+}
+
+// renderHeatmap draws one row per weekday and one column per hour
+func renderHeatmap(h [7][24]int) string {
+	peak := 0
+	for _, row := range h {
+		for _, n := range row {
+			peak = max(peak, n)
+		}
+	}
+	var b strings.Builder
+	b.WriteString("      ")
+	for hour := 0; hour < 24; hour += 3 {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%-6d", hour)))
+	}
+	b.WriteString("\n")
+	for _, d := range weekdayOrder {
+		b.WriteString(fmt.Sprintf("  %s ", d.String()[:3]))
+		for _, n := range h[d] {
+			shade := 0
+			if n > 0 {
+				// Any activity gets at least the lightest block
+				shade = 1 + (n*(len(heatShades)-2))/peak
+			}
+			cell := heatShades[shade]
+			if shade == 0 {
+				cell = dimStyle.Render(cell)
+			} else {
+				cell = successStyle.Render(cell)
+			}
+			b.WriteString(cell + " ")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatSeconds renders a duration as e.g. "3d 4h", "2h 10m" or "45m"
+func formatSeconds(s int64) string {
+	d := time.Duration(s) * time.Second
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// handleStats reports switch counts, time spent and a weekday/hour heatmap:
+// ksw stats [--since 30d] [--context <glob>] [--json] [--queries [on|off]]
+func handleStats(cfg config) {
+	var since time.Duration
+	sinceArg, pattern, asJSON := "", "", false
+	queries, toggle := false, ""
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--since":
+			if i+1 < len(os.Args) {
+				d, err := parseSince(os.Args[i+1])
+				if err != nil {
+					fatal(err)
+				}
+				since, sinceArg = d, os.Args[i+1]
+				i++
+			}
+		case "--context", "-c":
+			if i+1 < len(os.Args) {
+				pattern = os.Args[i+1]
+				i++
+			}
+		case "--json":
+			asJSON = true
+		case "--queries":
+			queries = true
+			if i+1 < len(os.Args) && (os.Args[i+1] == "on" || os.Args[i+1] == "off") {
+				toggle = os.Args[i+1]
+				i++
+			}
+		}
+	}
+	if queries {
+		handleQueryStats(cfg, toggle, since, sinceArg, pattern, asJSON)
+		return
+	}
+
+	r := buildStats(cfg, since, pattern)
+	r.Since, r.Context = sinceArg, pattern
+	if asJSON {
+		data, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if r.Switches == 0 {
+		fmt.Println(dimStyle.Render("No switches recorded yet."))
+		return
+	}
+
+	fmt.Printf("%s %d switches across %d contexts\n\n", successStyle.Render("✔"), r.Switches, len(r.Top))
+	top := r.Top
+	if len(top) > 5 {
+		top = top[:5]
+	}
+	for _, s := range top {
+		fmt.Printf("  %-40s %s %s\n", s.Context, currentValueStyle.Render(fmt.Sprintf("%4d×", s.Switches)), dimStyle.Render(formatSeconds(s.Seconds)))
+	}
+	fmt.Println()
+	fmt.Print(renderHeatmap(r.Heatmap))
+	fmt.Println()
+	fmt.Println(dimStyle.Render("  Local time · " + strings.Join(heatShades[1:], " ") + " = fewer → more switches"))
+}
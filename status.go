@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── TUI status line ────────────────────────────────────
+
+// statusTTL is how long feedback for an in-TUI action stays on screen
+const statusTTL = 3 * time.Second
+
+// clearStatusMsg clears the status line, unless a newer message replaced it
+type clearStatusMsg struct{ id int }
+
+// setStatus shows text above the footer until statusTTL passes
+func (m *model) setStatus(text string) tea.Cmd {
+	m.statusID++
+	m.status = text
+	id := m.statusID
+	return tea.Tick(statusTTL, func(time.Time) tea.Msg { return clearStatusMsg{id} })
+}
+
+// saveStatus persists m.cfg and reports done, or the error if saving failed
+func (m *model) saveStatus(done string) tea.Cmd {
+	if err := saveConfig(m.cfg); err != nil {
+		return m.setStatus(warnStyle.Render("✗ couldn't save config: " + err.Error()))
+	}
+	return m.setStatus(done)
+}
+
+// onOff renders a toggle's new state for the status line
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ── Subprocess timeouts ────────────────────────────────
+
+// defaultCommandTimeout bounds each kubectl and aws call ksw waits on, unless
+// timeout_seconds says otherwise. A hanging exec-credential plugin or an
+// unreachable API server then fails the call instead of freezing ksw.
+const defaultCommandTimeout = 20 * time.Second
+
+var commandTimeout = defaultCommandTimeout
+
+func setCommandTimeout(cfg config) {
+	if cfg.Timeout > 0 {
+		commandTimeout = time.Duration(cfg.Timeout) * time.Second
+	}
+}
+
+// commandParent is what bounded commands hang off. The TUI swaps in its own
+// while it runs (killCommandsOnQuit), so quitting kills loads still in flight
+// instead of leaving kubectl behind.
+var (
+	commandParentMu sync.Mutex
+	commandParent   = context.Background()
+)
+
+func killCommandsOnQuit() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	commandParentMu.Lock()
+	commandParent = ctx
+	commandParentMu.Unlock()
+	return func() {
+		cancel()
+		commandParentMu.Lock()
+		commandParent = context.Background()
+		commandParentMu.Unlock()
+	}
+}
+
+// command is exec.Command for a kubectl or aws call ksw waits on: it's killed
+// after commandTimeout or once the TUI quits. Pass done the command's error
+// when it has finished; it releases the timer, names the timeout when that
+// is what killed it and logs the call under --debug. Commands the user drives themselves (ksw exec, k, logs,
+// forward) are left unbounded, and get Ctrl+C from the terminal directly.
+func command(name string, args ...string) (*exec.Cmd, func(error) error) {
+	commandParentMu.Lock()
+	parent := commandParent
+	commandParentMu.Unlock()
+	return commandContext(parent, name, args...)
+}
+
+// commandContext is command for callers that stop on Ctrl+C themselves
+func commandContext(parent context.Context, name string, args ...string) (*exec.Cmd, func(error) error) {
+	ctx, cancel := context.WithTimeout(parent, commandTimeout)
+	cmd := exec.CommandContext(ctx, name, args...)
+	// A credential plugin killed along with kubectl may leave a child holding
+	// its output open; don't wait on it
+	cmd.WaitDelay = time.Second
+	start := time.Now()
+	return cmd, func(err error) error {
+		defer cancel()
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%s timed out after %s (timeout_seconds in the config): %w", name, commandTimeout, err)
+		}
+		traceCommand(cmd, start, err)
+		return err
+	}
+}
+
+// output runs a bounded command for its stdout, like exec.Cmd.Output
+func output(name string, args ...string) ([]byte, error) {
+	cmd, done := command(name, args...)
+	out, err := cmd.Output()
+	return out, done(err)
+}
+
+// combinedOutput runs a bounded command for its stdout and stderr
+func combinedOutput(name string, args ...string) ([]byte, error) {
+	cmd, done := command(name, args...)
+	out, err := cmd.CombinedOutput()
+	return out, done(err)
+}
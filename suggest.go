@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// ── ksw suggest ────────────────────────────────────────
+
+const (
+	// suggestMinUses is how many switches in suggestWindow make a context
+	// worth an alias
+	suggestMinUses = 5
+	suggestWindow  = 30 * 24 * time.Hour
+	// suggestMinLen is the shortest name worth an alias; ARNs always are
+	suggestMinLen = 16
+	// suggestHintEvery spaces out the hint after a switch
+	suggestHintEvery = 7 * 24 * time.Hour
+)
+
+// aliasSuggestion proposes alias for a context switched to uses times lately
+type aliasSuggestion struct {
+	ctx   string
+	alias string
+	uses  int
+}
+
+var nameWordRe = regexp.MustCompile(`[a-z0-9]+`)
+
+// aliasCandidates are the names to try for ctx, shortest first:
+// payments-pdn → pay, pay-pdn, pp, payments-pdn
+func aliasCandidates(ctx string) []string {
+	short := strings.ToLower(shortName(ctx))
+	words := nameWordRe.FindAllString(short, -1)
+	if len(words) == 0 {
+		return nil
+	}
+	first := words[0][:min(3, len(words[0]))]
+	var out []string
+	add := func(s string) {
+		if s != "" && !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	add(first)
+	if len(words) > 1 {
+		add(first + "-" + words[len(words)-1])
+		initials := ""
+		for _, w := range words {
+			initials += w[:1]
+		}
+		add(initials)
+	}
+	add(strings.Join(words, "-"))
+	return out
+}
+
+// hasAlias reports whether any alias leads to ctx alone
+func hasAlias(cfg config, ctx string) bool {
+	for _, target := range cfg.Aliases {
+		if target == ctx {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasSuggestions lists the contexts in contexts switched to at least
+// suggestMinUses times in suggestWindow that have a long name, no alias and
+// weren't declined before, most used first, each with a free alias
+func aliasSuggestions(cfg config, contexts []string, now time.Time) []aliasSuggestion {
+	uses := make(map[string]int)
+	for _, e := range cfg.HistoryLog {
+		if now.Sub(time.Unix(e.Time, 0)) < suggestWindow {
+			uses[e.Context]++
+		}
+	}
+	var out []aliasSuggestion
+	for ctx, n := range uses {
+		if n < suggestMinUses || !slices.Contains(contexts, ctx) || hasAlias(cfg, ctx) ||
+			slices.Contains(cfg.NoSuggest, ctx) || slices.Contains(cfg.Archived, ctx) {
+			continue
+		}
+		if len(ctx) < suggestMinLen && !strings.Contains(ctx, "/") {
+			continue
+		}
+		out = append(out, aliasSuggestion{ctx: ctx, uses: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].uses != out[j].uses {
+			return out[i].uses > out[j].uses
+		}
+		return out[i].ctx < out[j].ctx
+	})
+
+	// The most used context gets the shortest free name
+	proposed := make(map[string]bool)
+	var kept []aliasSuggestion
+	for _, s := range out {
+		for _, name := range aliasCandidates(s.ctx) {
+			_, single := cfg.Aliases[name]
+			_, multi := cfg.MultiAliases[name]
+			if !single && !multi && !proposed[name] {
+				s.alias = name
+				proposed[name] = true
+				kept = append(kept, s)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// suggestHint is run after a switch: at most once a week, a context that
+// deserves an alias gets a one-line pointer to ksw suggest
+func suggestHint(cfg config, to string) {
+	now := time.Now()
+	if now.Sub(time.Unix(cfg.SuggestHintAt, 0)) < suggestHintEvery {
+		return
+	}
+	for _, s := range aliasSuggestions(cfg, []string{to}, now) {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", dimStyle.Render("·"), dimStyle.Render(fmt.Sprintf(
+			"%d switches to %s this month · ksw suggest makes it @%s", s.uses, shortName(to), s.alias)))
+		cfg.SuggestHintAt = now.Unix()
+		_ = saveConfig(cfg)
+	}
+}
+
+// readKey reads a single key press from the terminal after printing prompt
+// on stderr; ok is false when stdin or stderr isn't a terminal
+func readKey(prompt string) (key byte, ok bool) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) || !stderrIsTerminal() {
+		return 0, false
+	}
+	fmt.Fprintf(os.Stderr, "  %s ", dimStyle.Render(prompt))
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, false
+	}
+	buf := make([]byte, 1)
+	_, err = os.Stdin.Read(buf)
+	_ = term.Restore(fd, state)
+	fmt.Fprintln(os.Stderr)
+	return buf[0], err == nil
+}
+
+// handleSuggest: ksw suggest. Offers an alias for each context used often
+// enough to deserve one; y takes it, n never asks about that context again.
+func handleSuggest(cfg config) {
+	contexts, err := getContexts()
+	if err != nil {
+		fatal(err)
+	}
+	suggestions := aliasSuggestions(cfg, contexts, time.Now())
+	if len(suggestions) == 0 {
+		fmt.Println(dimStyle.Render("No alias suggestions: every context you use often is short or has an alias."))
+		return
+	}
+
+	changed := false
+ask:
+	for _, s := range suggestions {
+		fmt.Printf("  %s → %s %s\n", aliasStyle.Render("@"+s.alias), s.ctx,
+			dimStyle.Render(fmt.Sprintf("(%d switches in 30 days)", s.uses)))
+		key, ok := readKey("y add · n never for this context · s skip · q quit:")
+		if !ok {
+			fmt.Printf("    %s\n", dimStyle.Render("ksw alias "+s.alias+" "+s.ctx))
+			continue
+		}
+		switch key {
+		case 'y', 'Y':
+			cfg.Aliases[s.alias] = s.ctx
+			changed = true
+			fmt.Printf("  %s Added %s\n", successStyle.Render("✔"), aliasStyle.Render("@"+s.alias))
+		case 'n', 'N':
+			cfg.NoSuggest = append(cfg.NoSuggest, s.ctx)
+			changed = true
+		case 'q', 'Q', 3, 27: // Ctrl+C and Esc quit too
+			break ask
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := saveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── --debug trace log ──────────────────────────────────
+
+// debugFlag is --debug: every kubectl and aws call ksw waits on is logged to
+// debugLogPath with its arguments, duration and exit status, to find what
+// makes a switch slow (usually an exec-credential plugin)
+var debugFlag bool
+
+// debugLogMax is how big the log grows before the next run starts it over,
+// keeping the previous one as debug.log.old
+const debugLogMax = 1 << 20
+
+var (
+	traceMu   sync.Mutex
+	traceFile *os.File
+)
+
+func debugLogPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ksw", "debug.log")
+}
+
+// startTrace opens the trace log and heads this run's entries with the
+// command line. Tracing stays off if the log can't be opened.
+func startTrace() {
+	path := debugLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "%s --debug: %v\n", warnStyle.Render("⚠"), err)
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > debugLogMax {
+		_ = os.Rename(path, path+".old")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s --debug: %v\n", warnStyle.Render("⚠"), err)
+		return
+	}
+	traceFile = f
+	fmt.Fprintf(f, "\n--- %s ksw v%s (pid %d): %s\n", time.Now().Format(time.RFC3339), version, os.Getpid(), strings.Join(redactSecrets(os.Args), " "))
+	fmt.Fprintf(os.Stderr, "%s\n", dimStyle.Render("· Tracing kubectl and aws calls to "+path))
+}
+
+// traceCommand logs one finished command: when it started, how long it took,
+// how it ended and what it was
+func traceCommand(cmd *exec.Cmd, start time.Time, err error) {
+	if traceFile == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %8s  %-10s %s\n", start.Format("15:04:05.000"),
+		time.Since(start).Round(time.Millisecond), exitStatus(err), traceArgs(cmd.Args))
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	_, _ = traceFile.WriteString(line)
+}
+
+// exitStatus is how a command ended, briefly
+func exitStatus(err error) string {
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return "exit 0"
+	case strings.Contains(err.Error(), "timed out after"):
+		return "timed out"
+	case errors.As(err, &exit) && exit.ExitCode() >= 0:
+		return fmt.Sprintf("exit %d", exit.ExitCode())
+	case errors.As(err, &exit):
+		return "killed"
+	}
+	return "failed: " + err.Error()
+}
+
+// redactSecrets hides the value of --token, a credential, and leaves the
+// rest of argv as it is, unlike the crash report's redactArgs
+func redactSecrets(argv []string) []string {
+	out := slices.Clone(argv)
+	for i, a := range out {
+		if strings.HasPrefix(a, "--token=") {
+			out[i] = "--token=<redacted>"
+		} else if a == "--token" && i+1 < len(out) && out[i+1] != "-" {
+			out[i+1] = "<redacted>"
+		}
+	}
+	return out
+}
+
+// traceArgs renders argv for the log, quoting where a shell would need it
+// and cutting long values (a Bedrock request carries the whole prompt)
+func traceArgs(argv []string) string {
+	parts := make([]string, len(argv))
+	for i, a := range redactSecrets(argv) {
+		if len(a) > 120 {
+			a = fmt.Sprintf("%s…(%d bytes)", strings.ToValidUTF8(a[:80], ""), len(a))
+		}
+		if a == "" || strings.ContainsAny(a, " \t\n'\"$\\{}*") {
+			a = "'" + shellQuote(a) + "'"
+		}
+		parts[i] = a
+	}
+	return strings.Join(parts, " ")
+}
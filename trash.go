@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Trash ──────────────────────────────────────────────
+
+// trashEntry is a removed alias, pin or group, kept so it can be restored
+type trashEntry struct {
+	Kind    string   `json:"kind"`              // "alias", "pin" or "group"
+	Name    string   `json:"name"`              // alias or group name, pinned context
+	Targets []string `json:"targets,omitempty"` // alias target(s) or group members
+	Multi   bool     `json:"multi,omitempty"`   // a multi-alias, even with one target left
+	Default string   `json:"default,omitempty"` // the group's default context
+	Color   string   `json:"color,omitempty"`   // the group's color
+	Deleted int64    `json:"deleted"`
+	By      string   `json:"by,omitempty"` // "ai" when an AI action removed it
+}
+
+const maxTrash = 100
+
+func (cfg *config) addTrash(e trashEntry) {
+	e.Deleted = time.Now().Unix()
+	cfg.Trash = append(cfg.Trash, e)
+	if len(cfg.Trash) > maxTrash {
+		cfg.Trash = cfg.Trash[len(cfg.Trash)-maxTrash:]
+	}
+}
+
+// trashAlias moves a single or multi alias to the trash
+func trashAlias(cfg *config, name, by string) {
+	if target, ok := cfg.Aliases[name]; ok {
+		cfg.addTrash(trashEntry{Kind: "alias", Name: name, Targets: []string{target}, By: by})
+		delete(cfg.Aliases, name)
+	}
+	if targets, ok := cfg.MultiAliases[name]; ok {
+		cfg.addTrash(trashEntry{Kind: "alias", Name: name, Targets: targets, Multi: true, By: by})
+		delete(cfg.MultiAliases, name)
+	}
+}
+
+// trashPin unpins ctx, keeping it in the trash
+func trashPin(cfg *config, ctx, by string) {
+	if !slices.Contains(cfg.Pins, ctx) {
+		return
+	}
+	cfg.addTrash(trashEntry{Kind: "pin", Name: ctx, By: by})
+	cfg.Pins = slices.DeleteFunc(cfg.Pins, func(p string) bool { return p == ctx })
+}
+
+// trashGroup moves a group, with its members, default and color, to the trash
+func trashGroup(cfg *config, name, by string) {
+	members, ok := cfg.Groups[name]
+	if !ok {
+		return
+	}
+	cfg.addTrash(trashEntry{Kind: "group", Name: name, Targets: members, Default: cfg.GroupDefault[name],
+		Color: cfg.GroupColors[name], By: by})
+	delete(cfg.Groups, name)
+	delete(cfg.GroupDefault, name)
+	delete(cfg.GroupColors, name)
+}
+
+// restoreTrash puts entry i back, refusing to overwrite something that
+// was created with the same name since
+func restoreTrash(cfg *config, i int) error {
+	e := cfg.Trash[i]
+	switch e.Kind {
+	case "alias":
+		_, single := cfg.Aliases[e.Name]
+		_, multi := cfg.MultiAliases[e.Name]
+		if single || multi {
+			return fmt.Errorf("alias @%s already exists", e.Name)
+		}
+		if e.Multi {
+			cfg.MultiAliases[e.Name] = e.Targets
+		} else if len(e.Targets) > 0 {
+			cfg.Aliases[e.Name] = e.Targets[0]
+		}
+	case "pin":
+		if !slices.Contains(cfg.Pins, e.Name) {
+			cfg.Pins = append(cfg.Pins, e.Name)
+		}
+	case "group":
+		if _, ok := cfg.Groups[e.Name]; ok {
+			return fmt.Errorf("group %s already exists", e.Name)
+		}
+		cfg.Groups[e.Name] = e.Targets
+		if e.Default != "" {
+			if cfg.GroupDefault == nil {
+				cfg.GroupDefault = make(map[string]string)
+			}
+			cfg.GroupDefault[e.Name] = e.Default
+		}
+		if e.Color != "" {
+			if cfg.GroupColors == nil {
+				cfg.GroupColors = make(map[string]string)
+			}
+			cfg.GroupColors[e.Name] = e.Color
+		}
+	}
+	cfg.Trash = slices.Delete(cfg.Trash, i, i+1)
+	return nil
+}
+
+// describe renders an entry for ksw trash ls
+func (e trashEntry) describe() string {
+	switch e.Kind {
+	case "alias":
+		return aliasStyle.Render("@"+e.Name) + " → " + strings.Join(e.Targets, ", ")
+	case "group":
+		return "group " + aliasStyle.Render(e.Name) + dimStyle.Render(fmt.Sprintf(" (%d contexts)", len(e.Targets)))
+	default:
+		return "pin " + e.Name
+	}
+}
+
+// handleTrash lists and restores removed aliases, pins and groups:
+// ksw trash [ls] | ksw trash restore <n|name> | ksw trash empty
+func handleTrash(cfg config) {
+	sub := "ls"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+	switch sub {
+	case "ls", "list":
+		if len(cfg.Trash) == 0 {
+			fmt.Println(dimStyle.Render("Trash is empty."))
+			return
+		}
+		// Newest first, numbered for ksw trash restore <n>
+		for n := 1; n <= len(cfg.Trash); n++ {
+			e := cfg.Trash[len(cfg.Trash)-n]
+			by := ""
+			if e.By != "" {
+				by = " " + dimStyle.Render("by "+e.By)
+			}
+			fmt.Printf("  %s %s  %s%s\n", counterStyle.Render(fmt.Sprintf("%2d", n)), e.describe(),
+				dimStyle.Render(time.Unix(e.Deleted, 0).Format("2006-01-02 15:04")), by)
+		}
+
+	case "restore":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw trash restore <n|name>")
+			os.Exit(1)
+		}
+		arg := strings.TrimPrefix(os.Args[3], "@")
+		i := -1
+		if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(cfg.Trash) {
+			i = len(cfg.Trash) - n
+		} else {
+			// Most recently removed entry with that name
+			for j := len(cfg.Trash) - 1; j >= 0; j-- {
+				if cfg.Trash[j].Name == arg || shortName(cfg.Trash[j].Name) == arg {
+					i = j
+					break
+				}
+			}
+		}
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "%s '%s' not found in trash. Use 'ksw trash ls' to list.\n", warnStyle.Render("✗"), os.Args[3])
+			os.Exit(exitNotFound)
+		}
+		e := cfg.Trash[i]
+		if err := restoreTrash(&cfg, i); err != nil {
+			fatal(err)
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Restored %s\n", successStyle.Render("✔"), e.describe())
+
+	case "empty":
+		n := len(cfg.Trash)
+		cfg.Trash = nil
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Emptied trash (%d entries)\n", successStyle.Render("✔"), n)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown trash subcommand '%s'.\nUsage: ksw trash <ls|restore|empty>\n", sub)
+		os.Exit(1)
+	}
+}
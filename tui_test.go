@@ -0,0 +1,714 @@
+package main
+
+import (
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current View output")
+
+// runKeys feeds msgs through Update as the bubbletea runtime would and
+// returns the final model
+func runKeys(t *testing.T, m model, msgs ...tea.Msg) model {
+	t.Helper()
+	m = sendMsg(m, tea.WindowSizeMsg{Width: 100, Height: 20})
+	for _, msg := range msgs {
+		m = sendMsg(m, msg)
+	}
+	return m
+}
+
+func sendMsg(m model, msg tea.Msg) model {
+	next, _ := m.Update(msg)
+	return next.(model)
+}
+
+func typeText(s string) tea.Msg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// assertGolden compares got with testdata/<name>.golden; go test -update
+// rewrites the file instead
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("View() does not match %s (go test -update to accept)\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestViewSnapshots(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		keys []tea.Msg
+	}{
+		{name: "initial"},
+		{name: "search", keys: []tea.Msg{typeText("prod")}},
+		{name: "search_no_match", keys: []tea.Msg{typeText("zzz")}},
+		{name: "cursor_down", keys: []tea.Msg{tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}}},
+		{name: "short_names", keys: []tea.Msg{tea.KeyMsg{Type: tea.KeyCtrlH}}},
+		{name: "pinned", cfg: config{Pins: []string{testContexts[3]}}},
+		{name: "aliases", cfg: config{Aliases: map[string]string{"d": "payments-dev", "p": "payments-prod"}}},
+		{name: "expired", cfg: config{Expiry: map[string]string{testContexts[1]: "2000-01-01"}}},
+		{name: "narrow", keys: []tea.Msg{tea.WindowSizeMsg{Width: 50, Height: 20}}},
+		{name: "narrow_pinned", cfg: config{Pins: []string{testContexts[3]}}, keys: []tea.Msg{tea.WindowSizeMsg{Width: 40, Height: 20}}},
+		{name: "narrow_truncate_end", cfg: config{Truncate: "end"}, keys: []tea.Msg{tea.WindowSizeMsg{Width: 50, Height: 20}}},
+		{name: "help", cfg: config{Pins: []string{testContexts[3]}}, keys: []tea.Msg{tea.WindowSizeMsg{Width: 100, Height: 40}, tea.KeyMsg{Type: tea.KeyCtrlF}, typeText("?")}},
+		{name: "help_short_terminal", keys: []tea.Msg{typeText("?")}},
+		{name: "sidebar", cfg: config{ShowSidebar: true, Groups: map[string][]string{"payments": testContexts[:3], "search": testContexts[3:4]}}},
+		{name: "sidebar_group", cfg: config{ShowSidebar: true, Groups: map[string][]string{"payments": testContexts[:3], "search": testContexts[3:4]}},
+			keys: []tea.Msg{tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyEnd}}},
+		{name: "sort_alpha", cfg: config{Sort: "alpha"}, keys: []tea.Msg{tea.KeyMsg{Type: tea.KeyCtrlH}}},
+		{name: "short_terminal", keys: []tea.Msg{tea.WindowSizeMsg{Width: 120, Height: 10}, tea.KeyMsg{Type: tea.KeyEnd}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeKube(t, testContexts[0])
+			writeConfig(t, tt.cfg)
+			contexts, _ := f.Contexts()
+			m := runKeys(t, initialModel(contexts, f.Current(), loadConfig(), "", false), tt.keys...)
+			assertGolden(t, tt.name, m.View())
+		})
+	}
+}
+
+func TestEnterChoosesHighlighted(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false),
+		typeText("search"), tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != testContexts[3] {
+		t.Errorf("chosen = %q, want %s", m.chosen, testContexts[3])
+	}
+}
+
+func TestEscapeClearsSearchThenQuits(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText("qa"))
+	if len(m.filtered) != 1 {
+		t.Fatalf("filtered = %d contexts for 'qa', want 1", len(m.filtered))
+	}
+	m = sendMsg(m, tea.KeyMsg{Type: tea.KeyEscape})
+	if m.search != "" || len(m.filtered) != len(testContexts) || m.quitting {
+		t.Fatalf("first Esc: search = %q, filtered = %d, quitting = %v", m.search, len(m.filtered), m.quitting)
+	}
+	m = sendMsg(m, tea.KeyMsg{Type: tea.KeyEscape})
+	if !m.quitting || m.chosen != "" {
+		t.Errorf("second Esc: quitting = %v, chosen = %q", m.quitting, m.chosen)
+	}
+}
+
+func TestCtrlPTogglesPinAndSaves(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false),
+		typeText("docker"), tea.KeyMsg{Type: tea.KeyCtrlP})
+	if pins := loadConfig().Pins; !slices.Equal(pins, []string{"docker-desktop"}) {
+		t.Fatalf("pins after Ctrl+P = %v", pins)
+	}
+	sendMsg(m, tea.KeyMsg{Type: tea.KeyCtrlP})
+	cfg := loadConfig()
+	if len(cfg.Pins) != 0 || len(cfg.Trash) != 1 {
+		t.Errorf("after second Ctrl+P: pins = %v, trash = %v", cfg.Pins, cfg.Trash)
+	}
+}
+
+func TestViewCacheMatchesFreshRender(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	f.contexts = syntheticContexts(200)
+	writeConfig(t, config{Pins: f.contexts[10:12], Aliases: map[string]string{"x": f.contexts[30]}})
+	m := runKeys(t, initialModel(f.contexts, f.contexts[5], loadConfig(), "", false))
+
+	keys := []tea.Msg{
+		tea.KeyMsg{Type: tea.KeyPgDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyCtrlH},
+		typeText("risk"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyBackspace},
+		tea.KeyMsg{Type: tea.KeyCtrlP}, tea.KeyMsg{Type: tea.KeyEnd}, tea.KeyMsg{Type: tea.KeyPgUp},
+	}
+	for _, k := range keys {
+		m = sendMsg(m, k)
+		fresh := m
+		fresh.view = nil
+		if got, want := m.View(), fresh.View(); got != want {
+			t.Fatalf("after %v the cached view differs from a fresh render\n--- cached ---\n%s\n--- fresh ---\n%s", k, got, want)
+		}
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	arn := "arn:aws:eks:us-east-1:111122223333:cluster/payments-pdn"
+	tests := []struct {
+		s        string
+		width    int
+		strategy string
+		want     string
+	}{
+		{arn, 80, "", arn},
+		{arn, 50, "", "arn:aws:eks:…:111122223333:cluster/payments-pdn"},
+		{arn, 40, "", "arn:aws:eks:…:cluster/payments-pdn"},
+		{arn, 24, "", "…:cluster/payments-pdn"},
+		{arn, 10, "", "…ments-pdn"},
+		{arn, 20, "start", "…luster/payments-pdn"},
+		{arn, 20, "end", "arn:aws:eks:us-east…"},
+		{"gke_acme-platform_europe-west1_data-pipeline-prod", 30, "", "gke_acme-…1_data-pipeline-prod"},
+	}
+	for _, tt := range tests {
+		if got := truncateName(tt.s, tt.width, tt.strategy); got != tt.want {
+			t.Errorf("truncateName(%q, %d, %q) = %q, want %q", tt.s, tt.width, tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestProviderBadge(t *testing.T) {
+	tests := []struct {
+		ctx, server, want string
+	}{
+		{testContexts[0], "", "aws"},
+		{"prod-eks-1", "", "aws"},
+		{"geeks", "", ""},
+		{"oaks-staging", "", ""},
+		{"payments", "https://ABC123.gr7.us-east-1.eks.amazonaws.com", "aws"},
+		{"payments", "https://payments-dns-1a2b.hcp.eastus.azmk8s.io:443", "azure"},
+		{"payments", "https://payments.example.com/eks", ""},
+		{"team_aks", "", "azure"},
+		{"gke_proj_us-central1_web", "", "gcp"},
+		{"kind-dev", "https://127.0.0.1:6443", "kind"},
+	}
+	for _, tt := range tests {
+		if got := providerBadge(tt.ctx, tt.server, nil); got != tt.want {
+			t.Errorf("providerBadge(%q, %q) = %q, want %q", tt.ctx, tt.server, got, tt.want)
+		}
+	}
+	// User rules win, and may match the server alone
+	rules := []iconRule{{Server: `\.corp\.internal`, Icon: "onprem"}, {Match: `-pdn$`, Icon: "🔥"}}
+	if got := providerBadge("payments", "https://k8s.corp.internal:6443", rules); got != "onprem" {
+		t.Errorf("server rule: %q", got)
+	}
+	if got := providerBadge("payments-pdn", "", rules); got != "🔥" {
+		t.Errorf("name rule: %q", got)
+	}
+}
+
+func TestStatusLine(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText("search"))
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = next.(model)
+	if !strings.Contains(m.status, "pinned search-prod") || cmd == nil {
+		t.Fatalf("status after Ctrl+P = %q, cmd = %v", m.status, cmd)
+	}
+	first := m.statusID
+
+	// A newer message outlives the older one's timer
+	m = sendMsg(m, tea.KeyMsg{Type: tea.KeyCtrlH})
+	m = sendMsg(m, clearStatusMsg{id: first})
+	if m.status != "short names on" {
+		t.Fatalf("status = %q after the first message's timer", m.status)
+	}
+	m = sendMsg(m, clearStatusMsg{id: m.statusID})
+	if m.status != "" {
+		t.Errorf("status = %q after its own timer", m.status)
+	}
+}
+
+func TestHelpOverlayKeepsAIPrefix(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText("?"))
+	if !m.showHelp {
+		t.Fatal("? on an empty filter didn't open the help overlay")
+	}
+	m = sendMsg(m, typeText("p"))
+	if m.showHelp || m.search != "?p" {
+		t.Errorf("typing in the overlay: showHelp = %v, search = %q, want a \"?p\" AI query", m.showHelp, m.search)
+	}
+
+	m = sendMsg(sendMsg(m, tea.KeyMsg{Type: tea.KeyEscape}), typeText("?"))
+	m = sendMsg(m, tea.KeyMsg{Type: tea.KeyEscape})
+	if m.showHelp || m.quitting {
+		t.Errorf("Esc in the overlay: showHelp = %v, quitting = %v, want it closed", m.showHelp, m.quitting)
+	}
+}
+
+func TestSidebarFiltersList(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	writeConfig(t, config{
+		Groups:  map[string][]string{"payments": testContexts[:3]},
+		History: []string{"docker-desktop"},
+		Pins:    []string{testContexts[3]},
+	})
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), tea.KeyMsg{Type: tea.KeyCtrlB})
+	if !loadConfig().ShowSidebar {
+		t.Fatal("Ctrl+B didn't persist the sidebar")
+	}
+
+	shown := func() []string {
+		var names []string
+		for _, idx := range m.filtered {
+			names = append(names, shortName(m.contexts[idx]))
+		}
+		return names
+	}
+	m = sendMsg(m, tea.KeyMsg{Type: tea.KeyLeft})
+	for _, tt := range []struct {
+		item string
+		want []string
+	}{
+		{sidebarPinned, []string{"search-prod"}},
+		{sidebarRecent, []string{"payments-dev", "docker-desktop"}},
+		{"payments", []string{"payments-dev", "payments-qa", "payments-prod"}},
+	} {
+		m = sendMsg(m, tea.KeyMsg{Type: tea.KeyDown})
+		if got := m.sidebarItems()[m.sidebarCursor]; got != tt.item {
+			t.Fatalf("sidebar on %q, want %q", got, tt.item)
+		}
+		if got := shown(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s shows %v, want %v", tt.item, got, tt.want)
+		}
+	}
+
+	// Enter hands focus back to the list, where ↓ moves the cursor again
+	m = sendMsg(sendMsg(m, tea.KeyMsg{Type: tea.KeyEnter}), tea.KeyMsg{Type: tea.KeyDown})
+	if m.sidebarFocus || m.cursor != 1 || m.activeGroup != "payments" {
+		t.Errorf("after Enter and ↓: sidebarFocus = %v, cursor = %d, group = %q", m.sidebarFocus, m.cursor, m.activeGroup)
+	}
+}
+
+func TestSortModes(t *testing.T) {
+	f := newFakeKube(t, "docker-desktop")
+	now := time.Now().Unix()
+	writeConfig(t, config{
+		Pins:   []string{testContexts[3]},
+		Groups: map[string][]string{"b-qa": {testContexts[1]}, "a-prod": {testContexts[2], testContexts[3]}},
+		HistoryLog: []historyEntry{
+			{Context: testContexts[2], Time: now - 40*86400},
+			{Context: testContexts[2], Time: now - 35*86400},
+			{Context: testContexts[2], Time: now - 32*86400},
+			{Context: testContexts[1], Time: now - 3600},
+			{Context: testContexts[0], Time: now - 60},
+		},
+	})
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false))
+
+	order := func() []string {
+		var names []string
+		for _, idx := range m.filtered {
+			names = append(names, shortName(m.contexts[idx]))
+		}
+		return names
+	}
+	for _, tt := range []struct {
+		mode string
+		want []string
+	}{
+		{"alpha", []string{"payments-dev", "payments-qa", "payments-prod", "search-prod", "docker-desktop"}},
+		{"recent", []string{"docker-desktop", "payments-dev", "payments-qa", "payments-prod", "search-prod"}},
+		{"frecency", []string{"payments-dev", "payments-qa", "payments-prod", "search-prod", "docker-desktop"}},
+		{"group", []string{"payments-prod", "search-prod", "payments-qa", "payments-dev", "docker-desktop"}},
+		{"pins", []string{"search-prod", "payments-dev", "payments-qa", "payments-prod", "docker-desktop"}},
+	} {
+		m = sendMsg(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+		if m.sortMode() != tt.mode || loadConfig().Sort != tt.mode {
+			t.Fatalf("Ctrl+S gave mode %q (saved %q), want %q", m.sortMode(), loadConfig().Sort, tt.mode)
+		}
+		if got := order(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s order = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestKubeconfigFiles(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	dir := t.TempDir()
+	work, home := filepath.Join(dir, "work.yaml"), filepath.Join(dir, "config")
+	t.Setenv("KUBECONFIG", work+string(os.PathListSeparator)+home)
+	f.files = map[string]string{"docker-desktop": home}
+	for _, ctx := range testContexts[:4] {
+		f.files[ctx] = work
+	}
+
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false))
+	assertGolden(t, "kubeconfig_files", m.View())
+	// Contexts ksw knows nothing about get the file they come from as origin
+	if p := loadConfig().Provenance["docker-desktop"]; p.Source != "file" || p.Detail != home {
+		t.Errorf("docker-desktop provenance = %+v, want file %s", p, home)
+	}
+
+	for _, tt := range []struct {
+		search string
+		want   []string
+	}{
+		{"file:config", []string{"docker-desktop"}},
+		{"file:WORK qa", []string{"payments-qa"}},
+		{"prod file:work.yaml", []string{"search-prod", "payments-prod"}},
+		{"file:nope", nil},
+	} {
+		m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText(tt.search))
+		var got []string
+		for _, idx := range m.filtered {
+			got = append(got, shortName(m.contexts[idx]))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q shows %v, want %v", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestKubeconfigLabels(t *testing.T) {
+	labels := kubeconfigLabels(map[string]string{
+		"a": "/home/me/.kube/config",
+		"b": "/home/me/work/config",
+		"c": "/home/me/eks.yaml",
+	})
+	want := map[string]string{
+		"/home/me/.kube/config": ".kube/config",
+		"/home/me/work/config":  "work/config",
+		"/home/me/eks.yaml":     "eks.yaml",
+	}
+	if !maps.Equal(labels, want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+}
+
+func TestPreviewHistory(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	prevLocal := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = prevLocal })
+	day := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	var log []historyEntry
+	for i := range 5 {
+		log = append(log, historyEntry{Context: testContexts[3], Time: day.Add(time.Duration(i) * 24 * time.Hour).Unix()})
+	}
+	log = append(log, historyEntry{Context: testContexts[1], Time: day.Unix()})
+	writeConfig(t, config{HistoryLog: log, Provenance: map[string]provenance{testContexts[3]: {Source: "eks", Detail: "profile prod, us-east-1"}}})
+
+	m := initialModel(f.contexts, f.current, loadConfig(), "", false)
+	for _, tt := range []struct {
+		ctx, want string
+	}{
+		{testContexts[3], "5 switches · last 2026-10-05 09:30, 2026-10-04 09:30, 2026-10-03 09:30"},
+		{testContexts[1], "1 switch · 2026-10-01 09:30"},
+		{testContexts[0], "never switched to"},
+	} {
+		if got := m.usagePreview(tt.ctx); got != tt.want {
+			t.Errorf("usagePreview(%s) = %q, want %q", shortName(tt.ctx), got, tt.want)
+		}
+	}
+	m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown})
+	assertGolden(t, "preview_history", m.View())
+}
+
+func TestPrefill(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := initialModel(f.contexts, f.current, loadConfig(), "", false)
+	m.prefill("prod")
+	m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != testContexts[3] {
+		t.Errorf("chosen = %q, want the best match for 'prod', %s", m.chosen, testContexts[3])
+	}
+
+	m = initialModel(f.contexts, f.current, loadConfig(), "", false)
+	m.prefill("prod")
+	m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyEscape})
+	if m.search != "" || len(m.filtered) != len(testContexts) {
+		t.Errorf("Esc left search = %q with %d contexts, want the full list", m.search, len(m.filtered))
+	}
+}
+
+func TestAutoSelectSingle(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	m := runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText("search"))
+	if m.chosen != "" {
+		t.Fatalf("chose %s without auto_select_single", m.chosen)
+	}
+
+	writeConfig(t, config{AutoSelect: true})
+	m = runKeys(t, initialModel(f.contexts, f.current, loadConfig(), "", false), typeText("pay"))
+	if m.chosen != "" {
+		t.Fatalf("chose %s while 3 contexts matched", m.chosen)
+	}
+	m = runKeys(t, m, typeText("ments-q"))
+	if m.chosen != testContexts[1] {
+		t.Errorf("chosen = %q once only payments-qa matched, want %s", m.chosen, testContexts[1])
+	}
+
+	m = initialModel(f.contexts, f.current, loadConfig(), "", false)
+	m.prefill("docker")
+	if m.chosen != "docker-desktop" {
+		t.Errorf("prefill chose %q, want docker-desktop", m.chosen)
+	}
+}
+
+func TestStartCursor(t *testing.T) {
+	f := newFakeKube(t, testContexts[2])
+	// The newest history entry is gone from the kubeconfig and the next is
+	// the current context, so recent lands on payments-qa
+	history := []string{"docker-desktop-old", testContexts[2], testContexts[1], testContexts[0]}
+	under := func(mode string) string {
+		m := initialModel(f.contexts, f.current, config{StartCursor: mode, History: history}, "", false)
+		return m.contexts[m.filtered[m.cursor]]
+	}
+	if got := under(""); got != testContexts[2] {
+		t.Errorf("default start on %s, want the current context", got)
+	}
+	if got := under("recent"); got != testContexts[1] {
+		t.Errorf("start_cursor recent starts on %s, want %s", got, testContexts[1])
+	}
+	if got := under("top"); got != f.contexts[0] {
+		t.Errorf("start_cursor top starts on %s", got)
+	}
+
+	m := initialModel(f.contexts, f.current, config{StartCursor: "recent"}, "", false)
+	if got := m.contexts[m.filtered[m.cursor]]; got != testContexts[2] {
+		t.Errorf("recent without history starts on %s, want the current context", got)
+	}
+	if m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter}); m.chosen != testContexts[2] {
+		t.Errorf("enter chose %s", m.chosen)
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	cfg := config{
+		HistoryLog: []historyEntry{
+			{Context: testContexts[3], From: "docker-desktop", Time: 1},
+			{Context: testContexts[2], From: testContexts[3], Time: 2},
+			{Context: testContexts[0], From: testContexts[2], Time: 3},
+		},
+		History: []string{testContexts[2], testContexts[3], "docker-desktop", testContexts[1]},
+	}
+	shown := func(m model) []string {
+		var names []string
+		for _, i := range m.filtered {
+			names = append(names, shortName(m.contexts[i]))
+		}
+		return names
+	}
+
+	m := runKeys(t, initialModel(f.contexts, f.current, cfg, "", false), tea.KeyMsg{Type: tea.KeyCtrlR})
+	if want := []string{"payments-prod", "search-prod", "docker-desktop", "payments-qa"}; !slices.Equal(shown(m), want) {
+		t.Errorf("Ctrl+R shows %v, want %v", shown(m), want)
+	}
+	if !strings.Contains(m.View(), "(reverse-i-search)") {
+		t.Error("no reverse-i-search prompt")
+	}
+
+	// Matches stay newest first: prod matches payments-prod better than
+	// search-prod, but each keeps its place in the history
+	m = runKeys(t, m, typeText("prod"))
+	if want := []string{"payments-prod", "search-prod"}; !slices.Equal(shown(m), want) {
+		t.Errorf("Ctrl+R prod shows %v, want %v", shown(m), want)
+	}
+	m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyCtrlR}, tea.KeyMsg{Type: tea.KeyCtrlR}, tea.KeyMsg{Type: tea.KeyEnter},
+		tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != testContexts[3] {
+		t.Errorf("Ctrl+R twice chose %s, want the older match %s", m.chosen, testContexts[3])
+	}
+
+	m = runKeys(t, initialModel(f.contexts, f.current, cfg, "", false), tea.KeyMsg{Type: tea.KeyCtrlR}, typeText("q"),
+		tea.KeyMsg{Type: tea.KeyEscape})
+	if m.historySearch || m.search != "" || len(m.filtered) != len(f.contexts) {
+		t.Errorf("Esc left history search on %v with search %q", shown(m), m.search)
+	}
+}
+
+func TestGroupColors(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	cfg := config{
+		Groups:       map[string][]string{"dev": testContexts[:2], "payments": testContexts[:3], "prod": testContexts[2:4]},
+		GroupDefault: map[string]string{"payments": testContexts[1]},
+		GroupColors:  map[string]string{"dev": "#50fa7b", "payments": "#bd93f9", "prod": "#ff5555"},
+	}
+	m := initialModel(f.contexts, f.current, cfg, "", false)
+	for ctx, want := range map[string]string{
+		testContexts[0]:  "#50fa7b", // first colored group by name
+		testContexts[1]:  "#bd93f9", // payments' default
+		testContexts[3]:  "#ff5555",
+		"docker-desktop": "",
+	} {
+		if got := m.groupColor(ctx); got != want {
+			t.Errorf("groupColor(%s) = %q, want %q", ctx, got, want)
+		}
+	}
+	m = initialModel(f.contexts, f.current, cfg, "payments", false)
+	if got := m.groupColor(testContexts[0]); got != "#bd93f9" {
+		t.Errorf("with the payments filter, groupColor = %q, want the filter's color", got)
+	}
+
+	// Rows keep their width with or without a marker
+	plain := runKeys(t, initialModel(f.contexts, f.current, config{}, "", false)).View()
+	colored := runKeys(t, initialModel(f.contexts, f.current, cfg, "", false)).View()
+	if !strings.Contains(colored, "▎") || strings.Contains(plain, "▎") {
+		t.Error("the marker should show only for contexts in a colored group")
+	}
+	if c, ok := parseGroupColor("Red"); !ok || c != "#ff5555" {
+		t.Errorf("parseGroupColor(Red) = %q, %v", c, ok)
+	}
+	if _, ok := parseGroupColor("#12345"); ok {
+		t.Error("parseGroupColor accepted #12345")
+	}
+}
+
+func TestProdConfirm(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m := runKeys(t, initialModel(f.contexts, f.current, config{}, "", false), typeText("payments-prod"), enter)
+	if m.chosen != "" || m.confirmProd != testContexts[2] {
+		t.Fatalf("one Enter on a prod context chose %q", m.chosen)
+	}
+	if !strings.Contains(m.View(), "Enter again") {
+		t.Error("no confirmation prompt in the footer")
+	}
+	if m = runKeys(t, m, tea.KeyMsg{Type: tea.KeyDown}, enter); m.chosen != "" {
+		t.Errorf("a key in between didn't cancel the confirmation: chose %q", m.chosen)
+	}
+	if m = runKeys(t, m, enter); m.chosen != testContexts[2] {
+		t.Errorf("second Enter chose %q", m.chosen)
+	}
+
+	// Not protected: one Enter, as before
+	m = runKeys(t, initialModel(f.contexts, f.current, config{}, "", false), typeText("payments-qa"), enter)
+	if m.chosen != testContexts[1] {
+		t.Errorf("Enter on payments-qa chose %q", m.chosen)
+	}
+	// protected replaces the default patterns, prod_confirm off skips the prompt
+	m = runKeys(t, initialModel(f.contexts, f.current, config{Protected: []string{"*qa*"}}, "", false), typeText("payments-prod"), enter)
+	if m.chosen != testContexts[2] {
+		t.Errorf("payments-prod isn't protected under *qa*, chose %q", m.chosen)
+	}
+	m = runKeys(t, initialModel(f.contexts, f.current, config{ProdConfirm: "off"}, "", false), typeText("search-prod"), enter)
+	if m.chosen != testContexts[3] {
+		t.Errorf("prod_confirm off chose %q", m.chosen)
+	}
+
+	// auto_select_single waits for the Enter on a prod match
+	m = runKeys(t, initialModel(f.contexts, f.current, config{AutoSelect: true}, "", false), typeText("search"))
+	if m.chosen != "" || m.confirmProd != testContexts[3] {
+		t.Errorf("auto select on a prod context chose %q", m.chosen)
+	}
+	if m = runKeys(t, m, enter); m.chosen != testContexts[3] {
+		t.Errorf("Enter after auto select chose %q", m.chosen)
+	}
+}
+
+func TestLocalClustersAfterFirstPaint(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	f.contexts = append(f.contexts, "kind-dev", "minikube")
+	m := initialModel(f.contexts, f.current, loadConfig(), "", false)
+	m.terminalHeight = 30
+	if strings.Contains(m.View(), "running") {
+		t.Fatal("local clusters detected before the first paint")
+	}
+	m = sendMsg(m, localClustersMsg{"kind-dev": true, "minikube": false})
+	view := m.View()
+	if !strings.Contains(view, "running") || !strings.Contains(view, "stopped") {
+		t.Errorf("running/stopped badges missing once detected:\n%s", view)
+	}
+}
+
+func TestServersAfterFirstPaint(t *testing.T) {
+	f := newFakeKube(t, testContexts[0])
+	f.contexts = append(f.contexts, "payments")
+	f.servers = map[string]string{"payments": "https://payments-dns-1a2b.hcp.eastus.azmk8s.io:443"}
+	m := initialModel(f.contexts, f.current, loadConfig(), "", false)
+	m.terminalHeight = 30
+	if strings.Contains(m.View(), "azure") {
+		t.Fatal("API servers read before the first paint")
+	}
+	m = sendMsg(m, loadServersCmd())
+	if view := m.View(); !strings.Contains(view, "azure") {
+		t.Errorf("server badge missing once the servers are loaded:\n%s", view)
+	}
+}
+
+func TestOpensTUI(t *testing.T) {
+	for _, args := range [][]string{nil, {"--tui", "prod"}, {"/prod"}, {"--print-only"}, {"--archived"}} {
+		if !opensTUI(args) {
+			t.Errorf("opensTUI(%q) = false", args)
+		}
+	}
+	for _, args := range [][]string{{"prod"}, {"-"}, {"ls"}, {"k", "get", "pods"}} {
+		if opensTUI(args) {
+			t.Errorf("opensTUI(%q) = true", args)
+		}
+	}
+}
+
+// panicky is a model that panics wherever it's told to
+type panicky struct{ in string }
+
+func (p panicky) Init() tea.Cmd { return nil }
+func (p panicky) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if p.in == "update" {
+		panic("boom in update")
+	}
+	return p, tea.Batch(func() tea.Msg { panic("boom in a cmd") }, tea.ClearScreen)
+}
+func (p panicky) View() string {
+	if p.in == "view" {
+		panic("boom in view")
+	}
+	return "ok"
+}
+
+func TestCrashGuard(t *testing.T) {
+	isQuit := func(cmd tea.Cmd) bool {
+		_, ok := cmd().(tea.QuitMsg)
+		return cmd != nil && ok
+	}
+
+	c := &tuiCrash{}
+	_, cmd := crashGuard{Model: panicky{in: "update"}, crash: c}.Update(tea.KeyMsg{})
+	if c.value != "boom in update" || !isQuit(cmd) {
+		t.Errorf("panic in Update: recorded %v, quit %v", c.value, isQuit(cmd))
+	}
+
+	// A Cmd panics on another goroutine; the guard turns it into a message
+	// that quits, even when it's inside a Batch
+	c = &tuiCrash{}
+	g := crashGuard{Model: panicky{}, crash: c}
+	_, cmd = g.Update(tea.KeyMsg{})
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("Batch not kept: %#v", batch)
+	}
+	msg := batch[0]()
+	if _, ok := msg.(crashMsg); !ok || c.value != "boom in a cmd" || !strings.Contains(string(c.stack), "TestCrashGuard") {
+		t.Errorf("panic in a Cmd: msg %#v, recorded %v", msg, c.value)
+	}
+	if _, cmd := g.Update(msg); !isQuit(cmd) {
+		t.Error("crashMsg doesn't quit")
+	}
+
+	c = &tuiCrash{}
+	quit := make(chan bool, 1)
+	c.quit = func() { quit <- true }
+	if view := (crashGuard{Model: panicky{in: "view"}, crash: c}).View(); view != "" || c.value != "boom in view" {
+		t.Errorf("panic in View: %q, recorded %v", view, c.value)
+	}
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Error("panic in View doesn't quit")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ── Tunnels ────────────────────────────────────────────
+
+// tunnelConfig describes a VPN/SSH tunnel a context needs before kubectl can reach it
+type tunnelConfig struct {
+	Match     string `json:"match"`            // glob pattern matched against the context name
+	Command   string `json:"command"`          // run with sh -c, left running in the background
+	Health    string `json:"health,omitempty"` // http(s) URL or host:port; empty = the context's API server
+	AutoStart bool   `json:"auto_start,omitempty"`
+}
+
+const tunnelStartTimeout = 20 * time.Second
+
+// findTunnel returns the first tunnel whose pattern matches ctx
+func findTunnel(tunnels []tunnelConfig, ctx string) (tunnelConfig, bool) {
+	for _, t := range tunnels {
+		if globMatch(t.Match, ctx) {
+			return t, true
+		}
+	}
+	return tunnelConfig{}, false
+}
+
+// healthTarget is what proves the tunnel for ctx up: its health setting, or
+// else ctx's API server. It reads the kubeconfig, so callers look it up
+// once rather than on every poll.
+func (t tunnelConfig) healthTarget(ctx string) string {
+	if t.Health != "" {
+		return t.Health
+	}
+	return getContextServers()[ctx]
+}
+
+// healthy reports whether target, from healthTarget, answers. Any HTTP
+// response counts: a 401 from an API server still proves the path is up.
+func healthy(target string) bool {
+	if target == "" {
+		return false
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		client := &http.Client{
+			Timeout:   2 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+		resp, err := client.Get(target)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}
+	conn, err := net.DialTimeout("tcp", target, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// start launches the tunnel command detached and waits until target is healthy
+func (t tunnelConfig) start(ctx, target string) error {
+	logDir := filepath.Join(os.TempDir(), "ksw-tunnels")
+	_ = os.MkdirAll(logDir, 0755)
+	logPath := filepath.Join(logDir, strings.NewReplacer("/", "_", ":", "_").Replace(shortName(ctx))+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command("sh", "-c", t.Command)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start tunnel: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+
+	deadline := time.Now().Add(tunnelStartTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+		if healthy(target) {
+			return nil
+		}
+	}
+	return fmt.Errorf("tunnel not healthy after %s (log: %s)", tunnelStartTimeout, logPath)
+}
+
+// ensureTunnel is run from the switch hooks: it verifies the tunnel for ctx
+// and starts it when auto_start is set, otherwise tells the user how to.
+func ensureTunnel(tunnels []tunnelConfig, ctx string) {
+	t, ok := findTunnel(tunnels, ctx)
+	if !ok {
+		return
+	}
+	target := t.healthTarget(ctx)
+	if healthy(target) {
+		return
+	}
+	if !t.AutoStart {
+		fmt.Fprintf(os.Stderr, "%s Tunnel for %s is down. Start it with: ksw tunnel up\n", warnStyle.Render("!"), shortName(ctx))
+		return
+	}
+	fmt.Printf("%s Starting tunnel for %s...\n", dimStyle.Render("·"), shortName(ctx))
+	if err := t.start(ctx, target); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", warnStyle.Render("✗"), err)
+		return
+	}
+	fmt.Printf("%s Tunnel up\n", successStyle.Render("✔"))
+}
+
+// ── handleTunnel ───────────────────────────────────────
+
+func handleTunnel(cfg config) {
+	sub := "ls"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+
+	switch sub {
+	case "ls", "list":
+		if len(cfg.Tunnels) == 0 {
+			fmt.Println(dimStyle.Render("No tunnels configured. Use: ksw tunnel set <pattern> --cmd \"<command>\""))
+			return
+		}
+		for _, t := range cfg.Tunnels {
+			auto := ""
+			if t.AutoStart {
+				auto = " " + dimStyle.Render("(auto)")
+			}
+			health := t.Health
+			if health == "" {
+				health = "api server"
+			}
+			fmt.Printf("  %s%s\n    %s %s\n    %s %s\n", aliasStyle.Render(t.Match), auto,
+				dimStyle.Render("cmd:   "), t.Command, dimStyle.Render("health:"), health)
+		}
+
+	case "set", "add":
+		// ksw tunnel set <pattern> --cmd "<command>" [--health <url|host:port>] [--auto]
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw tunnel set <pattern> --cmd \"<command>\" [--health <url|host:port>] [--auto]")
+			os.Exit(1)
+		}
+		t := tunnelConfig{Match: os.Args[3]}
+		for i := 4; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--cmd":
+				if i+1 < len(os.Args) {
+					t.Command = os.Args[i+1]
+					i++
+				}
+			case "--health":
+				if i+1 < len(os.Args) {
+					t.Health = os.Args[i+1]
+					i++
+				}
+			case "--auto":
+				t.AutoStart = true
+			}
+		}
+		if t.Command == "" {
+			fmt.Fprintf(os.Stderr, "%s --cmd is required.\n", warnStyle.Render("✗"))
+			os.Exit(1)
+		}
+		// Replace an existing rule for the same pattern
+		var kept []tunnelConfig
+		for _, existing := range cfg.Tunnels {
+			if existing.Match != t.Match {
+				kept = append(kept, existing)
+			}
+		}
+		cfg.Tunnels = append(kept, t)
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Tunnel set for %s\n", successStyle.Render("✔"), aliasStyle.Render(t.Match))
+
+	case "rm", "remove", "delete":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: ksw tunnel rm <pattern>")
+			os.Exit(1)
+		}
+		var kept []tunnelConfig
+		for _, t := range cfg.Tunnels {
+			if t.Match != os.Args[3] {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == len(cfg.Tunnels) {
+			fmt.Fprintf(os.Stderr, "%s No tunnel for '%s'.\n", warnStyle.Render("✗"), os.Args[3])
+			os.Exit(1)
+		}
+		cfg.Tunnels = kept
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Removed tunnel for %s\n", successStyle.Render("✔"), os.Args[3])
+
+	case "up", "status":
+		// ksw tunnel up|status [context] — defaults to the current context
+		ctx := getCurrentContext()
+		if len(os.Args) >= 4 {
+			ctx = mustResolveContext(os.Args[3])
+		}
+		t, ok := findTunnel(cfg.Tunnels, ctx)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s No tunnel configured for %s.\n", warnStyle.Render("✗"), shortName(ctx))
+			os.Exit(1)
+		}
+		target := t.healthTarget(ctx)
+		if healthy(target) {
+			fmt.Printf("%s Tunnel for %s is up\n", successStyle.Render("✔"), shortName(ctx))
+			return
+		}
+		if sub == "status" {
+			fmt.Printf("%s Tunnel for %s is down\n", warnStyle.Render("✗"), shortName(ctx))
+			os.Exit(1)
+		}
+		fmt.Printf("%s Starting tunnel for %s...\n", dimStyle.Render("·"), shortName(ctx))
+		if err := t.start(ctx, target); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("%s Tunnel up\n", successStyle.Render("✔"))
+
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: ksw tunnel [ls|set|rm|up|status]")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// ── ksw version --verbose ──────────────────────────────
+
+// Build metadata, set by release builds and packagers with
+//
+//	go build -ldflags "-X main.commit=<sha> -X main.buildDate=<RFC 3339> -X main.builtBy=homebrew"
+//
+// version itself stays a constant in main.go, which scripts/release.sh
+// checks against the tag. Plain go build / go install fill commit and date
+// from the VCS stamp instead.
+var (
+	commit    string
+	buildDate string
+	builtBy   string
+)
+
+// buildInfo is what ksw version --verbose reports about the binary
+type buildInfo struct {
+	Version   string
+	Commit    string
+	Dirty     bool
+	Date      string
+	GoVersion string
+	Platform  string
+	BuiltBy   string
+}
+
+func currentBuild() buildInfo {
+	b := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		BuiltBy:   builtBy,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if b.Commit == "" {
+					b.Commit = s.Value
+				}
+			case "vcs.time":
+				if b.Date == "" {
+					b.Date = s.Value
+				}
+			case "vcs.modified":
+				b.Dirty = s.Value == "true"
+			}
+		}
+	}
+	if len(b.Commit) > 12 {
+		b.Commit = b.Commit[:12]
+	}
+	return b
+}
+
+// enabledFeatures lists the optional subsystems cfg turns on, the first
+// thing to know when a bug only shows up for some people
+func enabledFeatures(cfg config) []string {
+	var on []string
+	if cfg.AI.Provider != "" {
+		on = append(on, "ai ("+cfg.AI.Provider+")")
+	}
+	if cfg.Integrations.Slack.Enabled {
+		on = append(on, "slack")
+	}
+	if len(cfg.Tunnels) > 0 {
+		on = append(on, fmt.Sprintf("tunnels (%d)", len(cfg.Tunnels)))
+	}
+	if n := len(cfg.Freeze.Windows); n > 0 || cfg.Freeze.URL != "" {
+		s := fmt.Sprintf("freeze windows (%d)", n)
+		if cfg.Freeze.URL != "" {
+			s += " + url"
+		}
+		on = append(on, s)
+	}
+	if cfg.PolicyURL != "" {
+		on = append(on, "policy_url")
+	}
+	if cfg.KubectxSync {
+		on = append(on, "kubectx sync")
+	}
+	if cfg.AutoSelect {
+		on = append(on, "auto_select_single")
+	}
+	if cfg.Sort != "" {
+		on = append(on, "sort "+cfg.Sort)
+	}
+	if cfg.StartCursor != "" {
+		on = append(on, "start_cursor "+cfg.StartCursor)
+	}
+	if cfg.RecordQueries {
+		on = append(on, "record_queries")
+	}
+	if cfg.Timeout > 0 {
+		on = append(on, fmt.Sprintf("timeout %ds", cfg.Timeout))
+	}
+	for _, name := range featureNames() {
+		if featureOn(cfg, name) {
+			on = append(on, "experimental "+name)
+		}
+	}
+	return on
+}
+
+// kubectlClientVersion is kubectl's own version, or why it isn't usable
+func kubectlClientVersion() string {
+	path, err := exec.LookPath("kubectl")
+	if err != nil {
+		return "not found in PATH"
+	}
+	out, err := output("kubectl", "version", "--client", "-o", "json")
+	var v struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err != nil || json.Unmarshal(out, &v) != nil {
+		return path
+	}
+	return path + " (" + v.ClientVersion.GitVersion + ")"
+}
+
+// handleVersion: ksw version [--verbose], also ksw -v --verbose
+func handleVersion(cfg config) {
+	fmt.Printf("ksw v%s\n", version)
+	verbose := false
+	for _, a := range os.Args[2:] {
+		verbose = verbose || a == "--verbose" || a == "-V"
+	}
+	if !verbose {
+		return
+	}
+
+	b := currentBuild()
+	row := func(label, value string) {
+		if value == "" {
+			value = dimStyle.Render("unknown")
+		}
+		fmt.Printf("  %-11s %s\n", label, value)
+	}
+	c := b.Commit
+	if c != "" && b.Dirty {
+		c += " (modified)"
+	}
+	row("commit", c)
+	row("built", b.Date)
+	row("built by", b.BuiltBy)
+	row("go", b.GoVersion)
+	row("platform", b.Platform)
+
+	configFile := configPath()
+	if p := activeProfileName(cfg); p != "" {
+		configFile += " (profile " + p + ")"
+	}
+	if _, err := os.Stat(configPath()); os.IsNotExist(err) {
+		configFile += dimStyle.Render(" (not created yet)")
+	}
+	row("config", configFile)
+	for i, p := range kubeconfigPaths() {
+		label := ""
+		if i == 0 {
+			label = "kubeconfig"
+		}
+		if _, err := os.Stat(p); err != nil {
+			p += dimStyle.Render(" (missing)")
+		}
+		fmt.Printf("  %-11s %s\n", label, p)
+	}
+	row("kubectl", kubectlClientVersion())
+	features := strings.Join(enabledFeatures(cfg), ", ")
+	if features == "" {
+		features = dimStyle.Render("none")
+	}
+	row("features", features)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ── ksw wait ───────────────────────────────────────────
+
+// defaultWaitTimeout is how long ksw wait waits without --timeout
+const defaultWaitTimeout = time.Minute
+
+// waitInterval is the pause between two tries of ksw wait
+var waitInterval = time.Second
+
+// handleWait blocks until a context's API server answers an authenticated
+// request: ksw wait <ctx> [--timeout 60s]. The context doesn't have to exist
+// yet, so it can follow kind create cluster or minikube start right away.
+func handleWait(cfg config) {
+	name, timeout := "", defaultWaitTimeout
+	args := os.Args[2:]
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: ksw wait <context> [--timeout 60s]")
+		os.Exit(1)
+	}
+	for i := 0; i < len(args); i++ {
+		value, isTimeout := strings.CutPrefix(args[i], "--timeout=")
+		if args[i] == "--timeout" && i+1 < len(args) {
+			i++
+			value, isTimeout = args[i], true
+		}
+		if isTimeout {
+			d, err := parseSince(value)
+			if err != nil || d <= 0 {
+				fmt.Fprintf(os.Stderr, "%s invalid --timeout '%s' (use e.g. 60s, 5m)\n", warnStyle.Render("✗"), value)
+				os.Exit(1)
+			}
+			timeout = d
+			continue
+		}
+		if name != "" || strings.HasPrefix(args[i], "-") {
+			usage()
+		}
+		name = args[i]
+	}
+	if name == "" {
+		usage()
+	}
+	name, err := aliasTarget(cfg, name)
+	if err != nil {
+		fatal(err)
+	}
+
+	start := time.Now()
+	var ctx string
+	exitIfInterrupted(runWithProgress(context.Background(), "Waiting for "+name, 1, func(run context.Context, pr *progress) error {
+		ctx, err = waitReady(run, name, timeout)
+		pr.Step()
+		return nil
+	}))
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%s %s is ready %s\n", successStyle.Render("✔"), shortName(ctx), dimStyle.Render("("+formatBenchDuration(time.Since(start))+")"))
+}
+
+// waitReady tries name every waitInterval until its API server answers,
+// then returns the context it resolved to. A name matching no context is
+// tried again too, since the cluster may still be being created; one
+// matching several fails at once. Past timeout, it fails with exitVerify
+// and the last reason it wasn't ready.
+//
+// name is matched exactly, against full or short names: kind-dev mustn't
+// resolve to an existing kind-dev-old while kind-dev is being created.
+func waitReady(run context.Context, name string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		contexts, err := getContexts()
+		var ctx string
+		if err == nil {
+			ctx, err = waitContext(name, contexts)
+		}
+		if err == nil {
+			if err = probeContext(ctx); err == nil {
+				return ctx, nil
+			}
+		} else if exitCode(err) == exitAmbiguous {
+			return "", err
+		}
+		if !time.Now().Add(waitInterval).Before(deadline) {
+			return "", withExitCode(exitVerify, fmt.Errorf("%s wasn't ready after %s: %w", name, timeout, err))
+		}
+		select {
+		case <-run.Done():
+			return "", run.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// waitContext is the context whose full or short name is name
+func waitContext(name string, contexts []string) (string, error) {
+	var matches []string
+	for _, ctx := range contexts {
+		if ctx == name {
+			return ctx, nil
+		}
+		if shortName(ctx) == name {
+			matches = append(matches, ctx)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", withExitCode(exitNotFound, fmt.Errorf("context '%s' not found", name))
+	case 1:
+		return matches[0], nil
+	}
+	return "", withExitCode(exitAmbiguous, fmt.Errorf("ambiguous '%s', matches:\n  %s", name, strings.Join(matches, "\n  ")))
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"time"
+)
+
+// ── Watch mode ─────────────────────────────────────────
+
+// idleReminderConfig controls the reminder ksw watch emits when a prod
+// (protected) context has been left current without any ksw activity
+type idleReminderConfig struct {
+	After   string `json:"after,omitempty"`   // idle period, e.g. "30m"; default 30m
+	Default string `json:"default,omitempty"` // safer context to suggest; default home, then the last non-prod one
+}
+
+const (
+	defaultIdleAfter     = 30 * time.Minute
+	watchPollingInterval = 30 * time.Second
+)
+
+// idleAfter returns the configured idle period, falling back to the default
+func (r idleReminderConfig) idleAfter() time.Duration {
+	if r.After == "" {
+		return defaultIdleAfter
+	}
+	d, err := parseSince(r.After)
+	if err != nil || d <= 0 {
+		return defaultIdleAfter
+	}
+	return d
+}
+
+// safeSuggestion picks the context to suggest leaving prod for: the
+// reminder's default, then the home context, then the last non-prod one
+func safeSuggestion(cfg config) string {
+	if cfg.IdleReminder.Default != "" {
+		return cfg.IdleReminder.Default
+	}
+	if cfg.Home != "" {
+		return cfg.Home
+	}
+	for _, h := range cfg.History {
+		if !isProtected(cfg, h) {
+			return h
+		}
+	}
+	return ""
+}
+
+// lastSwitchTime is the time of the latest ksw switch, zero if none was logged
+func lastSwitchTime(cfg config) time.Time {
+	if len(cfg.HistoryLog) == 0 {
+		return time.Time{}
+	}
+	return time.Unix(cfg.HistoryLog[len(cfg.HistoryLog)-1].Time, 0)
+}
+
+// notifyDesktop shows a desktop notification when the platform has a way to;
+// failures are ignored since the terminal line is always printed too
+func notifyDesktop(title, msg string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", msg, title))
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return
+		}
+		cmd = exec.Command("notify-send", title, msg)
+	default:
+		return
+	}
+	_ = cmd.Run()
+}
+
+// handleWatch runs in the foreground and reminds the user to leave a prod
+// context that has been idle for too long:
+// ksw watch [--idle 30m] [--default <ctx>]
+// ksw watch set [--idle 30m] [--default <ctx>] persists the settings.
+func handleWatch(cfg config) {
+	args := os.Args[2:]
+	persist := len(args) > 0 && args[0] == "set"
+	if persist {
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--idle":
+			if i+1 < len(args) {
+				if _, err := parseSince(args[i+1]); err != nil {
+					fatal(err)
+				}
+				cfg.IdleReminder.After = args[i+1]
+				i++
+			}
+		case "--default":
+			if i+1 < len(args) {
+				cfg.IdleReminder.Default = args[i+1]
+				i++
+			}
+		}
+	}
+	if persist {
+		if err := saveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s Idle prod reminder after %s\n", successStyle.Render("✔"), cfg.IdleReminder.idleAfter())
+		return
+	}
+	overrides := cfg.IdleReminder
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	idle := overrides.idleAfter()
+	fmt.Printf("%s Watching for idle prod contexts (reminder after %s) · Ctrl+C to stop\n", dimStyle.Render("·"), idle)
+
+	current := getCurrentContext()
+	since := time.Now() // when the current context was first seen by watch
+	var reminded time.Time
+	ticker := time.NewTicker(watchPollingInterval)
+	defer ticker.Stop()
+	for {
+		// Re-read the config so switches made from other terminals count as activity
+		live := loadConfig()
+		live.IdleReminder = overrides
+		if now := getCurrentContext(); now != current {
+			current, since, reminded = now, time.Now(), time.Time{}
+		}
+		active := since
+		if t := lastSwitchTime(live); t.After(active) {
+			active = t
+		}
+		if reminded.After(active) {
+			active = reminded
+		}
+
+		if current != "" && isProtected(live, current) && time.Since(active) >= idle {
+			msg := fmt.Sprintf("Still on %s after %s without activity.", shortName(current), idle)
+			if s := safeSuggestion(live); s != "" && s != current {
+				msg += fmt.Sprintf(" Switch back with: ksw %s", s)
+			}
+			fmt.Printf("\a%s %s %s\n", warnStyle.Render("⚠"), dimStyle.Render(time.Now().Format("15:04")), msg)
+			notifyDesktop("ksw: idle prod context", msg)
+			reminded = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case <-ticker.C:
+		}
+	}
+}